@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// botAccount runs the schedule of one configured account. Each account has its own ticker, data lock
+// and status, so one account failing to post, or being rate limited, doesn't hold up the others.
+type botAccount struct {
+	config accountConfig
+
+	ticker   *time.Ticker
+	stop     chan bool
+	running  bool
+	tickLock sync.RWMutex
+
+	// dataLock is held while reading and writing the account's tweets and counters data files,
+	// so posting and the schedule API don't overwrite each other's changes
+	dataLock sync.Mutex
+
+	status botStatus
+}
+
+func newBotAccount(config accountConfig) *botAccount {
+	return &botAccount{
+		config: config,
+		stop:   make(chan bool),
+		status: botStatus{started: time.Now().UTC()},
+	}
+}
+
+// loadAccounts returns a botAccount for each account in the config file
+func loadAccounts(config configuration) ([]*botAccount, error) {
+	accountConfigs, err := config.accountConfigs()
+	if err != nil {
+		return nil, err
+	}
+
+	var accounts []*botAccount
+	for _, accountConfig := range accountConfigs {
+		accounts = append(accounts, newBotAccount(accountConfig))
+	}
+
+	return accounts, nil
+}
+
+// findAccount returns the account called name, which can be left blank when there's only one account
+func findAccount(accounts []*botAccount, name string) (*botAccount, error) {
+	if name == "" {
+		if len(accounts) == 1 {
+			return accounts[0], nil
+		}
+		return nil, fmt.Errorf("there are %d accounts in %s, choose one with -account", len(accounts), *configFile)
+	}
+
+	for _, account := range accounts {
+		if account.config.Name == name {
+			return account, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no account named '%s' in %s", name, *configFile)
+}
+
+func (account *botAccount) isRunning() bool {
+	account.tickLock.RLock()
+	defer account.tickLock.RUnlock()
+
+	return account.running
+}
+
+// startTicker posts the account's tweets as they become due, until stopped. Errors are logged and reported
+// by the account's status rather than stopping the bot, as the other accounts can carry on.
+func (account *botAccount) startTicker() {
+	account.tickLock.Lock()
+	defer account.tickLock.Unlock()
+
+	if account.running {
+		return
+	}
+
+	account.ticker = time.NewTicker(tickInterval)
+	account.running = true
+
+	go func(ticker *time.Ticker) {
+		for {
+			select {
+			case now := <-ticker.C:
+				account.status.recordTick(now.UTC())
+
+				if err := account.postNextTweet(); err != nil {
+					log.Printf("%s: %s\n", account.config.Name, err)
+					account.status.recordError(err, time.Now().UTC())
+				}
+			case <-account.stop:
+				return
+			}
+		}
+	}(account.ticker)
+
+	log.Printf("%s: started\n", account.config.Name)
+}
+
+func (account *botAccount) stopTicker() {
+	account.tickLock.Lock()
+	defer account.tickLock.Unlock()
+
+	if !account.running {
+		return
+	}
+
+	account.ticker.Stop()
+	account.stop <- true
+	account.running = false
+
+	log.Printf("%s: stopped\n", account.config.Name)
+}
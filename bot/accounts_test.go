@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestAccountConfigs(t *testing.T) {
+	config := configuration{
+		Username:  "@legacy",
+		Variables: map[string]string{"site": "example.com"},
+	}
+
+	accounts, err := config.accountConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(accounts) != 1 || accounts[0].Name != "legacy" || accounts[0].DataFile != *dataFile || accounts[0].CountersFile != *countersFile {
+		t.Errorf("expected the top level settings as one account, got %+v", accounts)
+	}
+
+	config.Webhooks = []webhookConfig{{URL: "https://example.com/shared"}}
+	config.Accounts = []accountConfig{
+		{Username: "@first", DataFile: "first.json", Variables: map[string]string{"site": "first.example.com"}},
+		{Name: "second", Username: "@second", DataFile: "second.json", Webhooks: []webhookConfig{{URL: "https://example.com/second"}}},
+	}
+
+	accounts, err = config.accountConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(accounts) != 2 {
+		t.Fatalf("expected 2 accounts, got %d", len(accounts))
+	}
+
+	if accounts[0].Name != "first" || accounts[0].CountersFile != "first-counters.json" || accounts[0].Variables["site"] != "first.example.com" {
+		t.Errorf("unexpected first account: %+v", accounts[0])
+	}
+
+	if accounts[1].Variables["site"] != "example.com" || len(accounts[1].Webhooks) != 2 {
+		t.Errorf("expected the second account to share the top level variables and webhooks, got %+v", accounts[1])
+	}
+
+	var invalidConfigs = []struct {
+		name     string
+		accounts []accountConfig
+	}{
+		{"duplicate name", []accountConfig{{Name: "same", DataFile: "a.json"}, {Name: "same", DataFile: "b.json"}}},
+		{"invalid name", []accountConfig{{Name: "not/valid", DataFile: "a.json"}}},
+		{"no data file", []accountConfig{{Name: "nodata"}}},
+	}
+
+	for _, invalid := range invalidConfigs {
+		if _, err = (configuration{Accounts: invalid.accounts}).accountConfigs(); err == nil {
+			t.Errorf("%s: expected the accounts to be rejected", invalid.name)
+		}
+	}
+}
+
+func TestAccountRoutes(t *testing.T) {
+	firstFile, secondFile := "tweets_first_test.json", "tweets_second_test.json"
+	defer os.Remove(firstFile)
+	defer os.Remove(secondFile)
+
+	if err := SaveTweets([]Tweet{Tweet{ID: "1", Text: "First"}}, firstFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveTweets([]Tweet{Tweet{ID: "2", Text: "Second"}, Tweet{ID: "3", Text: "Second again"}}, secondFile); err != nil {
+		t.Fatal(err)
+	}
+
+	first := newBotAccount(accountConfig{Name: "first", DataFile: firstFile})
+	second := newBotAccount(accountConfig{Name: "second", DataFile: secondFile})
+	defer first.stopTicker()
+
+	mux := newServeMux(controlConfig{Token: "a_secret_token"}, []*botAccount{first, second})
+
+	request := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer a_secret_token")
+
+		res := httptest.NewRecorder()
+		mux.ServeHTTP(res, req)
+		return res
+	}
+
+	var listed tweetsResponse
+	res := request("GET", "/accounts/second/tweets")
+	json.NewDecoder(res.Body).Decode(&listed)
+	if res.Code != http.StatusOK || len(listed.Tweets) != 2 {
+		t.Errorf("expected the second account's 2 tweets, got status %d: %+v", res.Code, listed.Tweets)
+	}
+
+	if res = request("GET", "/accounts/second/tweets/1"); res.Code != http.StatusNotFound {
+		t.Errorf("expected the first account's tweet not to be found for the second account, got status %d", res.Code)
+	}
+
+	if res = request("GET", "/tweets"); res.Code != http.StatusNotFound {
+		t.Errorf("expected /tweets to need an account when there's more than one, got status %d", res.Code)
+	}
+
+	if res = request("POST", "/accounts/first/start"); res.Code != http.StatusOK {
+		t.Fatalf("expected status 200 starting the first account, got %d", res.Code)
+	}
+
+	if !first.isRunning() || second.isRunning() {
+		t.Error("expected only the first account to be started")
+	}
+
+	var statuses statusesResponse
+	res = request("GET", "/status")
+	json.NewDecoder(res.Body).Decode(&statuses)
+	if len(statuses.Accounts) != 2 || !statuses.Running || !statuses.Accounts[0].Running || statuses.Accounts[1].Running || statuses.Accounts[1].Tweets.Pending != 2 {
+		t.Errorf("unexpected status: %+v", statuses)
+	}
+
+	var accountStatus statusResponse
+	res = request("GET", "/accounts/first/status")
+	json.NewDecoder(res.Body).Decode(&accountStatus)
+	if accountStatus.Account != "first" || !accountStatus.Running || accountStatus.Tweets.Pending != 1 {
+		t.Errorf("unexpected status for the first account: %+v", accountStatus)
+	}
+
+	if res = request("POST", "/accounts/first/stop"); res.Code != http.StatusOK || first.isRunning() {
+		t.Errorf("expected the first account to be stopped, got status %d", res.Code)
+	}
+}
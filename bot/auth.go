@@ -0,0 +1,96 @@
+package main
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// controlConfig holds the credentials required to use the bot's control endpoints, either
+// a bearer token, HTTP basic username and password, or both
+type controlConfig struct {
+	Token    string `json:"token"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// tlsConfig holds the certificate and key files to serve HTTPS with, leave blank to serve plain HTTP
+type tlsConfig struct {
+	CertFile string `json:"certFile"`
+	KeyFile  string `json:"keyFile"`
+}
+
+func (control controlConfig) hasBasic() bool {
+	return control.Username != "" && control.Password != ""
+}
+
+func (control controlConfig) isConfigured() bool {
+	return control.Token != "" || control.hasBasic()
+}
+
+// authorised determines if the request has a valid bearer token or basic credentials
+func (control controlConfig) authorised(req *http.Request) bool {
+	if control.Token != "" {
+		header := req.Header.Get("Authorization")
+		if strings.HasPrefix(header, "Bearer ") && secureCompare(strings.TrimPrefix(header, "Bearer "), control.Token) {
+			return true
+		}
+	}
+
+	if control.hasBasic() {
+		username, password, ok := req.BasicAuth()
+		if ok && secureCompare(username, control.Username) && secureCompare(password, control.Password) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func secureCompare(given, expected string) bool {
+	return subtle.ConstantTimeCompare([]byte(given), []byte(expected)) == 1
+}
+
+// requireControl only allows POST requests with valid control credentials through to handler,
+// logging every attempt with the caller's address. 'action' describes the request in the log.
+func requireControl(control controlConfig, action string, handler http.HandlerFunc) http.HandlerFunc {
+	authorisedHandler := requireCredentials(control, action, handler)
+
+	return func(res http.ResponseWriter, req *http.Request) {
+		if req.Method != "POST" {
+			res.Header().Set("Allow", "POST")
+			http.Error(res, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		authorisedHandler(res, req)
+	}
+}
+
+// requireCredentials only allows requests with valid control credentials through to handler,
+// logging every attempt with the caller's address. 'action' describes the request in the log.
+func requireCredentials(control controlConfig, action string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		if !control.isConfigured() {
+			log.Printf("control: %s (%s %s) from %s refused, no control credentials are configured\n", action, req.Method, req.URL.Path, req.RemoteAddr)
+			http.Error(res, "Control endpoints are disabled until control credentials are set in config.json", http.StatusForbidden)
+			return
+		}
+
+		if !control.authorised(req) {
+			log.Printf("control: %s (%s %s) from %s refused, invalid credentials\n", action, req.Method, req.URL.Path, req.RemoteAddr)
+
+			if control.hasBasic() {
+				res.Header().Set("WWW-Authenticate", `Basic realm="go-twitter-bot"`)
+			} else {
+				res.Header().Set("WWW-Authenticate", "Bearer")
+			}
+			http.Error(res, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		log.Printf("control: %s (%s %s) from %s\n", action, req.Method, req.URL.Path, req.RemoteAddr)
+		handler(res, req)
+	}
+}
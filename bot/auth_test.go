@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireControl(t *testing.T) {
+	control := controlConfig{
+		Token:    "a_secret_token",
+		Username: "admin",
+		Password: "a_secret_password",
+	}
+
+	handler := requireControl(control, "test", func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusNoContent)
+	})
+
+	var testCases = []struct {
+		name     string
+		method   string
+		setAuth  func(req *http.Request)
+		expected int
+	}{
+		{"GET", "GET", func(req *http.Request) { req.Header.Set("Authorization", "Bearer a_secret_token") }, http.StatusMethodNotAllowed},
+		{"no credentials", "POST", func(req *http.Request) {}, http.StatusUnauthorized},
+		{"bearer token", "POST", func(req *http.Request) { req.Header.Set("Authorization", "Bearer a_secret_token") }, http.StatusNoContent},
+		{"wrong bearer token", "POST", func(req *http.Request) { req.Header.Set("Authorization", "Bearer wrong") }, http.StatusUnauthorized},
+		{"basic", "POST", func(req *http.Request) { req.SetBasicAuth("admin", "a_secret_password") }, http.StatusNoContent},
+		{"wrong basic", "POST", func(req *http.Request) { req.SetBasicAuth("admin", "wrong") }, http.StatusUnauthorized},
+	}
+
+	for _, testCase := range testCases {
+		req := httptest.NewRequest(testCase.method, "/start", nil)
+		testCase.setAuth(req)
+
+		res := httptest.NewRecorder()
+		handler(res, req)
+
+		if res.Code != testCase.expected {
+			t.Errorf("%s: expected status %d, got %d", testCase.name, testCase.expected, res.Code)
+		}
+	}
+
+	res := httptest.NewRecorder()
+	requireControl(controlConfig{}, "test", handler)(res, httptest.NewRequest("POST", "/start", nil))
+	if res.Code != http.StatusForbidden {
+		t.Errorf("expected control endpoints to be disabled without credentials, got status %d", res.Code)
+	}
+}
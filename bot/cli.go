@@ -0,0 +1,470 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sironfoot/go-twitter-bot/data/models"
+	"github.com/sironfoot/go-twitter-bot/lib/tweettext"
+)
+
+// commands are the subcommands for working with the schedule in the data file from the command line,
+// e.g. "bot -account name list --state pending"
+var commands = map[string]func(account *botAccount, args []string, out io.Writer) error{
+	"list":     listCommand,
+	"add":      addCommand,
+	"edit":     editCommand,
+	"remove":   removeCommand,
+	"validate": validateCommand,
+	"shift":    shiftCommand,
+	"next":     nextCommand,
+	"import":   importCommand,
+	"export":   exportCommand,
+	"migrate":  migrateCommand,
+}
+
+// atLayouts are the formats accepted by --at, times without a zone are in the configured timeZone
+var atLayouts = []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02 15:04"}
+
+// runCommand runs the subcommand named by args[0] with the rest of args
+func runCommand(account *botAccount, args []string, out io.Writer) error {
+	command, ok := commands[args[0]]
+	if !ok {
+		var names []string
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		return fmt.Errorf("unknown command '%s', expected one of: %s", args[0], strings.Join(names, ", "))
+	}
+
+	return command(account, args[1:], out)
+}
+
+func parseAt(config accountConfig, value string) (time.Time, error) {
+	location, err := config.location()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("problem loading time zone: %s", err)
+	}
+
+	for _, layout := range atLayouts {
+		if at, err := time.ParseInLocation(layout, value, location); err == nil {
+			return at.UTC(), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("--at '%s' must be formatted as one of: %s", value, strings.Join(atLayouts, ", "))
+}
+
+// splitID takes the tweet ID from the start of args, so it can come before the flags
+func splitID(command string, args []string) (string, []string, error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return "", nil, fmt.Errorf("%s: a tweet ID is required", command)
+	}
+
+	return args[0], args[1:], nil
+}
+
+// tweetModel returns the models.Tweet for a tweet, for validating with the same rules as the data server
+func tweetModel(tweet *Tweet) models.Tweet {
+	model := models.Tweet{
+		Text:             tweet.Text,
+		PostOn:           tweet.PostOn,
+		IsPosted:         tweet.IsPosted,
+		IsDraft:          tweet.IsDraft,
+		VariantSelection: tweet.VariantSelection,
+		Tags:             tweet.Tags,
+	}
+
+	for _, variant := range tweet.Variants {
+		model.Variants = append(model.Variants, models.TweetVariant{
+			Text:   variant.Text,
+			Weight: variant.Weight,
+		})
+	}
+
+	model.Sanitise()
+	return model
+}
+
+// validateModel sanitises and validates a tweet, returning the validation errors as one error
+func validateModel(tweet *Tweet) error {
+	model := tweetModel(tweet)
+
+	validationErrors, err := model.Validate()
+	if err != nil {
+		return err
+	}
+
+	if len(validationErrors) > 0 {
+		var messages []string
+		for _, validationError := range validationErrors {
+			messages = append(messages, validationError.Message)
+		}
+		return fmt.Errorf("tweet is invalid: %s", strings.Join(messages, " "))
+	}
+
+	applyTweetModel(tweet, model)
+	return nil
+}
+
+func writeTweets(out io.Writer, tweets []Tweet, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "\t")
+		return encoder.Encode(tweets)
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "ID\tSTATE\tPOST ON\tTEXT")
+
+	for i := range tweets {
+		tweet := &tweets[i]
+
+		text := strings.Replace(tweet.Text, "\n", " ", -1)
+		if runes := []rune(text); len(runes) > 60 {
+			text = string(runes[:57]) + "..."
+		}
+
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", tweet.ID, tweetState(tweet), tweet.PostOn.Format(time.RFC3339), text)
+	}
+
+	return writer.Flush()
+}
+
+// addFilterFlags adds flags to a command for building a tweetFilter, see parseTweetFilter
+func addFilterFlags(flags *flag.FlagSet) func() (tweetFilter, error) {
+	state := flags.String("state", "", "only tweets in this state: "+strings.Join(tweetStates, ", "))
+	from := flags.String("from", "", "only tweets to be posted on or after this RFC 3339 time")
+	to := flags.String("to", "", "only tweets to be posted on or before this RFC 3339 time")
+	search := flags.String("q", "", "only tweets containing this text")
+
+	return func() (tweetFilter, error) {
+		return parseTweetFilter(map[string][]string{
+			"state": []string{*state},
+			"from":  []string{*from},
+			"to":    []string{*to},
+			"q":     []string{*search},
+		})
+	}
+}
+
+// list [--state s] [--from t] [--to t] [--q text] [--json]
+func listCommand(account *botAccount, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	filterFlags := addFilterFlags(flags)
+	asJSON := flags.Bool("json", false, "output JSON instead of a table")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	filter, err := filterFlags()
+	if err != nil {
+		return err
+	}
+
+	tweets, err := account.loadSchedule()
+	if err != nil {
+		return err
+	}
+
+	return writeTweets(out, filterTweets(tweets, filter), *asJSON)
+}
+
+// add [--at time] [--draft] text...
+func addCommand(account *botAccount, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("add", flag.ContinueOnError)
+	at := flags.String("at", "", "when to post the tweet, defaults to the next tick")
+	draft := flags.Bool("draft", false, "add the tweet as a draft, which isn't posted")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	tweet := Tweet{
+		ID:      newTweetID(),
+		Text:    strings.Join(flags.Args(), " "),
+		PostOn:  time.Now().UTC(),
+		IsDraft: *draft,
+	}
+
+	if *at != "" {
+		postOn, err := parseAt(account.config, *at)
+		if err != nil {
+			return err
+		}
+		tweet.PostOn = postOn
+	}
+
+	if err := validateModel(&tweet); err != nil {
+		return err
+	}
+
+	tweets, err := account.loadSchedule()
+	if err != nil {
+		return err
+	}
+
+	if err = SaveTweets(append(tweets, tweet), account.config.DataFile); err != nil {
+		return fmt.Errorf("problem saving tweets: %s", err)
+	}
+
+	fmt.Fprintf(out, "Added %s\n", tweet.ID)
+	return nil
+}
+
+// edit id [--text text] [--at time] [--draft=true|false]
+func editCommand(account *botAccount, args []string, out io.Writer) error {
+	id, args, err := splitID("edit", args)
+	if err != nil {
+		return err
+	}
+
+	flags := flag.NewFlagSet("edit", flag.ContinueOnError)
+	text := flags.String("text", "", "new text for the tweet")
+	at := flags.String("at", "", "new time to post the tweet")
+	draft := flags.Bool("draft", false, "whether the tweet is a draft")
+	if err = flags.Parse(args); err != nil {
+		return err
+	}
+
+	tweets, err := account.loadSchedule()
+	if err != nil {
+		return err
+	}
+
+	index := findTweet(tweets, id)
+	if index < 0 {
+		return fmt.Errorf("tweet %s not found", id)
+	}
+	tweet := &tweets[index]
+
+	var flagErr error
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "text":
+			tweet.Text = *text
+		case "at":
+			tweet.PostOn, flagErr = parseAt(account.config, *at)
+		case "draft":
+			tweet.IsDraft = *draft
+		}
+	})
+
+	if flagErr != nil {
+		return flagErr
+	}
+
+	if err = validateModel(tweet); err != nil {
+		return err
+	}
+
+	if err = SaveTweets(tweets, account.config.DataFile); err != nil {
+		return fmt.Errorf("problem saving tweets: %s", err)
+	}
+
+	fmt.Fprintf(out, "Updated %s\n", id)
+	return nil
+}
+
+// remove id
+func removeCommand(account *botAccount, args []string, out io.Writer) error {
+	id, _, err := splitID("remove", args)
+	if err != nil {
+		return err
+	}
+
+	tweets, err := account.loadSchedule()
+	if err != nil {
+		return err
+	}
+
+	index := findTweet(tweets, id)
+	if index < 0 {
+		return fmt.Errorf("tweet %s not found", id)
+	}
+
+	tweets = append(tweets[:index], tweets[index+1:]...)
+	if err = SaveTweets(tweets, account.config.DataFile); err != nil {
+		return fmt.Errorf("problem saving tweets: %s", err)
+	}
+
+	fmt.Fprintf(out, "Removed %s\n", id)
+	return nil
+}
+
+// scheduleProblem is something wrong with a tweet found by validate
+type scheduleProblem struct {
+	ID      string `json:"id"`
+	Type    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// findScheduleProblems checks each unposted tweet is valid (see models.Tweet), isn't a duplicate or
+// near-repeat of another tweet in the schedule, and isn't scheduled in the past without having been tried
+func findScheduleProblems(tweets []Tweet, now time.Time) []scheduleProblem {
+	var problems []scheduleProblem
+
+	for i := range tweets {
+		tweet := &tweets[i]
+		if tweet.IsPosted {
+			continue
+		}
+
+		model := tweetModel(tweet)
+		validationErrors, _ := model.Validate()
+		for _, validationError := range validationErrors {
+			problems = append(problems, scheduleProblem{tweet.ID, validationError.Type, validationError.Message})
+		}
+
+		if !tweet.IsDraft && tweet.Attempts == 0 && tweet.PostOn.Before(now.Add(-tickInterval)) {
+			problems = append(problems, scheduleProblem{tweet.ID, "past", fmt.Sprintf("'postOn' %s is in the past.", tweet.PostOn.Format(time.RFC3339))})
+		}
+
+		normalised := tweettext.Normalise(tweet.Text)
+		for j := range tweets {
+			other := &tweets[j]
+			if i == j {
+				continue
+			}
+
+			if tweettext.Normalise(other.Text) == normalised {
+				problems = append(problems, scheduleProblem{tweet.ID, models.ValidationTypeDuplicate, fmt.Sprintf("'text' is the same as tweet %s.", other.ID)})
+			} else if tweettext.Similarity(other.Text, tweet.Text) >= models.DuplicateSimilarityThreshold {
+				problems = append(problems, scheduleProblem{tweet.ID, models.ValidationTypeSimilar, fmt.Sprintf("'text' is very similar to tweet %s.", other.ID)})
+			}
+		}
+	}
+
+	return problems
+}
+
+// validate [--json]
+func validateCommand(account *botAccount, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "output JSON instead of a table")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	tweets, err := account.loadSchedule()
+	if err != nil {
+		return err
+	}
+
+	problems := findScheduleProblems(tweets, time.Now().UTC())
+
+	if *asJSON {
+		if problems == nil {
+			problems = []scheduleProblem{}
+		}
+
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "\t")
+		if err = encoder.Encode(problems); err != nil {
+			return err
+		}
+	} else if len(problems) > 0 {
+		writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(writer, "ID\tPROBLEM\tMESSAGE")
+		for _, problem := range problems {
+			fmt.Fprintf(writer, "%s\t%s\t%s\n", problem.ID, problem.Type, problem.Message)
+		}
+		writer.Flush()
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%d problems found", len(problems))
+	}
+
+	if !*asJSON {
+		fmt.Fprintln(out, "No problems found")
+	}
+	return nil
+}
+
+// shift --by duration [--state s] [--from t] [--to t] [--q text]
+func shiftCommand(account *botAccount, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("shift", flag.ContinueOnError)
+	by := flags.Duration("by", 0, "how far to move the tweets, e.g. 2h or -30m")
+	filterFlags := addFilterFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *by == 0 {
+		return fmt.Errorf("shift: --by is required")
+	}
+
+	filter, err := filterFlags()
+	if err != nil {
+		return err
+	}
+
+	tweets, err := account.loadSchedule()
+	if err != nil {
+		return err
+	}
+
+	shifted := 0
+	for i := range tweets {
+		tweet := &tweets[i]
+
+		// posted tweets are history, moving them would misreport when they went out
+		if tweet.IsPosted || !filter.matches(tweet) {
+			continue
+		}
+
+		tweet.PostOn = tweet.PostOn.Add(*by)
+		shifted++
+	}
+
+	if err = SaveTweets(tweets, account.config.DataFile); err != nil {
+		return fmt.Errorf("problem saving tweets: %s", err)
+	}
+
+	fmt.Fprintf(out, "Shifted %d tweets by %s\n", shifted, *by)
+	return nil
+}
+
+// next [-n count] [--json]
+func nextCommand(account *botAccount, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("next", flag.ContinueOnError)
+	count := flags.Int("n", 1, "how many tweets to show")
+	asJSON := flags.Bool("json", false, "output JSON instead of a table")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	tweets, err := account.loadSchedule()
+	if err != nil {
+		return err
+	}
+
+	next := []Tweet{}
+	for i := range tweets {
+		if state := tweetState(&tweets[i]); state == tweetStatePending || state == tweetStateFailed {
+			next = append(next, tweets[i])
+		}
+	}
+
+	sort.Stable(byPostOn(next))
+
+	if len(next) > *count {
+		next = next[:*count]
+	}
+
+	return writeTweets(out, next, *asJSON)
+}
+
+type byPostOn []Tweet
+
+func (s byPostOn) Len() int           { return len(s) }
+func (s byPostOn) Less(i, j int) bool { return s[i].PostOn.Before(s[j].PostOn) }
+func (s byPostOn) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommands(t *testing.T) {
+	tweetFile := "tweets_cli_test.json"
+	defer os.Remove(tweetFile)
+
+	if err := SaveTweets([]Tweet{}, tweetFile); err != nil {
+		t.Fatal(err)
+	}
+
+	account := newBotAccount(accountConfig{Name: "test", TimeZone: "Europe/London", DataFile: tweetFile})
+
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		err := runCommand(account, args, &out)
+		return out.String(), err
+	}
+
+	if _, err := run("add", "--at", "2030-07-01 09:00", "Good morning everyone"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := run("add", "--at", "2030-07-01T10:00:00Z", "{{"); err == nil {
+		t.Error("expected an invalid template to be rejected")
+	}
+
+	if _, err := run("add", "--at", "tomorrow", "Hello"); err == nil {
+		t.Error("expected an invalid --at time to be rejected")
+	}
+
+	out, err := run("list", "--json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tweets []Tweet
+	if err = json.Unmarshal([]byte(out), &tweets); err != nil {
+		t.Fatalf("list --json output isn't JSON: %s", err)
+	}
+
+	if len(tweets) != 1 || !tweets[0].PostOn.Equal(time.Date(2030, 7, 1, 8, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected the added tweet at 08:00 UTC, got %+v", tweets)
+	}
+	id := tweets[0].ID
+
+	if _, err = run("shift", "--by", "2h"); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err = run("next")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(out, id) || !strings.Contains(out, "2030-07-01T10:00:00Z") {
+		t.Errorf("expected next to show the shifted tweet, got:\n%s", out)
+	}
+
+	if _, err = run("validate"); err != nil {
+		t.Errorf("expected no problems, got: %s", err)
+	}
+
+	if _, err = run("add", "--at", "2030-07-02 09:00", "Good morning, everyone!"); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err = run("validate")
+	if err == nil || !strings.Contains(out, "duplicate") {
+		t.Errorf("expected validate to find the duplicate, got:\n%s", out)
+	}
+
+	if _, err = run("edit", id, "--text", "Good afternoon everyone"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = run("remove", id); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = run("remove", id); err == nil {
+		t.Error("expected removing an unknown tweet to fail")
+	}
+
+	csvFile := "tweets_cli_test.csv"
+	defer os.Remove(csvFile)
+
+	if _, err = run("export", csvFile); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = run("import", csvFile, "--dry-run"); err != nil {
+		t.Errorf("expected the export to be valid to import: %s", err)
+	}
+
+	if err = ioutil.WriteFile(csvFile, []byte("text,postOn,account\nHello,2030-07-03 09:00,someone_else\n"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err = run("import", csvFile)
+	if err == nil || !strings.Contains(out, "row 2:") {
+		t.Errorf("expected the row for another account to be rejected, got:\n%s", out)
+	}
+
+	if _, err = run("unknown"); err == nil {
+		t.Error("expected an unknown command to fail")
+	}
+}
@@ -4,10 +4,45 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"regexp"
+	"strings"
+	"time"
 )
 
+// configuration is the bot's config file. The account settings at the top level are used when there
+// are no accounts listed, with the -data and -counters files, so older config files keep working.
+// With accounts listed, the top level variables and webhooks are shared by every account.
 type configuration struct {
-	TwitterAuth twitterAuth `json:"twitterAuth"`
+	TwitterAuth twitterAuth       `json:"twitterAuth"`
+	Username    string            `json:"username"`
+	TimeZone    string            `json:"timeZone"`
+	Variables   map[string]string `json:"variables"`
+	Webhooks    []webhookConfig   `json:"webhooks"`
+	Accounts    []accountConfig   `json:"accounts"`
+	Control     controlConfig     `json:"control"`
+	TLS         tlsConfig         `json:"tls"`
+}
+
+// accountConfig is a Twitter account run by the bot, with its own credentials and data files.
+// Name identifies the account in the HTTP API and -account flag, defaulting to the username.
+type accountConfig struct {
+	Name         string            `json:"name"`
+	TwitterAuth  twitterAuth       `json:"twitterAuth"`
+	Username     string            `json:"username"`
+	TimeZone     string            `json:"timeZone"`
+	Variables    map[string]string `json:"variables"`
+	Webhooks     []webhookConfig   `json:"webhooks"`
+	DataFile     string            `json:"dataFile"`
+	CountersFile string            `json:"countersFile"`
+}
+
+var accountNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// webhookConfig is a URL that is sent signed JSON payloads for the events listed, see lib/webhook
+type webhookConfig struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
 }
 
 type twitterAuth struct {
@@ -31,3 +66,75 @@ func loadConfig(path string) (configuration, error) {
 
 	return config, nil
 }
+
+// accountConfigs returns the accounts to run, a single account from the top level settings when none are listed
+func (config configuration) accountConfigs() ([]accountConfig, error) {
+	if len(config.Accounts) == 0 {
+		account := accountConfig{
+			Name:         strings.TrimPrefix(config.Username, "@"),
+			TwitterAuth:  config.TwitterAuth,
+			Username:     config.Username,
+			TimeZone:     config.TimeZone,
+			Variables:    config.Variables,
+			Webhooks:     config.Webhooks,
+			DataFile:     *dataFile,
+			CountersFile: *countersFile,
+		}
+
+		if !accountNamePattern.MatchString(account.Name) {
+			account.Name = "default"
+		}
+
+		return []accountConfig{account}, nil
+	}
+
+	var accounts []accountConfig
+	names := make(map[string]bool)
+
+	for _, account := range config.Accounts {
+		if account.Name == "" {
+			account.Name = strings.TrimPrefix(account.Username, "@")
+		}
+
+		if !accountNamePattern.MatchString(account.Name) {
+			return nil, fmt.Errorf("account name '%s' in %s can only contain letters, numbers, _ and -", account.Name, *configFile)
+		}
+
+		if names[account.Name] {
+			return nil, fmt.Errorf("account name '%s' is used more than once in %s", account.Name, *configFile)
+		}
+		names[account.Name] = true
+
+		if account.DataFile == "" {
+			return nil, fmt.Errorf("account '%s' in %s has no dataFile", account.Name, *configFile)
+		}
+
+		if account.CountersFile == "" {
+			account.CountersFile = account.Name + "-counters.json"
+		}
+
+		variables := make(map[string]string)
+		for name, value := range config.Variables {
+			variables[name] = value
+		}
+		for name, value := range account.Variables {
+			variables[name] = value
+		}
+		account.Variables = variables
+
+		account.Webhooks = append(append([]webhookConfig{}, config.Webhooks...), account.Webhooks...)
+
+		accounts = append(accounts, account)
+	}
+
+	return accounts, nil
+}
+
+// location returns the time.Location tweet templates are rendered in, UTC if no timeZone is configured
+func (config accountConfig) location() (*time.Location, error) {
+	if config.TimeZone == "" {
+		return time.UTC, nil
+	}
+
+	return time.LoadLocation(config.TimeZone)
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/sironfoot/go-twitter-bot/data/models"
+	"github.com/sironfoot/go-twitter-bot/lib/tweetcsv"
+)
+
+type importResponse struct {
+	Message string                 `json:"message"`
+	DryRun  bool                   `json:"dryRun"`
+	Rows    int                    `json:"rows"`
+	Created int                    `json:"created"`
+	Errors  []models.RowValidation `json:"errors"`
+}
+
+// readImport reads CSV (see tweetcsv.Read) and validates each row as a new tweet, returning
+// the tweets to add to the schedule along with the validation errors of any invalid rows
+func readImport(config accountConfig, r io.Reader) ([]Tweet, []models.RowValidation, error) {
+	location, err := config.location()
+	if err != nil {
+		return nil, nil, fmt.Errorf("problem loading time zone: %s", err)
+	}
+
+	rows, err := tweetcsv.Read(r, location)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var tweets []Tweet
+	rowErrors := make([]models.RowValidation, 0)
+
+	for _, row := range rows {
+		model := models.Tweet{
+			Text:   row.Text,
+			PostOn: row.PostOn,
+			Tags:   row.Tags,
+		}
+
+		validationErrors, err := model.ValidateImport(row, config.Username)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(validationErrors) > 0 {
+			rowErrors = append(rowErrors, models.RowValidation{
+				Row:    row.Line,
+				Errors: validationErrors,
+			})
+			continue
+		}
+
+		tweet := Tweet{ID: newTweetID()}
+		applyTweetModel(&tweet, model)
+		tweets = append(tweets, tweet)
+	}
+
+	return tweets, rowErrors, nil
+}
+
+// importTweets adds tweets to the schedule, the caller must hold dataLock
+func (account *botAccount) importTweets(tweets []Tweet) error {
+	schedule, err := account.loadSchedule()
+	if err != nil {
+		return err
+	}
+
+	if err = SaveTweets(append(schedule, tweets...), account.config.DataFile); err != nil {
+		return fmt.Errorf("problem saving tweets: %s", err)
+	}
+
+	return nil
+}
+
+// exportTweets writes the schedule as CSV, the caller must hold dataLock
+func (account *botAccount) exportTweets(w io.Writer) error {
+	tweets, err := account.loadSchedule()
+	if err != nil {
+		return err
+	}
+
+	var rows []tweetcsv.Row
+	for _, tweet := range tweets {
+		rows = append(rows, tweetcsv.Row{
+			Text:    tweet.Text,
+			PostOn:  tweet.PostOn,
+			Account: account.config.Username,
+			Tags:    tweet.Tags,
+		})
+	}
+
+	return tweetcsv.Write(w, rows)
+}
+
+// importHandler serves POST: /tweets/import, adding a tweet for each row of a CSV request body. Nothing is
+// added if any row is invalid, and with ?dryRun=true rows are only validated.
+func importHandler(account *botAccount) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		if req.Method != "POST" {
+			res.Header().Set("Allow", "POST")
+			writeMessage(res, http.StatusMethodNotAllowed, "Method Not Allowed")
+			return
+		}
+
+		tweets, rowErrors, err := readImport(account.config, io.LimitReader(req.Body, maxRequestLength))
+		if err != nil {
+			writeMessage(res, http.StatusBadRequest, fmt.Sprintf("CSV could not be read: %s", err))
+			return
+		}
+
+		response := importResponse{
+			DryRun: req.URL.Query().Get("dryRun") == "true",
+			Rows:   len(tweets) + len(rowErrors),
+			Errors: rowErrors,
+		}
+
+		if len(rowErrors) > 0 {
+			response.Message = "CSV contains invalid rows, no tweets were imported."
+			writeJSON(res, http.StatusBadRequest, response)
+			return
+		}
+
+		response.Message = "OK"
+
+		if response.DryRun {
+			writeJSON(res, http.StatusOK, response)
+			return
+		}
+
+		account.dataLock.Lock()
+		defer account.dataLock.Unlock()
+
+		if err = account.importTweets(tweets); err != nil {
+			writeMessage(res, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		response.Created = len(tweets)
+		writeJSON(res, http.StatusCreated, response)
+	}
+}
+
+// exportHandler serves GET: /tweets/export, downloading the schedule as CSV
+func exportHandler(account *botAccount) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		if req.Method != "GET" {
+			res.Header().Set("Allow", "GET")
+			writeMessage(res, http.StatusMethodNotAllowed, "Method Not Allowed")
+			return
+		}
+
+		account.dataLock.Lock()
+		defer account.dataLock.Unlock()
+
+		res.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		res.Header().Set("Content-Disposition", `attachment; filename="tweets.csv"`)
+
+		if err := account.exportTweets(res); err != nil {
+			writeMessage(res, http.StatusInternalServerError, err.Error())
+		}
+	}
+}
+
+// import file [--dry-run]
+func importCommand(account *botAccount, args []string, out io.Writer) error {
+	if len(args) == 0 || args[0] == "" || args[0][0] == '-' {
+		return fmt.Errorf("import: a CSV file is required")
+	}
+	path := args[0]
+
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "only validate the file, don't add any tweets")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	tweets, rowErrors, err := readImport(account.config, file)
+	if err != nil {
+		return err
+	}
+
+	if len(rowErrors) > 0 {
+		for _, rowError := range rowErrors {
+			for _, validationError := range rowError.Errors {
+				fmt.Fprintf(out, "row %d: %s\n", rowError.Row, validationError.Message)
+			}
+		}
+		return fmt.Errorf("%d invalid rows, no tweets were imported", len(rowErrors))
+	}
+
+	if *dryRun {
+		fmt.Fprintf(out, "%d tweets are valid\n", len(tweets))
+		return nil
+	}
+
+	if err = account.importTweets(tweets); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Imported %d tweets\n", len(tweets))
+	return nil
+}
+
+// export [file], writing to stdout without a file
+func exportCommand(account *botAccount, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return account.exportTweets(out)
+	}
+
+	file, err := os.Create(args[0])
+	if err != nil {
+		return err
+	}
+
+	if err = account.exportTweets(file); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
@@ -5,13 +5,58 @@ import (
 	"io/ioutil"
 	"os"
 	"time"
+
+	"github.com/sironfoot/go-twitter-bot/lib/tweettext"
 )
 
-// Tweet keeps record of a tweet and whether or not it has been posted to Twitter
+// Tweet keeps record of a tweet and whether or not it has been posted to Twitter,
+// draft tweets are never posted
 type Tweet struct {
-	Text     string    `json:"text"`
-	IsPosted bool      `json:"isPosted"`
-	PostOn   time.Time `json:"postOn"`
+	ID               string         `json:"id,omitempty"`
+	Text             string         `json:"text"`
+	IsPosted         bool           `json:"isPosted"`
+	IsDraft          bool           `json:"isDraft,omitempty"`
+	PostOn           time.Time      `json:"postOn"`
+	VariantSelection string         `json:"variantSelection,omitempty"`
+	Variants         []TweetVariant `json:"variants,omitempty"`
+	PostedVariant    *int           `json:"postedVariant,omitempty"`
+	StatusID         string         `json:"statusId,omitempty"`
+	Attempts         int            `json:"attempts,omitempty"`
+	LastError        string         `json:"lastError,omitempty"`
+	RetryAfter       *time.Time     `json:"retryAfter,omitempty"`
+	Tags             []string       `json:"tags,omitempty"`
+}
+
+// TweetVariant is an alternative text for a Tweet, one variant is chosen at post time
+// instead of the Tweet's own Text
+type TweetVariant struct {
+	Text        string `json:"text"`
+	Weight      int    `json:"weight,omitempty"`
+	TimesPosted int    `json:"timesPosted"`
+}
+
+// ChooseText picks the text to post using the Tweet's VariantSelection, returning the
+// index of the chosen variant, or -1 if the Tweet has no variants and its own Text is used
+func (tweet *Tweet) ChooseText() (string, int) {
+	var choices []tweettext.Variant
+	for _, variant := range tweet.Variants {
+		weight := variant.Weight
+		if weight == 0 {
+			weight = 1
+		}
+
+		choices = append(choices, tweettext.Variant{
+			Weight:      weight,
+			TimesPosted: variant.TimesPosted,
+		})
+	}
+
+	chosen := tweettext.ChooseVariant(tweet.VariantSelection, choices)
+	if chosen < 0 {
+		return tweet.Text, chosen
+	}
+
+	return tweet.Variants[chosen].Text, chosen
 }
 
 // LoadTweets loads Tweet structs from a json data file
@@ -45,3 +90,29 @@ func SaveTweets(tweets []Tweet, dataFile string) error {
 
 	return nil
 }
+
+// LoadCounters loads the current value of tweet template counters from a json data file,
+// a data file that doesn't exist yet is treated as all counters being 0
+func LoadCounters(countersFile string) (map[string]int, error) {
+	counters := make(map[string]int)
+
+	data, err := ioutil.ReadFile(countersFile)
+	if os.IsNotExist(err) {
+		return counters, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, &counters)
+	return counters, err
+}
+
+// SaveCounters saves the current value of tweet template counters to a json data file
+func SaveCounters(counters map[string]int, countersFile string) error {
+	counterData, err := json.MarshalIndent(&counters, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(countersFile, counterData, os.ModePerm)
+}
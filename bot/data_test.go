@@ -3,7 +3,10 @@ package main
 import (
 	"os"
 	"testing"
+	"time"
 	"unicode/utf8"
+
+	"github.com/sironfoot/go-twitter-bot/lib/tweettext"
 )
 
 func TestLoadTweets(t *testing.T) {
@@ -70,3 +73,110 @@ func TestTweetMaxLength(t *testing.T) {
 		}
 	}
 }
+
+func TestTweetTemplates(t *testing.T) {
+	tweets, err := LoadTweets("tweets.json")
+	if err != nil {
+		t.Fatalf("Failed to load tweets: %s", err)
+	}
+
+	for i, tweet := range tweets {
+		texts := []string{tweet.Text}
+		for _, variant := range tweet.Variants {
+			texts = append(texts, variant.Text)
+		}
+
+		for _, text := range texts {
+			result, err := tweettext.Render(text, tweettext.SampleData("sample_account", tweet.PostOn))
+			if err != nil {
+				t.Errorf("Tweet at index: %d is not a valid template: %s", i, err)
+				continue
+			}
+
+			length := tweettext.WeightedLength(result.Text)
+			if length > tweettext.MaxWeightedLength {
+				t.Errorf("Tweet at index: %d renders to a weighted length of %d. Tweet was:\n\n%s\n\n\n", i, length, result.Text)
+			}
+		}
+	}
+}
+
+func TestChooseText(t *testing.T) {
+	tweet := Tweet{
+		Text: "Original",
+	}
+
+	if text, variant := tweet.ChooseText(); text != "Original" || variant != -1 {
+		t.Errorf("Expected tweet without variants to use its own text, got '%s' (%d)", text, variant)
+	}
+
+	tweet.VariantSelection = tweettext.SelectRoundRobin
+	tweet.Variants = []TweetVariant{
+		{Text: "Variant A", TimesPosted: 1},
+		{Text: "Variant B", TimesPosted: 0},
+	}
+
+	if text, variant := tweet.ChooseText(); text != "Variant B" || variant != 1 {
+		t.Errorf("Expected round robin to choose the least posted variant, got '%s' (%d)", text, variant)
+	}
+}
+
+func TestSummariseTweets(t *testing.T) {
+	now := time.Now().UTC()
+
+	tweets := []Tweet{
+		Tweet{Text: "Posted", IsPosted: true, PostOn: now.Add(-2 * time.Hour)},
+		Tweet{Text: "Later", PostOn: now.Add(time.Hour)},
+		Tweet{Text: "Failed", Attempts: 2, PostOn: now.Add(-time.Hour)},
+		Tweet{Text: "Soon", PostOn: now.Add(time.Minute)},
+	}
+
+	counts, next := summariseTweets(tweets)
+
+	expected := tweetCounts{Pending: 2, Posted: 1, Failed: 1}
+	if counts != expected {
+		t.Errorf("expected counts %+v, got %+v", expected, counts)
+	}
+
+	if next == nil || next.Text != "Failed" {
+		t.Errorf("expected the failed tweet to be next, got %+v", next)
+	}
+
+	if _, next = summariseTweets(tweets[:1]); next != nil {
+		t.Errorf("expected no next tweet when all are posted, got %+v", next)
+	}
+}
+
+func TestSaveCounters(t *testing.T) {
+	countersFile := "counters_test.json"
+
+	counters, err := LoadCounters(countersFile)
+	if err != nil {
+		t.Fatalf("Failed to load counters from a file that doesn't exist: %s", err)
+	}
+
+	if len(counters) != 0 {
+		t.Errorf("Expected no counters, got %d", len(counters))
+	}
+
+	result, err := tweettext.Render(`Episode {{next "episode"}}`, tweettext.Data{Now: time.Now(), Counters: counters})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = SaveCounters(result.Counters, countersFile)
+	if err != nil {
+		t.Fatalf("Failed to save counters: %s", err)
+	}
+
+	defer os.Remove(countersFile)
+
+	savedCounters, err := LoadCounters(countersFile)
+	if err != nil {
+		t.Fatalf("Failed to load counters: %s", err)
+	}
+
+	if savedCounters["episode"] != 1 {
+		t.Errorf("Expected episode counter to be 1, got %d", savedCounters["episode"])
+	}
+}
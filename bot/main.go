@@ -5,21 +5,23 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"sync"
+	"os"
 	"time"
+
+	"github.com/sironfoot/go-twitter-bot/lib/tweettext"
+	"github.com/sironfoot/go-twitter-bot/lib/twitter"
+	"github.com/sironfoot/go-twitter-bot/lib/webhook"
 )
 
 var configFile = flag.String("config", "config.json", "path to config file")
-var dataFile = flag.String("data", "tweets.json", "path to json file containing tweets")
+var dataFile = flag.String("data", "tweets.json", "path to json file containing tweets, when the config file doesn't list accounts")
+var countersFile = flag.String("counters", "counters.json", "path to json file containing tweet template counters, when the config file doesn't list accounts")
 var addr = flag.String("addr", "localhost:7000", "Address to run server on")
 var start = flag.Bool("start", false, "start the service immediately on launch")
+var accountName = flag.String("account", "", "name of the account to run a command for, required when there's more than one")
 
-var (
-	ticker   *time.Ticker
-	stop     = make(chan bool)
-	running  = false
-	tickLock sync.RWMutex
-)
+// tickInterval is how often the bot checks for tweets that are due
+const tickInterval = 10 * time.Second
 
 func main() {
 	var fatalErr error
@@ -32,107 +34,213 @@ func main() {
 
 	flag.Parse()
 
+	// webhook URLs come from the config file, so they're trusted to be internal addresses
+	webhook.AllowPrivateAddresses = true
+
 	config, err := loadConfig(*configFile)
 	if err != nil {
 		fatalErr = err
 		return
 	}
 
-	mux := http.NewServeMux()
-
-	mux.HandleFunc("/status", func(res http.ResponseWriter, req *http.Request) {
-		tickLock.RLock()
-		defer tickLock.RUnlock()
+	accounts, err := loadAccounts(config)
+	if err != nil {
+		fatalErr = err
+		return
+	}
 
-		if running {
-			fmt.Fprint(res, "Running\n")
-		} else {
-			fmt.Fprint(res, "Paused\n")
+	if flag.NArg() > 0 {
+		account, err := findAccount(accounts, *accountName)
+		if err != nil {
+			log.Fatalln(err)
 		}
-	})
 
-	mux.HandleFunc("/start", func(res http.ResponseWriter, req *http.Request) {
-		startTicker(config)
-		fmt.Fprint(res, "Started\n")
-	})
-
-	mux.HandleFunc("/stop", func(res http.ResponseWriter, req *http.Request) {
-		stopTicker()
-		fmt.Fprint(res, "Stopped\n")
-	})
+		if err = runCommand(account, flag.Args(), os.Stdout); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
 
 	server := http.Server{
 		Addr:    *addr,
-		Handler: mux,
+		Handler: newServeMux(config.Control, accounts),
 	}
 
 	if *start {
-		startTicker(config)
+		for _, account := range accounts {
+			account.startTicker()
+		}
+	}
+
+	if !config.Control.isConfigured() {
+		log.Println("No control credentials in config.json, /start and /stop are disabled")
 	}
 
 	log.Printf("Go Twitter Bot Server is running on %s...\n\n", *addr)
-	server.ListenAndServe()
-}
 
-func startTicker(config configuration) {
-	tickLock.Lock()
-	defer tickLock.Unlock()
-
-	if !running {
-		ticker = time.NewTicker(time.Second * 10)
-		running = true
-
-		go func() {
-			for {
-				select {
-				case <-ticker.C:
-					fatalErr := postNextTweet(config)
-					if fatalErr != nil {
-						panic(fatalErr)
-					}
-				case <-stop:
-					return
-				}
-			}
-		}()
+	if config.TLS.CertFile != "" {
+		fatalErr = server.ListenAndServeTLS(config.TLS.CertFile, config.TLS.KeyFile)
+	} else {
+		fatalErr = server.ListenAndServe()
 	}
 }
 
-func stopTicker() {
-	tickLock.Lock()
-	defer tickLock.Unlock()
+// newServeMux routes /status, /start and /stop for all accounts, and each account's endpoints under
+// /accounts/:name/. The schedule endpoints are also kept at /tweets when there's only one account.
+func newServeMux(control controlConfig, accounts []*botAccount) *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/status", statusHandler(accounts))
+
+	mux.HandleFunc("/start", requireControl(control, "start", func(res http.ResponseWriter, req *http.Request) {
+		for _, account := range accounts {
+			account.startTicker()
+		}
+		fmt.Fprint(res, "Started\n")
+	}))
+
+	mux.HandleFunc("/stop", requireControl(control, "stop", func(res http.ResponseWriter, req *http.Request) {
+		for _, account := range accounts {
+			account.stopTicker()
+		}
+		fmt.Fprint(res, "Stopped\n")
+	}))
+
+	for _, account := range accounts {
+		account := account
+		prefix := "/accounts/" + account.config.Name
+
+		mux.HandleFunc(prefix+"/status", accountStatusHandler(account))
+
+		mux.HandleFunc(prefix+"/start", requireControl(control, "start "+account.config.Name, func(res http.ResponseWriter, req *http.Request) {
+			account.startTicker()
+			fmt.Fprint(res, "Started\n")
+		}))
+
+		mux.HandleFunc(prefix+"/stop", requireControl(control, "stop "+account.config.Name, func(res http.ResponseWriter, req *http.Request) {
+			account.stopTicker()
+			fmt.Fprint(res, "Stopped\n")
+		}))
+
+		handleSchedule(mux, prefix, control, account)
+	}
 
-	if running {
-		ticker.Stop()
-		stop <- true
-		running = false
+	if len(accounts) == 1 {
+		handleSchedule(mux, "", control, accounts[0])
 	}
+
+	return mux
 }
 
-func postNextTweet(config configuration) error {
-	tweets, err := LoadTweets(*dataFile)
+// handleSchedule routes the schedule endpoints for an account under prefix
+func handleSchedule(mux *http.ServeMux, prefix string, control controlConfig, account *botAccount) {
+	mux.HandleFunc(prefix+"/tweets", requireCredentials(control, "schedule", scheduleHandler(account)))
+	mux.HandleFunc(prefix+"/tweets/", requireCredentials(control, "schedule", scheduleTweetHandler(account, prefix+"/tweets/")))
+	mux.HandleFunc(prefix+"/tweets/import", requireCredentials(control, "import", importHandler(account)))
+	mux.HandleFunc(prefix+"/tweets/export", requireCredentials(control, "export", exportHandler(account)))
+}
+
+func (account *botAccount) postNextTweet() error {
+	account.dataLock.Lock()
+	defer account.dataLock.Unlock()
+
+	tweets, err := LoadTweets(account.config.DataFile)
 	if err != nil {
 		return fmt.Errorf("problem loading tweets: %s", err)
 	}
 
 	nextTweets := getNextTweets(tweets)
+	if len(nextTweets) == 0 {
+		return nil
+	}
+
+	location, err := account.config.location()
+	if err != nil {
+		return fmt.Errorf("problem loading time zone: %s", err)
+	}
+
+	counters, err := LoadCounters(account.config.CountersFile)
+	if err != nil {
+		return fmt.Errorf("problem loading counters: %s", err)
+	}
 
 	for _, tweet := range nextTweets {
-		log.Printf("Tweeting: %s\n\n", tweet.Text)
+		var posted bool
+		counters, posted = account.sendTweet(tweet, location, counters)
 
-		err = postTweet(config.TwitterAuth, tweet.Text)
-		if err != nil {
-			return err
+		if !posted {
+			// leave the rest of the tweets until the next tick, they'd most likely fail too
+			break
 		}
+	}
+
+	return account.saveData(tweets, counters)
+}
 
-		tweet.IsPosted = true
+// sendTweet renders and posts a single tweet, returning the updated template counters. A failure to render
+// or post is recorded on the tweet for retrying rather than returned as an error, with posted set to false,
+// so tweets posted earlier in the same tick are still saved.
+func (account *botAccount) sendTweet(tweet *Tweet, location *time.Location, counters map[string]int) (map[string]int, bool) {
+	config := account.config
+	text, variant := tweet.ChooseText()
+
+	result, err := tweettext.Render(text, tweettext.Data{
+		Account:  config.Username,
+		Now:      time.Now().In(location),
+		Vars:     config.Variables,
+		Counters: counters,
+	})
+	if err != nil {
+		err = fmt.Errorf("problem rendering tweet template: %s", err)
+		log.Printf("%s: %s\n", config.Name, err)
+		account.recordFailure(tweet, err)
+		return counters, false
+	}
+
+	if tweet.Attempts > 0 {
+		emitEvent(config, tweetEvent(webhook.EventTweetRetried, config, tweet))
 	}
 
-	err = SaveTweets(tweets, *dataFile)
+	log.Printf("%s: tweeting: %s\n\n", config.Name, result.Text)
+
+	posted, err := postTweet(config.TwitterAuth, result.Text)
+	if err != nil {
+		log.Printf("%s: problem posting tweet: %s\n", config.Name, err)
+		account.recordFailure(tweet, err)
+		return counters, false
+	}
+
+	account.status.recordPosted()
+
+	tweet.IsPosted = true
+	tweet.StatusID = posted.ID
+	tweet.LastError = ""
+	tweet.RetryAfter = nil
+
+	payload := tweetEvent(webhook.EventTweetPosted, config, tweet)
+	payload.Tweet.Text = result.Text
+	emitEvent(config, payload)
+
+	if variant >= 0 {
+		tweet.Variants[variant].TimesPosted++
+		tweet.PostedVariant = &variant
+	}
+
+	return result.Counters, true
+}
+
+// saveData saves the tweets and template counters, the caller must hold dataLock
+func (account *botAccount) saveData(tweets []Tweet, counters map[string]int) error {
+	err := SaveTweets(tweets, account.config.DataFile)
 	if err != nil {
 		return fmt.Errorf("problem saving tweets: %s", err)
 	}
 
+	err = SaveCounters(counters, account.config.CountersFile)
+	if err != nil {
+		return fmt.Errorf("problem saving counters: %s", err)
+	}
+
 	return nil
 }
 
@@ -143,10 +251,42 @@ func getNextTweets(tweets []Tweet) []*Tweet {
 	for i := range tweets {
 		tweet := &tweets[i]
 
-		if !tweet.IsPosted && now.After(tweet.PostOn) {
+		if tweet.RetryAfter != nil && now.Before(*tweet.RetryAfter) {
+			continue
+		}
+
+		if !tweet.IsPosted && !tweet.IsDraft && now.After(tweet.PostOn) {
 			nextTweets = append(nextTweets, tweet)
 		}
 	}
 
 	return nextTweets
 }
+
+// recordFailure notes a failed attempt to post the tweet, schedules a retry and emits the failure events
+func (account *botAccount) recordFailure(tweet *Tweet, postErr error) {
+	config := account.config
+
+	retryAfter := time.Now().UTC().Add(twitter.RetryBackoff(tweet.Attempts + 1))
+	if twitter.IsRateLimited(postErr) {
+		// no point retrying before the rate limit window resets
+		if reset := postErr.(*twitter.Error).RateLimitReset; reset.After(retryAfter) {
+			retryAfter = reset
+		}
+	}
+
+	tweet.Attempts++
+	tweet.LastError = postErr.Error()
+	tweet.RetryAfter = &retryAfter
+	account.status.recordError(postErr, time.Now().UTC())
+
+	payload := tweetEvent(webhook.EventTweetFailed, config, tweet)
+	payload.Error = postErr.Error()
+	emitEvent(config, payload)
+
+	if twitter.IsUnauthorized(postErr) {
+		payload = webhook.NewPayload(webhook.EventCredentialsInvalid, config.Username)
+		payload.Error = postErr.Error()
+		emitEvent(config, payload)
+	}
+}
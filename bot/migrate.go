@@ -0,0 +1,194 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sironfoot/go-twitter-bot/data/db"
+	"github.com/sironfoot/go-twitter-bot/lib/tweettext"
+)
+
+type migrateReport struct {
+	AccountID         string   `json:"twitterAccountId"`
+	Username          string   `json:"username"`
+	AccountCreated    bool     `json:"accountCreated"`
+	CredentialsDiffer bool     `json:"credentialsDiffer"`
+	Created           []string `json:"created"`
+	Skipped           []string `json:"skipped"`
+	Conflicted        []string `json:"conflicted"`
+}
+
+// migrateAccount returns the twitter_accounts row for this bot's config, owned by userID
+func migrateAccount(config accountConfig, userID string) db.TwitterAccount {
+	timeZone := config.TimeZone
+	if timeZone == "" {
+		timeZone = "UTC"
+	}
+
+	// every column is inserted, so the table's defaults need setting here
+	return db.TwitterAccount{
+		UserID:                sql.NullString{String: userID, Valid: true},
+		Username:              strings.TrimPrefix(config.Username, "@"),
+		ConsumerKey:           config.TwitterAuth.ConsumerKey,
+		ConsumerSecret:        config.TwitterAuth.ConsumerSecret,
+		AccessToken:           config.TwitterAuth.AccessToken,
+		AccessTokenSecret:     config.TwitterAuth.AccessTokenSecret,
+		TimeZone:              timeZone,
+		EvergreenNoRepeatDays: 30,
+		DuplicatePolicy:       db.DuplicatePolicyWarn,
+		DuplicateWindowDays:   30,
+	}
+}
+
+// migrateTweet returns the tweets row, and its tweet_variants rows, for a tweet from the data file
+func migrateTweet(tweet Tweet) db.ImportTweet {
+	now := time.Now().UTC()
+
+	variantSelection := tweet.VariantSelection
+	if variantSelection == "" {
+		variantSelection = tweettext.SelectRandom
+	}
+
+	importTweet := db.ImportTweet{
+		Tweet: db.Tweet{
+			Tweet: tweet.Text,
+			// TIMESTAMP columns don't keep the zone, or more than microseconds
+			PostOn:           tweet.PostOn.UTC().Truncate(time.Microsecond),
+			IsPosted:         tweet.IsPosted,
+			IsDraft:          tweet.IsDraft,
+			VariantSelection: variantSelection,
+			StatusID:         sql.NullString{String: tweet.StatusID, Valid: tweet.StatusID != ""},
+			Attempts:         tweet.Attempts,
+			LastError:        sql.NullString{String: tweet.LastError, Valid: tweet.LastError != ""},
+			Tags:             pq.StringArray(tweet.Tags),
+			DateCreated:      now,
+		},
+		PostedVariant: -1,
+	}
+
+	if tweet.RetryAfter != nil {
+		importTweet.RetryAfter = pq.NullTime{Time: tweet.RetryAfter.UTC(), Valid: true}
+	}
+
+	if tweet.PostedVariant != nil {
+		importTweet.PostedVariant = *tweet.PostedVariant
+	}
+
+	for _, variant := range tweet.Variants {
+		weight := variant.Weight
+		if weight == 0 {
+			weight = 1
+		}
+
+		importTweet.Variants = append(importTweet.Variants, db.TweetVariant{
+			Tweet:       variant.Text,
+			Weight:      weight,
+			TimesPosted: variant.TimesPosted,
+			DateCreated: now,
+		})
+	}
+
+	return importTweet
+}
+
+func migrateSummary(tweets []db.Tweet) []string {
+	summary := []string{}
+	for _, tweet := range tweets {
+		summary = append(summary, fmt.Sprintf("%s %s", tweet.PostOn.Format(time.RFC3339), tweet.Tweet))
+	}
+
+	return summary
+}
+
+// migrate --db connection-string --owner email [--json]
+// Copies the account from the config file and the tweets from the data file into the data server's
+// database, in one transaction. Tweets already copied are skipped, so it's safe to run again.
+func migrateCommand(account *botAccount, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	connectionString := flags.String("db", "", "data server database connection string")
+	owner := flags.String("owner", "", "email address of the data server user to own the account")
+	asJSON := flags.Bool("json", false, "output JSON instead of text")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *connectionString == "" || *owner == "" {
+		return fmt.Errorf("migrate: --db and --owner are required")
+	}
+
+	if account.config.Username == "" {
+		return fmt.Errorf("migrate: username must be set in %s", *configFile)
+	}
+
+	tweets, err := LoadTweets(account.config.DataFile)
+	if err != nil {
+		return fmt.Errorf("problem loading tweets: %s", err)
+	}
+
+	if err = db.InitDB(*connectionString); err != nil {
+		return fmt.Errorf("problem connecting to database: %s", err)
+	}
+	defer db.CloseDB()
+
+	user, err := db.UserFromEmail(*owner)
+	if err == db.ErrEntityNotFound {
+		return fmt.Errorf("migrate: no user with email %s", *owner)
+	} else if err != nil {
+		return err
+	}
+
+	var importTweets []db.ImportTweet
+	for _, tweet := range tweets {
+		importTweets = append(importTweets, migrateTweet(tweet))
+	}
+
+	twitterAccount := migrateAccount(account.config, user.ID)
+
+	result, err := db.ImportBotSchedule(twitterAccount, importTweets)
+	if err == db.ErrAccountOwnedByAnotherUser {
+		return fmt.Errorf("migrate: @%s already exists and belongs to another user, nothing was imported", twitterAccount.Username)
+	} else if err != nil {
+		return err
+	}
+
+	report := migrateReport{
+		AccountID:         result.Account.ID,
+		Username:          result.Account.Username,
+		AccountCreated:    result.AccountCreated,
+		CredentialsDiffer: result.CredentialsDiffer,
+		Created:           migrateSummary(result.Created),
+		Skipped:           migrateSummary(result.Skipped),
+		Conflicted:        migrateSummary(result.Conflicted),
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "\t")
+		return encoder.Encode(report)
+	}
+
+	if report.AccountCreated {
+		fmt.Fprintf(out, "Created account @%s (%s)\n", report.Username, report.AccountID)
+	} else {
+		fmt.Fprintf(out, "Matched existing account @%s (%s)\n", report.Username, report.AccountID)
+	}
+
+	if report.CredentialsDiffer {
+		fmt.Fprintf(out, "Warning: the existing account has different credentials to %s, they have been kept\n", *configFile)
+	}
+
+	fmt.Fprintf(out, "%d tweets created, %d skipped (already imported), %d conflicted\n",
+		len(report.Created), len(report.Skipped), len(report.Conflicted))
+
+	for _, conflicted := range report.Conflicted {
+		fmt.Fprintf(out, "  conflicted: %s\n", conflicted)
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMigrateTweet(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	posted := 1
+	tweet := Tweet{
+		Text:          "Hello",
+		PostOn:        time.Date(2017, 7, 1, 9, 0, 0, 500, london),
+		IsPosted:      true,
+		StatusID:      "123",
+		PostedVariant: &posted,
+		Variants: []TweetVariant{
+			{Text: "Hi"},
+			{Text: "Hey", Weight: 3, TimesPosted: 1},
+		},
+		Tags: []string{"greeting"},
+	}
+
+	importTweet := migrateTweet(tweet)
+
+	if !importTweet.PostOn.Equal(time.Date(2017, 7, 1, 8, 0, 0, 0, time.UTC)) || importTweet.PostOn.Location() != time.UTC {
+		t.Errorf("expected postOn in UTC to the microsecond, got %s", importTweet.PostOn)
+	}
+
+	if importTweet.VariantSelection == "" || importTweet.StatusID.String != "123" || importTweet.LastError.Valid {
+		t.Errorf("tweet not mapped correctly: %+v", importTweet.Tweet)
+	}
+
+	if len(importTweet.Variants) != 2 || importTweet.Variants[0].Weight != 1 || importTweet.Variants[1].TimesPosted != 1 {
+		t.Errorf("variants not mapped correctly: %+v", importTweet.Variants)
+	}
+
+	if importTweet.PostedVariant != 1 {
+		t.Errorf("expected posted variant 1, got %d", importTweet.PostedVariant)
+	}
+
+	if migrateTweet(Tweet{Text: "No variants"}).PostedVariant != -1 {
+		t.Error("expected no posted variant")
+	}
+}
@@ -0,0 +1,416 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sironfoot/go-twitter-bot/data/models"
+)
+
+const maxRequestLength = 1048576
+
+const (
+	tweetStatePending = "pending"
+	tweetStateFailed  = "failed"
+	tweetStatePosted  = "posted"
+	tweetStateDraft   = "draft"
+)
+
+var tweetStates = []string{tweetStatePending, tweetStateFailed, tweetStatePosted, tweetStateDraft}
+
+// tweetState is whether a tweet is posted, a draft, has failed to post at least once, or is waiting to be posted
+func tweetState(tweet *Tweet) string {
+	switch {
+	case tweet.IsPosted:
+		return tweetStatePosted
+	case tweet.IsDraft:
+		return tweetStateDraft
+	case tweet.Attempts > 0:
+		return tweetStateFailed
+	}
+
+	return tweetStatePending
+}
+
+// tweetFilter narrows down the tweets listed by the schedule API, zero values match every tweet
+type tweetFilter struct {
+	State  string
+	From   time.Time
+	To     time.Time
+	Search string
+}
+
+// parseTweetFilter reads a tweetFilter from the ?state=, ?from=, ?to= (both RFC 3339) and ?q= query string values
+func parseTweetFilter(query url.Values) (tweetFilter, error) {
+	filter := tweetFilter{
+		State:  query.Get("state"),
+		Search: strings.ToLower(strings.TrimSpace(query.Get("q"))),
+	}
+
+	if filter.State != "" {
+		valid := false
+		for _, state := range tweetStates {
+			valid = valid || state == filter.State
+		}
+
+		if !valid {
+			return filter, fmt.Errorf("'state' must be one of: %s", strings.Join(tweetStates, ", "))
+		}
+	}
+
+	var err error
+	if from := query.Get("from"); from != "" {
+		if filter.From, err = time.Parse(time.RFC3339, from); err != nil {
+			return filter, fmt.Errorf("'from' must be an RFC 3339 time")
+		}
+	}
+
+	if to := query.Get("to"); to != "" {
+		if filter.To, err = time.Parse(time.RFC3339, to); err != nil {
+			return filter, fmt.Errorf("'to' must be an RFC 3339 time")
+		}
+	}
+
+	return filter, nil
+}
+
+func (filter tweetFilter) matches(tweet *Tweet) bool {
+	if filter.State != "" && tweetState(tweet) != filter.State {
+		return false
+	}
+
+	if !filter.From.IsZero() && tweet.PostOn.Before(filter.From) {
+		return false
+	}
+
+	if !filter.To.IsZero() && tweet.PostOn.After(filter.To) {
+		return false
+	}
+
+	if filter.Search != "" && !strings.Contains(strings.ToLower(tweet.Text), filter.Search) {
+		return false
+	}
+
+	return true
+}
+
+// filterTweets returns the tweets matching filter, in schedule order
+func filterTweets(tweets []Tweet, filter tweetFilter) []Tweet {
+	filtered := []Tweet{}
+	for i := range tweets {
+		if filter.matches(&tweets[i]) {
+			filtered = append(filtered, tweets[i])
+		}
+	}
+
+	return filtered
+}
+
+// newTweetID returns a random ID to refer to a tweet by in the schedule API
+func newTweetID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// loadSchedule loads the tweets, giving any added by hand to the data file an ID and saving them so the
+// IDs are stable. The caller must hold dataLock.
+func (account *botAccount) loadSchedule() ([]Tweet, error) {
+	tweets, err := LoadTweets(account.config.DataFile)
+	if err != nil {
+		return nil, fmt.Errorf("problem loading tweets: %s", err)
+	}
+
+	assigned := false
+	for i := range tweets {
+		if tweets[i].ID == "" {
+			tweets[i].ID = newTweetID()
+			assigned = true
+		}
+	}
+
+	if assigned {
+		if err = SaveTweets(tweets, account.config.DataFile); err != nil {
+			return nil, fmt.Errorf("problem saving tweets: %s", err)
+		}
+	}
+
+	return tweets, nil
+}
+
+func findTweet(tweets []Tweet, id string) int {
+	for i := range tweets {
+		if tweets[i].ID == id {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// applyTweetModel copies the fields of a validated models.Tweet on to a tweet, keeping the post counts of
+// variants whose text hasn't changed
+func applyTweetModel(tweet *Tweet, model models.Tweet) {
+	timesPosted := make(map[string]int)
+	for _, variant := range tweet.Variants {
+		timesPosted[variant.Text] = variant.TimesPosted
+	}
+
+	tweet.Text = model.Text
+	tweet.PostOn = model.PostOn
+	tweet.IsPosted = model.IsPosted
+	tweet.IsDraft = model.IsDraft
+	tweet.VariantSelection = model.VariantSelection
+	tweet.Tags = model.Tags
+
+	tweet.Variants = nil
+	for _, variant := range model.Variants {
+		tweet.Variants = append(tweet.Variants, TweetVariant{
+			Text:        variant.Text,
+			Weight:      variant.Weight,
+			TimesPosted: timesPosted[variant.Text],
+		})
+	}
+}
+
+type messageResponse struct {
+	Message string `json:"message"`
+}
+
+type createResponse struct {
+	Message string                   `json:"message"`
+	Errors  []models.ValidationError `json:"errors"`
+	ID      *string                  `json:"id"`
+}
+
+type updateResponse struct {
+	Message string                   `json:"message"`
+	Errors  []models.ValidationError `json:"errors"`
+}
+
+type tweetsResponse struct {
+	Tweets []Tweet `json:"tweets"`
+}
+
+func writeJSON(res http.ResponseWriter, statusCode int, v interface{}) {
+	res.Header().Set("Content-Type", "application/json; charset=utf-8")
+	res.WriteHeader(statusCode)
+	json.NewEncoder(res).Encode(v)
+}
+
+func writeMessage(res http.ResponseWriter, statusCode int, message string) {
+	writeJSON(res, statusCode, messageResponse{Message: message})
+}
+
+// decodeTweetModel reads, sanitises and validates a models.Tweet from the request body, 'id' is the tweet
+// being updated or blank for a new tweet. Responds with the validation errors and returns false if invalid.
+func decodeTweetModel(res http.ResponseWriter, req *http.Request, id string) (models.Tweet, bool) {
+	var model models.Tweet
+
+	if err := json.NewDecoder(io.LimitReader(req.Body, maxRequestLength)).Decode(&model); err != nil {
+		writeMessage(res, http.StatusBadRequest, fmt.Sprintf("problem decoding JSON: %s", err))
+		return model, false
+	}
+
+	model.Sanitise()
+
+	var validationErrors []models.ValidationError
+	var err error
+	if id == "" {
+		validationErrors, err = model.ValidateCreate()
+	} else {
+		validationErrors, err = model.ValidateUpdate(id)
+	}
+
+	if err != nil {
+		writeMessage(res, http.StatusInternalServerError, err.Error())
+		return model, false
+	}
+
+	if len(validationErrors) > 0 {
+		if id == "" {
+			writeJSON(res, http.StatusBadRequest, createResponse{Message: "Tweet model is invalid.", Errors: validationErrors})
+		} else {
+			writeJSON(res, http.StatusBadRequest, updateResponse{Message: "Tweet model is invalid.", Errors: validationErrors})
+		}
+		return model, false
+	}
+
+	return model, true
+}
+
+// scheduleHandler serves /tweets, GET: lists tweets matching the filters from parseTweetFilter, POST: adds a tweet
+func scheduleHandler(account *botAccount) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case "GET":
+			filter, err := parseTweetFilter(req.URL.Query())
+			if err != nil {
+				writeMessage(res, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			account.dataLock.Lock()
+			defer account.dataLock.Unlock()
+
+			tweets, err := account.loadSchedule()
+			if err != nil {
+				writeMessage(res, http.StatusInternalServerError, err.Error())
+				return
+			}
+
+			writeJSON(res, http.StatusOK, tweetsResponse{Tweets: filterTweets(tweets, filter)})
+
+		case "POST":
+			model, valid := decodeTweetModel(res, req, "")
+			if !valid {
+				return
+			}
+
+			account.dataLock.Lock()
+			defer account.dataLock.Unlock()
+
+			tweets, err := account.loadSchedule()
+			if err != nil {
+				writeMessage(res, http.StatusInternalServerError, err.Error())
+				return
+			}
+
+			tweet := Tweet{ID: newTweetID()}
+			applyTweetModel(&tweet, model)
+			tweets = append(tweets, tweet)
+
+			if err = SaveTweets(tweets, account.config.DataFile); err != nil {
+				writeMessage(res, http.StatusInternalServerError, fmt.Sprintf("problem saving tweets: %s", err))
+				return
+			}
+
+			writeJSON(res, http.StatusCreated, createResponse{Message: "OK", Errors: []models.ValidationError{}, ID: &tweet.ID})
+
+		default:
+			res.Header().Set("Allow", "GET, POST")
+			writeMessage(res, http.StatusMethodNotAllowed, "Method Not Allowed")
+		}
+	}
+}
+
+// scheduleTweetHandler serves /tweets/:id, GET: a single tweet, PUT: edits the tweet, DELETE: removes it,
+// and /tweets/:id/post, POST: posts the tweet to Twitter now. prefix is the path up to the tweet's ID.
+func scheduleTweetHandler(account *botAccount, prefix string) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(req.URL.Path, prefix), "/")
+		id := parts[0]
+
+		if id == "" || len(parts) > 2 || (len(parts) == 2 && parts[1] != "post") {
+			writeMessage(res, http.StatusNotFound, "Not Found")
+			return
+		}
+
+		postNow := len(parts) == 2
+
+		var model models.Tweet
+		switch {
+		case postNow && req.Method == "POST", !postNow && (req.Method == "GET" || req.Method == "DELETE"):
+		case !postNow && req.Method == "PUT":
+			var valid bool
+			if model, valid = decodeTweetModel(res, req, id); !valid {
+				return
+			}
+		default:
+			if postNow {
+				res.Header().Set("Allow", "POST")
+			} else {
+				res.Header().Set("Allow", "GET, PUT, DELETE")
+			}
+			writeMessage(res, http.StatusMethodNotAllowed, "Method Not Allowed")
+			return
+		}
+
+		account.dataLock.Lock()
+		defer account.dataLock.Unlock()
+
+		tweets, err := account.loadSchedule()
+		if err != nil {
+			writeMessage(res, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		index := findTweet(tweets, id)
+		if index < 0 {
+			writeMessage(res, http.StatusNotFound, "Tweet not found.")
+			return
+		}
+		tweet := &tweets[index]
+
+		switch {
+		case postNow:
+			postTweetNow(res, account, tweets, tweet)
+			return
+
+		case req.Method == "GET":
+			writeJSON(res, http.StatusOK, tweet)
+			return
+
+		case req.Method == "PUT":
+			applyTweetModel(tweet, model)
+
+		case req.Method == "DELETE":
+			tweets = append(tweets[:index], tweets[index+1:]...)
+		}
+
+		if err = SaveTweets(tweets, account.config.DataFile); err != nil {
+			writeMessage(res, http.StatusInternalServerError, fmt.Sprintf("problem saving tweets: %s", err))
+			return
+		}
+
+		if req.Method == "PUT" {
+			writeJSON(res, http.StatusOK, updateResponse{Message: "OK", Errors: []models.ValidationError{}})
+		} else {
+			writeMessage(res, http.StatusOK, "OK")
+		}
+	}
+}
+
+// postTweetNow posts a tweet to Twitter straight away, regardless of when it's scheduled for. The caller must hold dataLock.
+func postTweetNow(res http.ResponseWriter, account *botAccount, tweets []Tweet, tweet *Tweet) {
+	if tweet.IsPosted {
+		writeMessage(res, http.StatusConflict, "Tweet has already been posted.")
+		return
+	}
+
+	location, err := account.config.location()
+	if err != nil {
+		writeMessage(res, http.StatusInternalServerError, fmt.Sprintf("problem loading time zone: %s", err))
+		return
+	}
+
+	counters, err := LoadCounters(account.config.CountersFile)
+	if err != nil {
+		writeMessage(res, http.StatusInternalServerError, fmt.Sprintf("problem loading counters: %s", err))
+		return
+	}
+
+	counters, posted := account.sendTweet(tweet, location, counters)
+
+	// save failures too, so the attempt is recorded
+	if err = account.saveData(tweets, counters); err != nil {
+		writeMessage(res, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if !posted {
+		writeMessage(res, http.StatusBadGateway, fmt.Sprintf("problem posting tweet: %s", tweet.LastError))
+		return
+	}
+
+	writeJSON(res, http.StatusOK, tweet)
+}
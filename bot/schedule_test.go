@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFilterTweets(t *testing.T) {
+	now := time.Now().UTC()
+
+	tweets := []Tweet{
+		Tweet{Text: "Posted yesterday", IsPosted: true, PostOn: now.AddDate(0, 0, -1)},
+		Tweet{Text: "Failed today", Attempts: 1, PostOn: now},
+		Tweet{Text: "Pending tomorrow", PostOn: now.AddDate(0, 0, 1)},
+		Tweet{Text: "Draft tomorrow", IsDraft: true, PostOn: now.AddDate(0, 0, 1)},
+	}
+
+	var testCases = []struct {
+		query    string
+		expected []string
+	}{
+		{"", []string{"Posted yesterday", "Failed today", "Pending tomorrow", "Draft tomorrow"}},
+		{"state=pending", []string{"Pending tomorrow"}},
+		{"state=failed", []string{"Failed today"}},
+		{"q=TOMORROW", []string{"Pending tomorrow", "Draft tomorrow"}},
+		{"from=" + url.QueryEscape(now.Add(-time.Hour).Format(time.RFC3339)) + "&to=" + url.QueryEscape(now.Add(time.Hour).Format(time.RFC3339)), []string{"Failed today"}},
+	}
+
+	for _, testCase := range testCases {
+		query, _ := url.ParseQuery(testCase.query)
+
+		filter, err := parseTweetFilter(query)
+		if err != nil {
+			t.Fatalf("%s: %s", testCase.query, err)
+		}
+
+		filtered := filterTweets(tweets, filter)
+		if len(filtered) != len(testCase.expected) {
+			t.Errorf("%s: expected %d tweets, got %d", testCase.query, len(testCase.expected), len(filtered))
+			continue
+		}
+
+		for i, tweet := range filtered {
+			if tweet.Text != testCase.expected[i] {
+				t.Errorf("%s: expected tweet %d to be '%s', got '%s'", testCase.query, i, testCase.expected[i], tweet.Text)
+			}
+		}
+	}
+
+	if _, err := parseTweetFilter(url.Values{"state": []string{"unknown"}}); err == nil {
+		t.Error("expected an unknown state to be rejected")
+	}
+}
+
+func TestScheduleAPI(t *testing.T) {
+	tweetFile := "tweets_schedule_test.json"
+	defer os.Remove(tweetFile)
+
+	err := SaveTweets([]Tweet{Tweet{Text: "Added by hand"}}, tweetFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	account := newBotAccount(accountConfig{Name: "test", DataFile: tweetFile})
+	list := scheduleHandler(account)
+	item := scheduleTweetHandler(account, "/tweets/")
+
+	res := httptest.NewRecorder()
+	list(res, httptest.NewRequest("POST", "/tweets", strings.NewReader(`{"text": "Hello {{"}`)))
+	if res.Code != http.StatusBadRequest || !strings.Contains(res.Body.String(), "not a valid template") {
+		t.Errorf("expected an invalid template to be rejected, got status %d: %s", res.Code, res.Body)
+	}
+
+	res = httptest.NewRecorder()
+	list(res, httptest.NewRequest("POST", "/tweets", strings.NewReader(`{"text": "  Hello world  "}`)))
+	if res.Code != http.StatusCreated {
+		t.Fatalf("expected status 201 adding a tweet, got %d: %s", res.Code, res.Body)
+	}
+
+	var created createResponse
+	json.NewDecoder(res.Body).Decode(&created)
+	if created.ID == nil {
+		t.Fatal("expected the new tweet's ID")
+	}
+
+	res = httptest.NewRecorder()
+	item(res, httptest.NewRequest("PUT", "/tweets/"+*created.ID, strings.NewReader(`{"text": "Hello again", "isDraft": true}`)))
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status 200 editing a tweet, got %d: %s", res.Code, res.Body)
+	}
+
+	tweets, err := LoadTweets(tweetFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tweets) != 2 || tweets[0].ID == "" || tweets[1].Text != "Hello again" || !tweets[1].IsDraft {
+		t.Errorf("unexpected tweets after adding and editing: %+v", tweets)
+	}
+
+	res = httptest.NewRecorder()
+	item(res, httptest.NewRequest("DELETE", "/tweets/"+tweets[0].ID, nil))
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status 200 deleting a tweet, got %d: %s", res.Code, res.Body)
+	}
+
+	res = httptest.NewRecorder()
+	list(res, httptest.NewRequest("GET", "/tweets?state=draft", nil))
+
+	var listed tweetsResponse
+	json.NewDecoder(res.Body).Decode(&listed)
+	if len(listed.Tweets) != 1 || listed.Tweets[0].ID != *created.ID {
+		t.Errorf("expected only the edited tweet to be listed, got %+v", listed.Tweets)
+	}
+
+	res = httptest.NewRecorder()
+	item(res, httptest.NewRequest("DELETE", "/tweets/unknown", nil))
+	if res.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for an unknown tweet, got %d", res.Code)
+	}
+}
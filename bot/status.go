@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sironfoot/go-twitter-bot/lib/twitter"
+)
+
+// botStatus records what an account has been doing, for reporting by /status
+type botStatus struct {
+	lock             sync.RWMutex
+	started          time.Time
+	lastTick         *time.Time
+	lastError        string
+	lastErrorTime    *time.Time
+	rateLimitedUntil *time.Time
+}
+
+func (s *botStatus) recordTick(now time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.lastTick = &now
+}
+
+// recordError notes the most recent error, and whether it means Twitter is rate limiting the account
+func (s *botStatus) recordError(err error, now time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.lastError = err.Error()
+	s.lastErrorTime = &now
+
+	if twitter.IsRateLimited(err) {
+		until := err.(*twitter.Error).RateLimitReset
+		if until.IsZero() {
+			until = now.Add(twitter.RetryBackoff(1))
+		}
+		s.rateLimitedUntil = &until
+	}
+}
+
+// recordPosted notes a successful post, which means the account is no longer rate limited
+func (s *botStatus) recordPosted() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.rateLimitedUntil = nil
+}
+
+// statusesResponse is the status of every account
+type statusesResponse struct {
+	Running  bool             `json:"running"`
+	Accounts []statusResponse `json:"accounts"`
+}
+
+type statusResponse struct {
+	Account      string           `json:"account"`
+	Username     string           `json:"username"`
+	Running      bool             `json:"running"`
+	Uptime       string           `json:"uptime"`
+	StartedAt    time.Time        `json:"startedAt"`
+	TickInterval string           `json:"tickInterval"`
+	LastTick     *time.Time       `json:"lastTick"`
+	NextTweet    *nextTweetStatus `json:"nextTweet"`
+	Tweets       tweetCounts      `json:"tweets"`
+	LastError    *errorStatus     `json:"lastError"`
+	RateLimit    rateLimitStatus  `json:"rateLimit"`
+}
+
+type nextTweetStatus struct {
+	Text   string    `json:"text"`
+	PostOn time.Time `json:"postOn"`
+}
+
+type tweetCounts struct {
+	Pending int `json:"pending"`
+	Posted  int `json:"posted"`
+	Failed  int `json:"failed"`
+	Drafts  int `json:"drafts"`
+}
+
+type errorStatus struct {
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+type rateLimitStatus struct {
+	IsLimited bool       `json:"isLimited"`
+	Until     *time.Time `json:"until,omitempty"`
+}
+
+// summariseTweets counts tweets by state (see tweetState) and finds the pending or failed tweet due soonest
+func summariseTweets(tweets []Tweet) (tweetCounts, *Tweet) {
+	var counts tweetCounts
+	var next *Tweet
+
+	for i := range tweets {
+		tweet := &tweets[i]
+
+		switch tweetState(tweet) {
+		case tweetStatePosted:
+			counts.Posted++
+			continue
+		case tweetStateDraft:
+			counts.Drafts++
+			continue
+		case tweetStateFailed:
+			counts.Failed++
+		default:
+			counts.Pending++
+		}
+
+		if next == nil || tweet.PostOn.Before(next.PostOn) {
+			next = tweet
+		}
+	}
+
+	return counts, next
+}
+
+// statusResponse reports the account's status, including a summary of its schedule
+func (account *botAccount) statusResponse() (statusResponse, error) {
+	account.dataLock.Lock()
+	tweets, err := LoadTweets(account.config.DataFile)
+	account.dataLock.Unlock()
+	if err != nil {
+		return statusResponse{}, fmt.Errorf("%s: problem loading tweets: %s", account.config.Name, err)
+	}
+
+	now := time.Now().UTC()
+	counts, next := summariseTweets(tweets)
+
+	status := &account.status
+	status.lock.RLock()
+	defer status.lock.RUnlock()
+
+	response := statusResponse{
+		Account:      account.config.Name,
+		Username:     account.config.Username,
+		Running:      account.isRunning(),
+		Uptime:       now.Sub(status.started).String(),
+		StartedAt:    status.started,
+		TickInterval: tickInterval.String(),
+		LastTick:     status.lastTick,
+		Tweets:       counts,
+	}
+
+	if next != nil {
+		response.NextTweet = &nextTweetStatus{
+			Text:   next.Text,
+			PostOn: next.PostOn,
+		}
+	}
+
+	if status.lastErrorTime != nil {
+		response.LastError = &errorStatus{
+			Message: status.lastError,
+			Time:    *status.lastErrorTime,
+		}
+	}
+
+	if status.rateLimitedUntil != nil && now.Before(*status.rateLimitedUntil) {
+		response.RateLimit = rateLimitStatus{
+			IsLimited: true,
+			Until:     status.rateLimitedUntil,
+		}
+	}
+
+	return response, nil
+}
+
+func runningText(isRunning bool) string {
+	if isRunning {
+		return "Running"
+	}
+	return "Paused"
+}
+
+// statusHandler serves /status, reporting every account's status as JSON. With Accept: text/plain it's
+// "Running" or "Paused", on a line per account ("name: Running") when there's more than one.
+func statusHandler(accounts []*botAccount) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		if strings.Contains(req.Header.Get("Accept"), "text/plain") {
+			for _, account := range accounts {
+				if len(accounts) == 1 {
+					fmt.Fprintf(res, "%s\n", runningText(account.isRunning()))
+				} else {
+					fmt.Fprintf(res, "%s: %s\n", account.config.Name, runningText(account.isRunning()))
+				}
+			}
+			return
+		}
+
+		response := statusesResponse{Accounts: []statusResponse{}}
+		for _, account := range accounts {
+			accountStatus, err := account.statusResponse()
+			if err != nil {
+				http.Error(res, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			response.Running = response.Running || accountStatus.Running
+			response.Accounts = append(response.Accounts, accountStatus)
+		}
+
+		res.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(res).Encode(response)
+	}
+}
+
+// accountStatusHandler serves /accounts/:name/status, reporting the account's status as JSON, or as plain
+// "Running" or "Paused" for Accept: text/plain
+func accountStatusHandler(account *botAccount) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		if strings.Contains(req.Header.Get("Accept"), "text/plain") {
+			fmt.Fprintf(res, "%s\n", runningText(account.isRunning()))
+			return
+		}
+
+		response, err := account.statusResponse()
+		if err != nil {
+			http.Error(res, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(res).Encode(response)
+	}
+}
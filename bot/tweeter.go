@@ -2,28 +2,20 @@ package main
 
 import (
 	"fmt"
-	"net/url"
 
-	"github.com/mrjones/oauth"
+	"github.com/sironfoot/go-twitter-bot/lib/twitter"
 )
 
-func postTweet(auth twitterAuth, tweet string) error {
-	consumer := oauth.NewConsumer(auth.ConsumerKey, auth.ConsumerSecret, oauth.ServiceProvider{})
-
-	accessToken := oauth.AccessToken{
-		Token:  auth.AccessToken,
-		Secret: auth.AccessTokenSecret,
-	}
-
-	client, err := consumer.MakeHttpClient(&accessToken)
-	if err != nil {
-		return fmt.Errorf("error posting to twitter: %s", err)
-	}
-
-	_, err = client.PostForm("https://api.twitter.com/1.1/statuses/update.json", url.Values{"status": []string{tweet}})
+func postTweet(auth twitterAuth, tweet string) (twitter.Tweet, error) {
+	client, err := twitter.NewClient(twitter.Credentials{
+		ConsumerKey:       auth.ConsumerKey,
+		ConsumerSecret:    auth.ConsumerSecret,
+		AccessToken:       auth.AccessToken,
+		AccessTokenSecret: auth.AccessTokenSecret,
+	})
 	if err != nil {
-		return fmt.Errorf("error posting to twitter: %s", err)
+		return twitter.Tweet{}, fmt.Errorf("error posting to twitter: %s", err)
 	}
 
-	return nil
+	return client.UpdateStatus(tweet, "")
 }
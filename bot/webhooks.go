@@ -0,0 +1,58 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/sironfoot/go-twitter-bot/lib/webhook"
+)
+
+// tweetEvent returns a webhook.Payload for an event about a tweet
+func tweetEvent(event string, config accountConfig, tweet *Tweet) webhook.Payload {
+	payload := webhook.NewPayload(event, config.Username)
+	payload.Tweet = &webhook.Tweet{
+		ID:       tweet.ID,
+		Text:     tweet.Text,
+		PostOn:   tweet.PostOn,
+		StatusID: tweet.StatusID,
+		Attempts: tweet.Attempts,
+	}
+
+	return payload
+}
+
+// emitEvent sends payload to each configured webhook subscribed to its event, in the background
+func emitEvent(config accountConfig, payload webhook.Payload) {
+	body, err := webhook.Marshal(payload)
+	if err != nil {
+		log.Printf("webhook: %s: %s\n", payload.Event, err)
+		return
+	}
+
+	for _, hook := range config.Webhooks {
+		for _, event := range hook.Events {
+			if event == payload.Event {
+				go deliver(hook, payload.Event, body)
+				break
+			}
+		}
+	}
+}
+
+// deliver sends body to the webhook, retrying with webhook.Backoff until webhook.MaxAttempts,
+// each attempt is logged as the bot's delivery log
+func deliver(hook webhookConfig, event string, body []byte) {
+	for attempt := 1; attempt <= webhook.MaxAttempts; attempt++ {
+		statusCode, err := webhook.Deliver(hook.URL, hook.Secret, event, body)
+		if err == nil {
+			log.Printf("webhook: %s delivered to %s (status %d)\n", event, hook.URL, statusCode)
+			return
+		}
+
+		log.Printf("webhook: %s delivery %d of %d to %s failed: %s\n", event, attempt, webhook.MaxAttempts, hook.URL, err)
+
+		if attempt < webhook.MaxAttempts {
+			time.Sleep(webhook.Backoff(attempt))
+		}
+	}
+}
@@ -3,15 +3,10 @@ package api
 import (
 	"database/sql"
 	"encoding/json"
-	"fmt"
 	"io"
+	"log"
 	"net/http"
-
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/rand"
-
-	"encoding/base64"
+	"time"
 
 	"github.com/sironfoot/go-twitter-bot/data/db"
 	"github.com/sironfoot/go-twitter-bot/data/models"
@@ -49,22 +44,32 @@ func AccountLogin(ctx context.Context, res http.ResponseWriter, req *http.Reques
 		return
 	}
 
-	// check user exists for email
-	user, err := db.UserFromEmail(login.Email)
-	if err == db.ErrEntityNotFound {
-		res.WriteHeader(http.StatusForbidden)
+	protection := appContext.Settings.AppSettings.LoginProtection
+	email := loginEmail(login.Email)
+	ipAddress := remoteIP(req)
+	now := time.Now().UTC()
 
-		var validationErrors []models.ValidationError
-		validationErrors = append(validationErrors, models.ValidationError{
-			FieldName: "email",
-			Type:      models.ValidationTypeNotFound,
-			Message:   fmt.Sprintf("User not found on email %s", login.Email),
-		})
+	if until := loginLockedUntil(protection, email, ipAddress, now); !until.IsZero() {
+		loginLockedOut(appContext, res, until, now)
+		return
+	}
 
-		appContext.Response = updateResponse{
-			Message: "Login model is invalid.",
-			Errors:  validationErrors,
+	// the same response whether it's the email or password that's wrong, so it doesn't reveal who has an account
+	var notCorrect = func(user *db.User) {
+		time.Sleep(recordLoginFailure(protection, email, ipAddress, user, now))
+
+		res.WriteHeader(http.StatusForbidden)
+		appContext.Response = MessageResponse{
+			Message: "Email address or password is not correct.",
 		}
+	}
+
+	// check user exists for email
+	user, err := db.UserFromEmail(login.Email)
+	if err == db.ErrEntityNotFound {
+		// compare against a hash anyway, so it takes as long as a wrong password
+		bcrypt.CompareHashAndPassword(dummyPasswordHash(appContext.Settings.AppSettings.BCryptWorkFactor), []byte(login.Password))
+		notCorrect(nil)
 		return
 	} else if err != nil {
 		panic(err)
@@ -74,74 +79,185 @@ func AccountLogin(ctx context.Context, res http.ResponseWriter, req *http.Reques
 	errHashCompare := bcrypt.CompareHashAndPassword([]byte(user.HashedPassword), []byte(login.Password))
 	if errHashCompare != nil {
 		if errHashCompare == bcrypt.ErrMismatchedHashAndPassword || errHashCompare == bcrypt.ErrHashTooShort {
-			res.WriteHeader(http.StatusForbidden)
-
-			var validationErrors []models.ValidationError
-			validationErrors = append(validationErrors, models.ValidationError{
-				FieldName: "password",
-				Type:      models.ValidationTypeNotFound,
-				Message:   fmt.Sprintf("Password not correct for user %s", login.Email),
-			})
-
-			appContext.Response = updateResponse{
-				Message: "Login model is invalid.",
-				Errors:  validationErrors,
-			}
+			notCorrect(&user)
 			return
 		}
 
 		panic(errHashCompare)
 	}
 
-	randomBytes := make([]byte, 32)
-	_, err = rand.Read(randomBytes)
+	if !user.IsVerified {
+		res.WriteHeader(http.StatusForbidden)
+		appContext.Response = MessageResponse{
+			Message: "Email address has not been verified. Please follow the link in the verification email, or request a new one with PUT: /account/verify/resend",
+		}
+		return
+	}
+
+	if user.TwoFactorEnabled {
+		// failed logins aren't cleared until the code is right too, so they still count towards a lockout
+		token, err := newUserToken(&user, db.UserTokenPurposeTwoFactorLogin, twoFactorLoginTokenLifetime)
+		if err != nil {
+			panic(err)
+		}
+
+		appContext.Response = twoFactorRequiredResponse{
+			Message:           "Two-factor code required. Please send it with the token to PUT: /account/login/two-factor",
+			TwoFactorRequired: true,
+			Token:             token,
+		}
+		return
+	}
+
+	appContext.Response = startSession(appContext, req, &user, now)
+}
+
+// startSession creates a Session for a User who has logged in, clearing their failed logins,
+// and returns their access token and refresh token
+func startSession(appContext *AppContext, req *http.Request, user *db.User, now time.Time) accessTokenResponse {
+	err := db.LoginFailuresDeleteForEmail(loginEmail(user.Email))
 	if err != nil {
 		panic(err)
 	}
 
-	user.AuthToken = sql.NullString{
-		String: base64.StdEncoding.EncodeToString(randomBytes),
-		Valid:  true,
+	session := db.Session{
+		UserID:      user.ID,
+		UserAgent:   truncate(req.UserAgent(), maxUserAgentLength),
+		IPAddress:   remoteIP(req),
+		DateCreated: now,
+		LastSeen:    now,
+		ExpiresOn:   now.Add(appContext.Settings.AppSettings.sessionLifetime()),
 	}
 
-	err = user.Save()
+	err = session.Save()
 	if err != nil {
 		panic(err)
 	}
 
-	block, err := aes.NewCipher([]byte(appContext.Settings.AppSettings.EncryptionKey))
+	tokens, err := issueTokens(appContext.Settings.AppSettings, &session, now)
 	if err != nil {
 		panic(err)
 	}
 
-	plaintextToken := []byte(user.ID + "_" + user.AuthToken.String)
-	encryptedToken := make([]byte, aes.BlockSize+len(plaintextToken))
+	return tokens
+}
+
+// AccountRefresh = PUT: /account/refresh
+// Exchanges a refresh token for a new access token and refresh token, sliding the Session's expiry on. Each
+// refresh token can only be used once, using one again revokes the Session as the token has been stolen.
+func AccountRefresh(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	var refresh models.Refresh
+
+	defer req.Body.Close()
+	err := json.NewDecoder(io.LimitReader(req.Body, maxRequestLength)).Decode(&refresh)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "JSON request body was not in a valid format",
+		}
+		return
+	}
 
-	// iv = initialization vector
-	iv := encryptedToken[:aes.BlockSize]
-	_, err = rand.Read(iv)
+	refresh.Sanitise()
+	validationErrors, err := refresh.Validate()
 	if err != nil {
 		panic(err)
 	}
 
-	cfb := cipher.NewCFBEncrypter(block, iv)
-	cfb.XORKeyStream(encryptedToken[aes.BlockSize:], plaintextToken)
-	accessToken := base64.StdEncoding.EncodeToString(encryptedToken)
+	if len(validationErrors) > 0 {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = updateResponse{
+			Message: "Refresh model is invalid.",
+			Errors:  validationErrors,
+		}
+		return
+	}
+
+	var notValid = func() {
+		res.WriteHeader(http.StatusUnauthorized)
+		appContext.Response = MessageResponse{
+			Message: "Refresh token is not valid. Please authenticate with PUT: /account/login",
+		}
+	}
+
+	refreshToken, err := db.RefreshTokenFromHash(hashSecretToken(refresh.RefreshToken))
+	if err == db.ErrEntityNotFound {
+		notValid()
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	session, err := db.SessionFromID(refreshToken.SessionID)
+	if err == db.ErrEntityNotFound {
+		notValid()
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	var revokeSession = func() {
+		err = session.Delete()
+		if err != nil {
+			panic(err)
+		}
+
+		log.Printf("refresh token reused for session %s of user %s, the session has been revoked\n", session.ID, session.UserID)
+		notValid()
+	}
+
+	if refreshToken.DateUsed.Valid {
+		revokeSession()
+		return
+	}
+
+	now := time.Now().UTC()
+	if session.IsExpired(now) {
+		notValid()
+		return
+	}
+
+	nextToken := newSecretToken()
+	next := db.RefreshToken{
+		TokenHash:   hashSecretToken(nextToken),
+		DateCreated: now,
+	}
+
+	err = db.RefreshTokenRotate(&refreshToken, &next, &session, now.Add(appContext.Settings.AppSettings.sessionLifetime()))
+	if err == db.ErrRefreshTokenUsed {
+		// used by another request in the meantime
+		revokeSession()
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	accessToken, expiresAt, err := issueAccessToken(appContext.Settings.AppSettings, &session, now)
+	if err != nil {
+		panic(err)
+	}
 
-	appContext.Response = struct {
-		Message     string `json:"message"`
-		AccessToken string `json:"accessToken"`
-	}{
-		Message:     ok,
-		AccessToken: accessToken,
+	appContext.Response = accessTokenResponse{
+		Message:      ok,
+		AccessToken:  accessToken,
+		ExpiresAt:    expiresAt,
+		RefreshToken: nextToken,
 	}
 }
 
 // AccountLogout = PUT: /account/logout
+// Ends the current Session only, see AccountSessionsDelete to log out everywhere
 func AccountLogout(ctx context.Context, res http.ResponseWriter, req *http.Request) {
 	appContext := ctx.Value("appContext").(*AppContext)
 
-	if appContext.AuthUser != nil {
+	if appContext.Session != nil {
+		err := appContext.Session.Delete()
+		if err != nil {
+			panic(err)
+		}
+	} else if appContext.AuthUser != nil {
+		// logged in with a legacy access token
 		authUser := appContext.AuthUser
 
 		authUser.AuthToken = sql.NullString{
@@ -159,7 +275,325 @@ func AccountLogout(ctx context.Context, res http.ResponseWriter, req *http.Reque
 	}
 }
 
-// AccountSignup = POST/PUT: /account/signup
+// AccountSignup = POST: /account/signup
+// Creates an unverified User, who can log in once they've followed the link in the verification email
 func AccountSignup(ctx context.Context, res http.ResponseWriter, req *http.Request) {
-	res.WriteHeader(http.StatusNotImplemented)
+	appContext := ctx.Value("appContext").(*AppContext)
+
+	settings, err := db.SettingsGet()
+	if err != nil {
+		panic(err)
+	}
+
+	if !settings.SignupEnabled {
+		res.WriteHeader(http.StatusForbidden)
+		appContext.Response = MessageResponse{
+			Message: "Signup is disabled.",
+		}
+		return
+	}
+
+	var newUser models.User
+
+	defer req.Body.Close()
+	err = json.NewDecoder(io.LimitReader(req.Body, maxRequestLength)).Decode(&newUser)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "JSON request body was not in a valid format",
+		}
+		return
+	}
+
+	// only admins can create admin and service users, see UserCreate
+	newUser.IsAdmin = false
+	newUser.IsService = false
+
+	newUser.Sanitise()
+	validationErrors, err := newUser.ValidateCreate()
+	if err != nil {
+		panic(err)
+	}
+
+	model := createResponse{}
+
+	if len(validationErrors) > 0 {
+		model.Message = "User model is invalid."
+		model.Errors = validationErrors
+		appContext.Response = model
+
+		res.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	bcryptWorkFactor := appContext.Settings.AppSettings.BCryptWorkFactor
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newUser.Password), bcryptWorkFactor)
+	if err != nil {
+		panic(err)
+	}
+
+	user := db.User{
+		Name:           newUser.Name,
+		Email:          newUser.Email,
+		HashedPassword: string(hashedPassword),
+		DateCreated:    time.Now().UTC(),
+	}
+
+	err = user.Save()
+	if err != nil {
+		panic(err)
+	}
+
+	err = sendVerificationEmail(appContext, &user)
+	if err != nil {
+		panic(err)
+	}
+
+	model.Message = ok
+	model.ID = &user.ID
+	res.WriteHeader(http.StatusCreated)
+
+	appContext.Response = model
+}
+
+// AccountVerify = GET: /account/verify?token=
+func AccountVerify(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+
+	var notValid = func() {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "Verification token is not valid or has expired.",
+		}
+	}
+
+	token := req.URL.Query().Get("token")
+	if token == "" {
+		notValid()
+		return
+	}
+
+	userToken, err := db.UserTokenFromHash(db.UserTokenPurposeVerifyEmail, hashSecretToken(token))
+	if err == db.ErrEntityNotFound {
+		notValid()
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	used, err := userToken.Use()
+	if err != nil {
+		panic(err)
+	} else if !used {
+		notValid()
+		return
+	}
+
+	user, err := db.UserFromID(userToken.UserID)
+	if err == db.ErrEntityNotFound {
+		notValid()
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	user.IsVerified = true
+
+	err = user.Save()
+	if err != nil {
+		panic(err)
+	}
+
+	appContext.Response = MessageResponse{
+		Message: ok,
+	}
+}
+
+// AccountVerifyResend = PUT: /account/verify/resend
+// Sends a new verification email. The response is the same whether or not the email address has an
+// unverified User, so it can't be used to find out who has signed up.
+func AccountVerifyResend(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	var accountEmail models.AccountEmail
+
+	defer req.Body.Close()
+	err := json.NewDecoder(io.LimitReader(req.Body, maxRequestLength)).Decode(&accountEmail)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "JSON request body was not in a valid format",
+		}
+		return
+	}
+
+	accountEmail.Sanitise()
+	validationErrors, err := accountEmail.Validate()
+	if err != nil {
+		panic(err)
+	}
+
+	if len(validationErrors) > 0 {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = updateResponse{
+			Message: "Email model is invalid.",
+			Errors:  validationErrors,
+		}
+		return
+	}
+
+	user, err := db.UserFromEmail(accountEmail.Email)
+	if err != nil && err != db.ErrEntityNotFound {
+		panic(err)
+	}
+
+	if err == nil && !user.IsVerified {
+		err = sendVerificationEmail(appContext, &user)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	appContext.Response = MessageResponse{
+		Message: ok,
+	}
+}
+
+// AccountForgotPassword = POST: /account/forgot-password
+// Emails a password reset token. The response is the same whether or not the email address has a User,
+// so it can't be used to find out who has an account.
+func AccountForgotPassword(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	var accountEmail models.AccountEmail
+
+	defer req.Body.Close()
+	err := json.NewDecoder(io.LimitReader(req.Body, maxRequestLength)).Decode(&accountEmail)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "JSON request body was not in a valid format",
+		}
+		return
+	}
+
+	accountEmail.Sanitise()
+	validationErrors, err := accountEmail.Validate()
+	if err != nil {
+		panic(err)
+	}
+
+	if len(validationErrors) > 0 {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = updateResponse{
+			Message: "Email model is invalid.",
+			Errors:  validationErrors,
+		}
+		return
+	}
+
+	user, err := db.UserFromEmail(accountEmail.Email)
+	if err != nil && err != db.ErrEntityNotFound {
+		panic(err)
+	}
+
+	if err == nil {
+		// sent in the background so the response doesn't take longer when the User exists
+		go func() {
+			if err := sendPasswordResetEmail(appContext, &user); err != nil {
+				log.Printf("problem sending password reset email to user %s: %s\n", user.ID, err)
+			}
+		}()
+	}
+
+	appContext.Response = MessageResponse{
+		Message: "If there's an account for that email address, a password reset email has been sent.",
+	}
+}
+
+// AccountResetPassword = POST: /account/reset-password
+// Sets a new password with the token from AccountForgotPassword, and logs the User out everywhere
+func AccountResetPassword(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	var resetPassword models.ResetPassword
+
+	defer req.Body.Close()
+	err := json.NewDecoder(io.LimitReader(req.Body, maxRequestLength)).Decode(&resetPassword)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "JSON request body was not in a valid format",
+		}
+		return
+	}
+
+	resetPassword.Sanitise()
+	validationErrors, err := resetPassword.Validate()
+	if err != nil {
+		panic(err)
+	}
+
+	if len(validationErrors) > 0 {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = updateResponse{
+			Message: "ResetPassword model is invalid.",
+			Errors:  validationErrors,
+		}
+		return
+	}
+
+	var notValid = func() {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "Password reset token is not valid or has expired.",
+		}
+	}
+
+	userToken, err := db.UserTokenFromHash(db.UserTokenPurposeResetPassword, hashSecretToken(resetPassword.Token))
+	if err == db.ErrEntityNotFound {
+		notValid()
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	used, err := userToken.Use()
+	if err != nil {
+		panic(err)
+	} else if !used {
+		notValid()
+		return
+	}
+
+	user, err := db.UserFromID(userToken.UserID)
+	if err == db.ErrEntityNotFound {
+		notValid()
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	bcryptWorkFactor := appContext.Settings.AppSettings.BCryptWorkFactor
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(resetPassword.Password), bcryptWorkFactor)
+	if err != nil {
+		panic(err)
+	}
+
+	user.HashedPassword = string(hashedPassword)
+	// the reset email reached them, so the email address is theirs
+	user.IsVerified = true
+
+	err = user.Save()
+	if err != nil {
+		panic(err)
+	}
+
+	err = user.DeleteTokens(db.UserTokenPurposeResetPassword)
+	if err != nil {
+		panic(err)
+	}
+
+	revokeSessions(&user, "")
+
+	appContext.Response = MessageResponse{
+		Message: ok,
+	}
 }
@@ -0,0 +1,309 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"goji.io/pat"
+
+	"github.com/lib/pq"
+	"github.com/sironfoot/go-twitter-bot/data/db"
+	"github.com/sironfoot/go-twitter-bot/data/models"
+	"golang.org/x/net/context"
+)
+
+// apiKeyPrefix starts every API key, so they can be told apart from access tokens and recognised if leaked
+const apiKeyPrefix = "gbk_"
+
+type apiKey struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Prefix      string     `json:"prefix"`
+	Scopes      []string   `json:"scopes"`
+	DateCreated time.Time  `json:"dateCreated"`
+	LastUsed    *time.Time `json:"lastUsed"`
+	ExpiresOn   *time.Time `json:"expiresOn"`
+}
+
+// newAPIKey returns a new API key, gbk_PREFIX_SECRET, and its prefix for identifying it
+func newAPIKey() (string, string) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	prefix := apiKeyPrefix + hex.EncodeToString(b)
+	return prefix + "_" + newSecretToken(), prefix
+}
+
+// isAPIKey determines if a bearer token is an API key rather than an access token
+func isAPIKey(token string) bool {
+	return strings.HasPrefix(token, apiKeyPrefix)
+}
+
+// AuthenticateAPIKey returns the service User and their APIKey for the request's Authorization: Bearer
+// header, or nil if it isn't an API key, or the key isn't valid or has expired
+func AuthenticateAPIKey(req *http.Request) (*db.User, *db.APIKey, error) {
+	token := requestToken(req)
+	if !isAPIKey(token) {
+		return nil, nil, nil
+	}
+
+	apiKeyDB, err := db.APIKeyFromHash(hashSecretToken(token))
+	if err == db.ErrEntityNotFound {
+		return nil, nil, nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now().UTC()
+	if apiKeyDB.IsExpired(now) {
+		return nil, nil, nil
+	}
+
+	user, err := db.UserFromID(apiKeyDB.UserID)
+	if err == db.ErrEntityNotFound || (err == nil && !user.IsService) {
+		return nil, nil, nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	if !apiKeyDB.LastUsed.Valid || now.Sub(apiKeyDB.LastUsed.Time) >= sessionTouchInterval {
+		if err = apiKeyDB.Touch(now); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return &user, &apiKeyDB, nil
+}
+
+// APIKeyAllows determines if an APIKey's scopes allow the request, read only keys can only make GET requests
+func APIKeyAllows(apiKeyDB *db.APIKey, req *http.Request) bool {
+	if apiKeyDB.HasScope(db.APIKeyScopeWrite) {
+		return true
+	}
+
+	return apiKeyDB.HasScope(db.APIKeyScopeRead) && (req.Method == http.MethodGet || req.Method == http.MethodHead)
+}
+
+func newAPIKeyModel(apiKeyDB db.APIKey) apiKey {
+	model := apiKey{
+		ID:          apiKeyDB.ID,
+		Name:        apiKeyDB.Name,
+		Prefix:      apiKeyDB.Prefix,
+		Scopes:      apiKeyDB.Scopes,
+		DateCreated: apiKeyDB.DateCreated,
+	}
+
+	if apiKeyDB.LastUsed.Valid {
+		lastUsed := apiKeyDB.LastUsed.Time
+		model.LastUsed = &lastUsed
+	}
+
+	if apiKeyDB.ExpiresOn.Valid {
+		expiresOn := apiKeyDB.ExpiresOn.Time
+		model.ExpiresOn = &expiresOn
+	}
+
+	return model
+}
+
+// UserAPIKeysAll = GET: /users/:userID/apiKeys
+func UserAPIKeysAll(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	userID := pat.Param(ctx, "userID")
+
+	// non-admins can only view their own API keys
+	if !appContext.AuthUser.IsAdmin && appContext.AuthUser.ID != userID {
+		appContext.Response = MessageResponse{
+			Message: "This resource is only available to users with administrator rights.",
+		}
+		res.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	userDB, err := db.UserFromID(userID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("User not found on ID: %s", userID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	apiKeysDB, err := userDB.GetAPIKeys()
+	if err != nil {
+		panic(err)
+	}
+
+	apiKeys := []apiKey{}
+	for _, apiKeyDB := range apiKeysDB {
+		apiKeys = append(apiKeys, newAPIKeyModel(apiKeyDB))
+	}
+
+	appContext.Response = struct {
+		Message string   `json:"message"`
+		APIKeys []apiKey `json:"apiKeys"`
+	}{ok, apiKeys}
+}
+
+// UserAPIKeyCreate = POST: /users/:userID/apiKeys
+// Creates an API key for a service User, the key is only returned this once
+func UserAPIKeyCreate(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	userID := pat.Param(ctx, "userID")
+
+	// non-admins can only create their own API keys
+	if !appContext.AuthUser.IsAdmin && appContext.AuthUser.ID != userID {
+		appContext.Response = MessageResponse{
+			Message: "This resource is only available to users with administrator rights.",
+		}
+		res.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	// otherwise a leaked key could be used to make more
+	if appContext.APIKey != nil {
+		res.WriteHeader(http.StatusForbidden)
+		appContext.Response = MessageResponse{
+			Message: "API keys can't be created with an API key. Please authenticate with PUT: /account/login",
+		}
+		return
+	}
+
+	userDB, err := db.UserFromID(userID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("User not found on ID: %s", userID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if !userDB.IsService {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "API keys can only be created for service users.",
+		}
+		return
+	}
+
+	var newAPIKeyModel models.APIKey
+
+	defer req.Body.Close()
+	err = json.NewDecoder(io.LimitReader(req.Body, maxRequestLength)).Decode(&newAPIKeyModel)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "JSON request body was not in a valid format",
+		}
+		return
+	}
+
+	newAPIKeyModel.Sanitise()
+	validationErrors, err := newAPIKeyModel.Validate()
+	if err != nil {
+		panic(err)
+	}
+
+	model := struct {
+		createResponse
+		Key    string `json:"key,omitempty"`
+		Prefix string `json:"prefix,omitempty"`
+	}{}
+
+	if len(validationErrors) > 0 {
+		model.Message = "APIKey model is invalid."
+		model.Errors = validationErrors
+		appContext.Response = model
+
+		res.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	key, prefix := newAPIKey()
+
+	apiKeyDB := db.APIKey{
+		UserID:      userDB.ID,
+		Name:        newAPIKeyModel.Name,
+		Prefix:      prefix,
+		KeyHash:     hashSecretToken(key),
+		Scopes:      pq.StringArray(newAPIKeyModel.Scopes),
+		DateCreated: time.Now().UTC(),
+	}
+
+	if newAPIKeyModel.ExpiresOn != nil {
+		apiKeyDB.ExpiresOn = pq.NullTime{Time: newAPIKeyModel.ExpiresOn.UTC(), Valid: true}
+	}
+
+	err = apiKeyDB.Save()
+	if err != nil {
+		panic(err)
+	}
+
+	model.Message = ok
+	model.ID = &apiKeyDB.ID
+	model.Key = key
+	model.Prefix = prefix
+	res.WriteHeader(http.StatusCreated)
+
+	appContext.Response = model
+}
+
+// UserAPIKeyDelete = DELETE: /users/:userID/apiKeys/:apiKeyID
+// Revokes an API key, it stops working straight away
+func UserAPIKeyDelete(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	userID := pat.Param(ctx, "userID")
+	apiKeyID := pat.Param(ctx, "apiKeyID")
+
+	// non-admins can only revoke their own API keys
+	if !appContext.AuthUser.IsAdmin && appContext.AuthUser.ID != userID {
+		appContext.Response = MessageResponse{
+			Message: "This resource is only available to users with administrator rights.",
+		}
+		res.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	userDB, err := db.UserFromID(userID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("User not found on ID: %s", userID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	apiKeyDB, err := userDB.GetAPIKeyFromID(apiKeyID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("API key not found on ID: %s", apiKeyID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	err = apiKeyDB.Delete()
+	if err != nil {
+		panic(err)
+	}
+
+	appContext.Response = MessageResponse{
+		Message: ok,
+	}
+}
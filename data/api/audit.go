@@ -0,0 +1,133 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"goji.io/pat"
+
+	"github.com/sironfoot/go-twitter-bot/data/db"
+	"golang.org/x/net/context"
+)
+
+type auditEntry struct {
+	ID          string    `json:"id"`
+	UserID      *string   `json:"userId"`
+	ActorID     *string   `json:"actorId"`
+	Event       string    `json:"event"`
+	IPAddress   string    `json:"ipAddress"`
+	Detail      string    `json:"detail"`
+	DateCreated time.Time `json:"dateCreated"`
+}
+
+// AuditEntriesAll = GET: /audit
+// Lists security related events such as lockouts, filtered to a User with ?userID=
+func AuditEntriesAll(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+
+	// admins only
+	if !appContext.AuthUser.IsAdmin {
+		appContext.Response = MessageResponse{
+			Message: "This resource is only available to users with administrator rights.",
+		}
+		res.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	defaults := getPagingDefaults(db.AuditEntriesOrderByDateCreated, false, db.AuditEntriesSortableColumns)
+	paging, err := ExtractAndValidatePagingInfo(req, defaults)
+	if err != nil {
+		appContext.Response = MessageResponse{err.Error()}
+		return
+	}
+
+	model := struct {
+		pagedResponse
+		AuditEntries []auditEntry `json:"auditEntries"`
+	}{}
+
+	auditEntriesDB, totalRecords, err := db.AuditEntriesAll(req.URL.Query().Get("userID"), paging)
+	if err != nil {
+		panic(err)
+	}
+
+	var auditEntries []auditEntry
+	for _, auditEntryDB := range auditEntriesDB {
+		entry := auditEntry{
+			ID:          auditEntryDB.ID,
+			Event:       auditEntryDB.Event,
+			IPAddress:   auditEntryDB.IPAddress,
+			Detail:      auditEntryDB.Detail,
+			DateCreated: auditEntryDB.DateCreated,
+		}
+
+		if auditEntryDB.UserID.Valid {
+			userID := auditEntryDB.UserID.String
+			entry.UserID = &userID
+		}
+
+		if auditEntryDB.ActorID.Valid {
+			actorID := auditEntryDB.ActorID.String
+			entry.ActorID = &actorID
+		}
+
+		auditEntries = append(auditEntries, entry)
+	}
+
+	model.Message = ok
+	model.Page = paging.Page
+	model.RecordsPerPage = paging.RecordsPerPage
+	model.TotalRecords = totalRecords
+	model.AuditEntries = auditEntries
+
+	appContext.Response = model
+}
+
+// UserUnlock = PUT: /users/:userID/unlock
+// Clears the failed logins for a User's email address, ending a lockout early
+func UserUnlock(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	userID := pat.Param(ctx, "userID")
+
+	// admins only
+	if !appContext.AuthUser.IsAdmin {
+		appContext.Response = MessageResponse{
+			Message: "This resource is only available to users with administrator rights.",
+		}
+		res.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	userDB, err := db.UserFromID(userID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("User not found on ID: %s", userID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	email := loginEmail(userDB.Email)
+
+	err = db.LoginFailuresDeleteForEmail(email)
+	if err != nil {
+		panic(err)
+	}
+
+	saveAuditEntry(&db.AuditEntry{
+		UserID:      sql.NullString{String: userDB.ID, Valid: true},
+		ActorID:     sql.NullString{String: appContext.AuthUser.ID, Valid: true},
+		Event:       db.AuditEventLoginUnlocked,
+		IPAddress:   remoteIP(req),
+		Detail:      fmt.Sprintf("%s unlocked by %s", email, appContext.AuthUser.Email),
+		DateCreated: time.Now().UTC(),
+	})
+
+	appContext.Response = MessageResponse{
+		Message: ok,
+	}
+}
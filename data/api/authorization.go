@@ -0,0 +1,137 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/sironfoot/go-twitter-bot/data/db"
+)
+
+// permission is something a User can do with a TwitterAccount, each role has a permission and all those below it
+type permission int
+
+const (
+	// permissionView allows viewing a TwitterAccount, its tweets, rules, feeds and settings
+	permissionView permission = iota
+	// permissionReview allows approving draft tweets
+	permissionReview
+	// permissionEdit allows creating, updating and deleting tweets, reply rules, feeds and evergreen tweets
+	permissionEdit
+	// permissionManage allows changing settings, managing webhooks, seeing credentials and granting access
+	permissionManage
+)
+
+var rolePermissions = map[string]permission{
+	db.TwitterAccountRoleViewer:   permissionView,
+	db.TwitterAccountRoleReviewer: permissionReview,
+	db.TwitterAccountRoleEditor:   permissionEdit,
+	db.TwitterAccountRoleOwner:    permissionManage,
+}
+
+// minimumRole returns the least role with 'required', for telling Users what they need
+func minimumRole(required permission) string {
+	for i := len(db.TwitterAccountRoles) - 1; i >= 0; i-- {
+		if rolePermissions[db.TwitterAccountRoles[i]] >= required {
+			return db.TwitterAccountRoles[i]
+		}
+	}
+	return db.TwitterAccountRoleOwner
+}
+
+// roleAllows determines if 'role' has the 'required' permission, no role allows nothing
+func roleAllows(role string, required permission) bool {
+	granted, ok := rolePermissions[role]
+	return ok && granted >= required
+}
+
+// accountRole returns the User's role on the TwitterAccount, or an empty string if they don't have one.
+// Admins are owners of every TwitterAccount. A TwitterAccount that belongs to an Organisation is only
+// shared through it, so the User's role is their role in the Organisation. A personal TwitterAccount is
+// owned by the User it belongs to, anyone else needs a role granted to them.
+func accountRole(user *db.User, account *db.TwitterAccount) string {
+	if user.IsAdmin {
+		return db.TwitterAccountRoleOwner
+	}
+
+	if account.OrganisationID.Valid {
+		return organisationRole(user, &db.Organisation{ID: account.OrganisationID.String})
+	}
+
+	if account.UserID.Valid && user.ID == account.UserID.String {
+		return db.TwitterAccountRoleOwner
+	}
+
+	granted, err := account.GetRoleForUser(user.ID)
+	if err == db.ErrEntityNotFound {
+		return ""
+	} else if err != nil {
+		panic(err)
+	}
+
+	return granted.Role
+}
+
+// accountRoleFrom returns the User's role on the TwitterAccount like accountRole, but from 'roles' loaded with
+// User.GetTwitterAccountRoles, so listing TwitterAccounts doesn't need a query for each one
+func accountRoleFrom(roles map[string]string, user *db.User, account *db.TwitterAccount) string {
+	if user.IsAdmin {
+		return db.TwitterAccountRoleOwner
+	}
+
+	if !account.OrganisationID.Valid && account.UserID.Valid && user.ID == account.UserID.String {
+		return db.TwitterAccountRoleOwner
+	}
+
+	return roles[account.ID]
+}
+
+// can determines if the User has the 'required' permission on the TwitterAccount
+func can(user *db.User, account *db.TwitterAccount, required permission) bool {
+	return roleAllows(accountRole(user, account), required)
+}
+
+// authorize is the policy check for every TwitterAccount endpoint, it determines if the logged in User has
+// the 'required' permission on the TwitterAccount, responding with 403 Forbidden when they don't
+func authorize(appContext *AppContext, res http.ResponseWriter, account *db.TwitterAccount, required permission) bool {
+	if can(appContext.AuthUser, account, required) {
+		return true
+	}
+
+	appContext.Response = MessageResponse{
+		Message: fmt.Sprintf("This resource is only available to users with the %s role on this TwitterAccount.", minimumRole(required)),
+	}
+	res.WriteHeader(http.StatusForbidden)
+	return false
+}
+
+// organisationRole returns the User's role in the Organisation, or an empty string if they aren't a member.
+// Admins are owners of every Organisation.
+func organisationRole(user *db.User, organisation *db.Organisation) string {
+	if user.IsAdmin {
+		return db.TwitterAccountRoleOwner
+	}
+
+	member, err := organisation.GetMember(user.ID)
+	if err == db.ErrEntityNotFound {
+		return ""
+	} else if err != nil {
+		panic(err)
+	}
+
+	return member.Role
+}
+
+// authorizeOrganisation is the policy check for Organisation endpoints, it determines if the logged in User's role in
+// the Organisation has the 'required' permission, responding with 403 Forbidden when it doesn't. Any member can view
+// an Organisation, only owners can manage it.
+func authorizeOrganisation(appContext *AppContext, res http.ResponseWriter, organisation *db.Organisation, required permission) bool {
+	if roleAllows(organisationRole(appContext.AuthUser, organisation), required) {
+		return true
+	}
+
+	appContext.Response = MessageResponse{
+		Message: fmt.Sprintf("This resource is only available to users with the %s role in this Organisation.", minimumRole(required)),
+	}
+	res.WriteHeader(http.StatusForbidden)
+	return false
+}
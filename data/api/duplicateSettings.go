@@ -0,0 +1,114 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"goji.io/pat"
+
+	"golang.org/x/net/context"
+
+	"github.com/sironfoot/go-twitter-bot/data/db"
+	"github.com/sironfoot/go-twitter-bot/data/models"
+)
+
+type duplicateSettings struct {
+	Policy     string `json:"policy"`
+	WindowDays int    `json:"windowDays"`
+}
+
+// TwitterAccountDuplicateSettingsGet = GET: /twitterAccounts/:twitterAccountID/duplicateSettings
+func TwitterAccountDuplicateSettingsGet(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	twitterAccountID := pat.Param(ctx, "twitterAccountID")
+
+	account, err := db.TwitterAccountFromID(twitterAccountID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("TwitterAccount not found on ID: %s", twitterAccountID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if !authorize(appContext, res, &account.TwitterAccount, permissionView) {
+		return
+	}
+
+	model := struct {
+		MessageResponse
+		DuplicateSettings duplicateSettings `json:"duplicateSettings"`
+	}{}
+
+	model.Message = ok
+	model.DuplicateSettings = duplicateSettings{
+		Policy:     account.DuplicatePolicy,
+		WindowDays: account.DuplicateWindowDays,
+	}
+
+	appContext.Response = model
+}
+
+// TwitterAccountDuplicateSettingsUpdate = PUT: /twitterAccounts/:twitterAccountID/duplicateSettings
+func TwitterAccountDuplicateSettingsUpdate(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	twitterAccountID := pat.Param(ctx, "twitterAccountID")
+
+	account, err := db.TwitterAccountFromID(twitterAccountID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("TwitterAccount not found on ID: %s", twitterAccountID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if !authorize(appContext, res, &account.TwitterAccount, permissionManage) {
+		return
+	}
+
+	var updateSettings models.DuplicateSettings
+
+	defer req.Body.Close()
+	err = json.NewDecoder(io.LimitReader(req.Body, maxRequestLength)).Decode(&updateSettings)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "JSON request body was not in a valid format",
+		}
+		return
+	}
+
+	updateSettings.Sanitise()
+	validationErrors, err := updateSettings.ValidateUpdate(account.ID)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(validationErrors) > 0 {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = updateResponse{
+			Message: "DuplicateSettings model is invalid.",
+			Errors:  validationErrors,
+		}
+		return
+	}
+
+	account.DuplicatePolicy = updateSettings.Policy
+	account.DuplicateWindowDays = updateSettings.WindowDays
+
+	err = account.Save()
+	if err != nil {
+		panic(err)
+	}
+
+	appContext.Response = MessageResponse{
+		Message: ok,
+	}
+}
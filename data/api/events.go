@@ -0,0 +1,125 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/sironfoot/go-twitter-bot/data/events"
+)
+
+// eventsHeartbeat is how often a comment is sent to keep idle event streams open through proxies
+const eventsHeartbeat = 30 * time.Second
+
+// EventsStream = GET: /events
+// Streams schedule changes for the TwitterAccounts the user can see as Server-Sent Events,
+// optionally for a single account with ?twitterAccountID=, resuming after the Last-Event-ID header
+func EventsStream(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+
+	flusher, ok := res.(http.Flusher)
+	if !ok {
+		res.WriteHeader(http.StatusInternalServerError)
+		appContext.Response = MessageResponse{
+			Message: "Streaming is not supported.",
+		}
+		return
+	}
+
+	var lastEventID int64
+	if header := req.Header.Get("Last-Event-ID"); header != "" {
+		id, err := strconv.ParseInt(header, 10, 64)
+		if err != nil {
+			res.WriteHeader(http.StatusBadRequest)
+			appContext.Response = MessageResponse{
+				Message: "Last-Event-ID header must be an event ID.",
+			}
+			return
+		}
+		lastEventID = id
+	}
+
+	twitterAccountID := req.URL.Query().Get("twitterAccountID")
+
+	// roles granted while the stream is open apply once the client reconnects
+	roles, err := appContext.AuthUser.GetTwitterAccountRoles()
+	if err != nil {
+		panic(err)
+	}
+
+	canSee := func(event events.Event) bool {
+		if twitterAccountID != "" && event.AccountID != twitterAccountID {
+			return false
+		}
+
+		return appContext.AuthUser.IsAdmin || event.UserID == appContext.AuthUser.ID || roleAllows(roles[event.AccountID], permissionView)
+	}
+
+	// subscribe before catching up, so nothing published in between is missed
+	subscriber := events.DefaultBroker.Subscribe()
+	defer events.DefaultBroker.Unsubscribe(subscriber)
+
+	res.Header().Set("Content-Type", "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	res.WriteHeader(http.StatusOK)
+
+	send := func(event events.Event) error {
+		if event.ID <= lastEventID || !canSee(event) {
+			return nil
+		}
+
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(res, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+		if err != nil {
+			return err
+		}
+
+		lastEventID = event.ID
+		flusher.Flush()
+		return nil
+	}
+
+	if lastEventID > 0 {
+		for _, event := range events.DefaultBroker.Since(lastEventID) {
+			if err := send(event); err != nil {
+				return
+			}
+		}
+	}
+
+	fmt.Fprint(res, ": connected\n\n")
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(eventsHeartbeat)
+	defer heartbeat.Stop()
+
+	var closed <-chan bool
+	if notifier, ok := res.(http.CloseNotifier); ok {
+		closed = notifier.CloseNotify()
+	}
+
+	for {
+		select {
+		case event := <-subscriber:
+			if err := send(event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(res, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-closed:
+			return
+		}
+	}
+}
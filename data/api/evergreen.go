@@ -0,0 +1,434 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"goji.io/pat"
+
+	"golang.org/x/net/context"
+
+	"github.com/sironfoot/go-twitter-bot/data/db"
+	"github.com/sironfoot/go-twitter-bot/data/models"
+)
+
+type evergreenItem struct {
+	ID            string     `json:"id"`
+	Text          string     `json:"text"`
+	Category      string     `json:"category"`
+	MinRepostDays int        `json:"minRepostDays"`
+	IsPaused      bool       `json:"isPaused"`
+	LastUsed      *time.Time `json:"lastUsed"`
+	TimesUsed     int        `json:"timesUsed"`
+	DateCreated   time.Time  `json:"dateCreated"`
+}
+
+type evergreenItemUse struct {
+	TweetID *string   `json:"tweetId"`
+	UsedFor time.Time `json:"usedFor"`
+}
+
+type evergreenSettings struct {
+	Slots        []string `json:"slots"`
+	NoRepeatDays int      `json:"noRepeatDays"`
+}
+
+// TwitterAccountEvergreenAll = GET: /twitterAccounts/:twitterAccountID/evergreen
+func TwitterAccountEvergreenAll(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	twitterAccountID := pat.Param(ctx, "twitterAccountID")
+
+	account, err := db.TwitterAccountFromID(twitterAccountID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("TwitterAccount not found on ID: %s", twitterAccountID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if !authorize(appContext, res, &account.TwitterAccount, permissionView) {
+		return
+	}
+
+	itemsDB, err := account.GetEvergreenItems()
+	if err != nil {
+		panic(err)
+	}
+
+	model := struct {
+		MessageResponse
+		Evergreen []evergreenItem `json:"evergreen"`
+	}{}
+
+	model.Message = ok
+	model.Evergreen = make([]evergreenItem, 0)
+
+	for _, itemDB := range itemsDB {
+		item := evergreenItem{
+			ID:            itemDB.ID,
+			Text:          itemDB.Tweet,
+			Category:      itemDB.Category,
+			MinRepostDays: itemDB.MinRepostDays,
+			IsPaused:      itemDB.IsPaused,
+			TimesUsed:     itemDB.TimesUsed,
+			DateCreated:   itemDB.DateCreated,
+		}
+
+		if itemDB.LastUsed.Valid {
+			lastUsed := itemDB.LastUsed.Time
+			item.LastUsed = &lastUsed
+		}
+
+		model.Evergreen = append(model.Evergreen, item)
+	}
+
+	appContext.Response = model
+}
+
+// TwitterAccountEvergreenCreate = POST: /twitterAccounts/:twitterAccountID/evergreen
+func TwitterAccountEvergreenCreate(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	twitterAccountID := pat.Param(ctx, "twitterAccountID")
+
+	account, err := db.TwitterAccountFromID(twitterAccountID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("TwitterAccount not found on ID: %s", twitterAccountID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if !authorize(appContext, res, &account.TwitterAccount, permissionEdit) {
+		return
+	}
+
+	var newItem models.EvergreenItem
+
+	defer req.Body.Close()
+	err = json.NewDecoder(io.LimitReader(req.Body, maxRequestLength)).Decode(&newItem)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "JSON request body was not in a valid format",
+		}
+		return
+	}
+
+	newItem.Sanitise()
+	validationErrors, err := newItem.ValidateCreate()
+	if err != nil {
+		panic(err)
+	}
+
+	model := createResponse{}
+
+	if len(validationErrors) > 0 {
+		model.Message = "EvergreenItem model is invalid."
+		model.Errors = validationErrors
+		appContext.Response = model
+
+		res.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	item := &db.EvergreenItem{
+		AccountID:     account.ID,
+		Tweet:         newItem.Text,
+		Category:      newItem.Category,
+		MinRepostDays: newItem.MinRepostDays,
+		IsPaused:      newItem.IsPaused,
+		DateCreated:   time.Now().UTC(),
+	}
+
+	err = item.Save()
+	if err != nil {
+		panic(err)
+	}
+
+	model.Message = ok
+	model.ID = &item.ID
+	res.WriteHeader(http.StatusCreated)
+
+	appContext.Response = model
+}
+
+// TwitterAccountEvergreenUpdate = PUT: /twitterAccounts/:twitterAccountID/evergreen/:evergreenID
+func TwitterAccountEvergreenUpdate(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	twitterAccountID := pat.Param(ctx, "twitterAccountID")
+
+	account, err := db.TwitterAccountFromID(twitterAccountID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("TwitterAccount not found on ID: %s", twitterAccountID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if !authorize(appContext, res, &account.TwitterAccount, permissionEdit) {
+		return
+	}
+
+	evergreenID := pat.Param(ctx, "evergreenID")
+	item, err := account.GetEvergreenItemFromID(evergreenID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("EvergreenItem not found on ID: %s", evergreenID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	var updateItem models.EvergreenItem
+
+	defer req.Body.Close()
+	err = json.NewDecoder(io.LimitReader(req.Body, maxRequestLength)).Decode(&updateItem)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "JSON request body was not in a valid format",
+		}
+		return
+	}
+
+	updateItem.Sanitise()
+	validationErrors, err := updateItem.ValidateUpdate(evergreenID)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(validationErrors) > 0 {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = updateResponse{
+			Message: "EvergreenItem model is invalid.",
+			Errors:  validationErrors,
+		}
+		return
+	}
+
+	item.Tweet = updateItem.Text
+	item.Category = updateItem.Category
+	item.MinRepostDays = updateItem.MinRepostDays
+	item.IsPaused = updateItem.IsPaused
+
+	err = item.Save()
+	if err != nil {
+		panic(err)
+	}
+
+	appContext.Response = MessageResponse{
+		Message: ok,
+	}
+}
+
+// TwitterAccountEvergreenDelete = DELETE: /twitterAccounts/:twitterAccountID/evergreen/:evergreenID
+func TwitterAccountEvergreenDelete(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	twitterAccountID := pat.Param(ctx, "twitterAccountID")
+
+	account, err := db.TwitterAccountFromID(twitterAccountID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("TwitterAccount not found on ID: %s", twitterAccountID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if !authorize(appContext, res, &account.TwitterAccount, permissionEdit) {
+		return
+	}
+
+	evergreenID := pat.Param(ctx, "evergreenID")
+	item, err := account.GetEvergreenItemFromID(evergreenID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("EvergreenItem not found on ID: %s", evergreenID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	err = item.Delete()
+	if err != nil {
+		panic(err)
+	}
+
+	appContext.Response = MessageResponse{
+		Message: ok,
+	}
+}
+
+// TwitterAccountEvergreenHistory = GET: /twitterAccounts/:twitterAccountID/evergreen/:evergreenID/history
+func TwitterAccountEvergreenHistory(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	twitterAccountID := pat.Param(ctx, "twitterAccountID")
+
+	account, err := db.TwitterAccountFromID(twitterAccountID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("TwitterAccount not found on ID: %s", twitterAccountID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if !authorize(appContext, res, &account.TwitterAccount, permissionView) {
+		return
+	}
+
+	evergreenID := pat.Param(ctx, "evergreenID")
+	item, err := account.GetEvergreenItemFromID(evergreenID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("EvergreenItem not found on ID: %s", evergreenID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	usesDB, err := item.GetUses()
+	if err != nil {
+		panic(err)
+	}
+
+	model := struct {
+		MessageResponse
+		History []evergreenItemUse `json:"history"`
+	}{}
+
+	model.Message = ok
+	model.History = make([]evergreenItemUse, 0)
+
+	for _, useDB := range usesDB {
+		use := evergreenItemUse{
+			UsedFor: useDB.UsedFor,
+		}
+
+		if useDB.TweetID.Valid {
+			tweetID := useDB.TweetID.String
+			use.TweetID = &tweetID
+		}
+
+		model.History = append(model.History, use)
+	}
+
+	appContext.Response = model
+}
+
+// TwitterAccountEvergreenSettingsGet = GET: /twitterAccounts/:twitterAccountID/evergreenSettings
+func TwitterAccountEvergreenSettingsGet(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	twitterAccountID := pat.Param(ctx, "twitterAccountID")
+
+	account, err := db.TwitterAccountFromID(twitterAccountID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("TwitterAccount not found on ID: %s", twitterAccountID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if !authorize(appContext, res, &account.TwitterAccount, permissionView) {
+		return
+	}
+
+	model := struct {
+		MessageResponse
+		EvergreenSettings evergreenSettings `json:"evergreenSettings"`
+	}{}
+
+	model.Message = ok
+	model.EvergreenSettings = evergreenSettings{
+		Slots:        make([]string, 0),
+		NoRepeatDays: account.EvergreenNoRepeatDays,
+	}
+	model.EvergreenSettings.Slots = append(model.EvergreenSettings.Slots, account.EvergreenSlots...)
+
+	appContext.Response = model
+}
+
+// TwitterAccountEvergreenSettingsUpdate = PUT: /twitterAccounts/:twitterAccountID/evergreenSettings
+func TwitterAccountEvergreenSettingsUpdate(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	twitterAccountID := pat.Param(ctx, "twitterAccountID")
+
+	account, err := db.TwitterAccountFromID(twitterAccountID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("TwitterAccount not found on ID: %s", twitterAccountID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if !authorize(appContext, res, &account.TwitterAccount, permissionManage) {
+		return
+	}
+
+	var updateSettings models.EvergreenSettings
+
+	defer req.Body.Close()
+	err = json.NewDecoder(io.LimitReader(req.Body, maxRequestLength)).Decode(&updateSettings)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "JSON request body was not in a valid format",
+		}
+		return
+	}
+
+	updateSettings.Sanitise()
+	validationErrors, err := updateSettings.ValidateUpdate(account.ID)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(validationErrors) > 0 {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = updateResponse{
+			Message: "EvergreenSettings model is invalid.",
+			Errors:  validationErrors,
+		}
+		return
+	}
+
+	account.EvergreenSlots = updateSettings.Slots
+	account.EvergreenNoRepeatDays = updateSettings.NoRepeatDays
+
+	err = account.Save()
+	if err != nil {
+		panic(err)
+	}
+
+	appContext.Response = MessageResponse{
+		Message: ok,
+	}
+}
@@ -0,0 +1,273 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"goji.io/pat"
+
+	"golang.org/x/net/context"
+
+	"github.com/lib/pq"
+	"github.com/sironfoot/go-twitter-bot/data/db"
+	"github.com/sironfoot/go-twitter-bot/data/models"
+)
+
+type feedSubscription struct {
+	ID                  string     `json:"id"`
+	URL                 string     `json:"url"`
+	PollIntervalMinutes int        `json:"pollIntervalMinutes"`
+	Template            string     `json:"template"`
+	CreateAsDraft       bool       `json:"createAsDraft"`
+	LastPolled          *time.Time `json:"lastPolled"`
+	DateCreated         time.Time  `json:"dateCreated"`
+}
+
+// TwitterAccountFeedsAll = GET: /twitterAccounts/:twitterAccountID/feeds
+func TwitterAccountFeedsAll(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	twitterAccountID := pat.Param(ctx, "twitterAccountID")
+
+	account, err := db.TwitterAccountFromID(twitterAccountID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("TwitterAccount not found on ID: %s", twitterAccountID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if !authorize(appContext, res, &account.TwitterAccount, permissionView) {
+		return
+	}
+
+	subscriptionsDB, err := account.GetFeedSubscriptions()
+	if err != nil {
+		panic(err)
+	}
+
+	model := struct {
+		MessageResponse
+		Feeds []feedSubscription `json:"feeds"`
+	}{}
+
+	model.Message = ok
+	model.Feeds = make([]feedSubscription, 0)
+
+	for _, subscriptionDB := range subscriptionsDB {
+		subscription := feedSubscription{
+			ID:                  subscriptionDB.ID,
+			URL:                 subscriptionDB.URL,
+			PollIntervalMinutes: subscriptionDB.PollIntervalMinutes,
+			Template:            subscriptionDB.Template,
+			CreateAsDraft:       subscriptionDB.CreateAsDraft,
+			DateCreated:         subscriptionDB.DateCreated,
+		}
+
+		if subscriptionDB.LastPolled.Valid {
+			lastPolled := subscriptionDB.LastPolled.Time
+			subscription.LastPolled = &lastPolled
+		}
+
+		model.Feeds = append(model.Feeds, subscription)
+	}
+
+	appContext.Response = model
+}
+
+// TwitterAccountFeedCreate = POST: /twitterAccounts/:twitterAccountID/feeds
+func TwitterAccountFeedCreate(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	twitterAccountID := pat.Param(ctx, "twitterAccountID")
+
+	account, err := db.TwitterAccountFromID(twitterAccountID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("TwitterAccount not found on ID: %s", twitterAccountID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if !authorize(appContext, res, &account.TwitterAccount, permissionEdit) {
+		return
+	}
+
+	var newSubscription models.FeedSubscription
+
+	defer req.Body.Close()
+	err = json.NewDecoder(io.LimitReader(req.Body, maxRequestLength)).Decode(&newSubscription)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "JSON request body was not in a valid format",
+		}
+		return
+	}
+
+	newSubscription.Sanitise()
+	validationErrors, err := newSubscription.ValidateCreate()
+	if err != nil {
+		panic(err)
+	}
+
+	model := createResponse{}
+
+	if len(validationErrors) > 0 {
+		model.Message = "FeedSubscription model is invalid."
+		model.Errors = validationErrors
+		appContext.Response = model
+
+		res.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	subscription := &db.FeedSubscription{
+		AccountID:           account.ID,
+		URL:                 newSubscription.URL,
+		PollIntervalMinutes: newSubscription.PollIntervalMinutes,
+		Template:            newSubscription.Template,
+		CreateAsDraft:       newSubscription.CreateAsDraft,
+		DateCreated:         time.Now().UTC(),
+	}
+
+	err = subscription.Save()
+	if err != nil {
+		panic(err)
+	}
+
+	model.Message = ok
+	model.ID = &subscription.ID
+	res.WriteHeader(http.StatusCreated)
+
+	appContext.Response = model
+}
+
+// TwitterAccountFeedUpdate = PUT: /twitterAccounts/:twitterAccountID/feeds/:feedID
+func TwitterAccountFeedUpdate(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	twitterAccountID := pat.Param(ctx, "twitterAccountID")
+
+	account, err := db.TwitterAccountFromID(twitterAccountID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("TwitterAccount not found on ID: %s", twitterAccountID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if !authorize(appContext, res, &account.TwitterAccount, permissionEdit) {
+		return
+	}
+
+	feedID := pat.Param(ctx, "feedID")
+	subscription, err := account.GetFeedSubscriptionFromID(feedID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("FeedSubscription not found on ID: %s", feedID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	var updateSubscription models.FeedSubscription
+
+	defer req.Body.Close()
+	err = json.NewDecoder(io.LimitReader(req.Body, maxRequestLength)).Decode(&updateSubscription)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "JSON request body was not in a valid format",
+		}
+		return
+	}
+
+	updateSubscription.Sanitise()
+	validationErrors, err := updateSubscription.ValidateUpdate(feedID)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(validationErrors) > 0 {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = updateResponse{
+			Message: "FeedSubscription model is invalid.",
+			Errors:  validationErrors,
+		}
+		return
+	}
+
+	if subscription.URL != updateSubscription.URL {
+		// poll the new URL straight away, and record what's already in it without tweeting it
+		subscription.LastPolled = pq.NullTime{}
+		subscription.IsSeeded = false
+	}
+
+	subscription.URL = updateSubscription.URL
+	subscription.PollIntervalMinutes = updateSubscription.PollIntervalMinutes
+	subscription.Template = updateSubscription.Template
+	subscription.CreateAsDraft = updateSubscription.CreateAsDraft
+
+	err = subscription.Save()
+	if err != nil {
+		panic(err)
+	}
+
+	appContext.Response = MessageResponse{
+		Message: ok,
+	}
+}
+
+// TwitterAccountFeedDelete = DELETE: /twitterAccounts/:twitterAccountID/feeds/:feedID
+func TwitterAccountFeedDelete(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	twitterAccountID := pat.Param(ctx, "twitterAccountID")
+
+	account, err := db.TwitterAccountFromID(twitterAccountID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("TwitterAccount not found on ID: %s", twitterAccountID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if !authorize(appContext, res, &account.TwitterAccount, permissionEdit) {
+		return
+	}
+
+	feedID := pat.Param(ctx, "feedID")
+	subscription, err := account.GetFeedSubscriptionFromID(feedID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("FeedSubscription not found on ID: %s", feedID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	err = subscription.Delete()
+	if err != nil {
+		panic(err)
+	}
+
+	appContext.Response = MessageResponse{
+		Message: ok,
+	}
+}
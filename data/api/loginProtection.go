@@ -0,0 +1,223 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sironfoot/go-twitter-bot/data/db"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	defaultMaxFailedLogins      = 5
+	defaultMaxFailedLoginsPerIP = 50
+	defaultFailureWindow        = 15 * time.Minute
+	defaultLockout              = 15 * time.Minute
+	defaultFailedLoginDelay     = 250 * time.Millisecond
+	defaultMaxFailedLoginDelay  = 5 * time.Second
+)
+
+// LoginProtection represents settings for slowing down and locking out repeated failed logins, zero values use defaults
+type LoginProtection struct {
+	// MaxFailedLogins is how many failed logins for an email address within the window lock it out
+	MaxFailedLogins int `json:"maxFailedLogins"`
+	// MaxFailedLoginsPerIP is how many failed logins from an IP address, for any email, within the window block it
+	MaxFailedLoginsPerIP int `json:"maxFailedLoginsPerIP"`
+	FailureWindowMinutes int `json:"failureWindowMinutes"`
+	// LockoutMinutes is how long a lockout lasts after the latest failed login
+	LockoutMinutes int `json:"lockoutMinutes"`
+	// DelayMilliseconds is how long a failed login response is delayed, doubling with each failure up to MaxDelayMilliseconds
+	DelayMilliseconds    int `json:"delayMilliseconds"`
+	MaxDelayMilliseconds int `json:"maxDelayMilliseconds"`
+}
+
+func (protection LoginProtection) maxFailedLogins() int {
+	if protection.MaxFailedLogins <= 0 {
+		return defaultMaxFailedLogins
+	}
+	return protection.MaxFailedLogins
+}
+
+func (protection LoginProtection) maxFailedLoginsPerIP() int {
+	if protection.MaxFailedLoginsPerIP <= 0 {
+		return defaultMaxFailedLoginsPerIP
+	}
+	return protection.MaxFailedLoginsPerIP
+}
+
+func (protection LoginProtection) failureWindow() time.Duration {
+	if protection.FailureWindowMinutes <= 0 {
+		return defaultFailureWindow
+	}
+	return time.Duration(protection.FailureWindowMinutes) * time.Minute
+}
+
+func (protection LoginProtection) lockout() time.Duration {
+	if protection.LockoutMinutes <= 0 {
+		return defaultLockout
+	}
+	return time.Duration(protection.LockoutMinutes) * time.Minute
+}
+
+// delay returns how long to wait before responding to a failed login, after 'failures' in a row
+func (protection LoginProtection) delay(failures int) time.Duration {
+	base, max := defaultFailedLoginDelay, defaultMaxFailedLoginDelay
+	if protection.DelayMilliseconds > 0 {
+		base = time.Duration(protection.DelayMilliseconds) * time.Millisecond
+	}
+	if protection.MaxDelayMilliseconds > 0 {
+		max = time.Duration(protection.MaxDelayMilliseconds) * time.Millisecond
+	}
+
+	if failures <= 0 {
+		return 0
+	}
+
+	delay := base
+	for i := 1; i < failures && delay < max; i++ {
+		delay *= 2
+	}
+
+	if delay > max {
+		return max
+	}
+	return delay
+}
+
+// lockedUntil returns when a lockout from failed logins for 'key' ends, or the zero time if there isn't one.
+// Locked out means the latest failure was within the lockout period, and there were max failures in the
+// window leading up to it, so lockouts longer than the window still last for all of the lockout period.
+func lockedUntil(failuresFor func(string, time.Time) (db.LoginFailures, error), key string, max int, protection LoginProtection, now time.Time) time.Time {
+	recent, err := failuresFor(key, now.Add(-protection.lockout()))
+	if err != nil {
+		panic(err)
+	}
+
+	if !recent.Latest.Valid {
+		return time.Time{}
+	}
+
+	failures, err := failuresFor(key, recent.Latest.Time.Add(-protection.failureWindow()))
+	if err != nil {
+		panic(err)
+	}
+
+	if failures.Count < max {
+		return time.Time{}
+	}
+	return recent.Latest.Time.Add(protection.lockout())
+}
+
+var dummyPasswordHashOnce sync.Once
+var dummyPasswordHashValue []byte
+
+// dummyPasswordHash returns a hash to compare passwords against when there's no User for an email address
+func dummyPasswordHash(workFactor int) []byte {
+	dummyPasswordHashOnce.Do(func() {
+		hash, err := bcrypt.GenerateFromPassword([]byte(newSecretToken()), workFactor)
+		if err != nil {
+			panic(err)
+		}
+		dummyPasswordHashValue = hash
+	})
+
+	return dummyPasswordHashValue
+}
+
+// loginEmail is how an email address is recorded for failed logins, so changing its case doesn't get around a lockout
+func loginEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// loginLockedUntil returns when the lockout on logging in to email from ipAddress ends, or the zero time if it isn't locked
+func loginLockedUntil(protection LoginProtection, email, ipAddress string, now time.Time) time.Time {
+	until := lockedUntil(db.LoginFailuresForEmail, email, protection.maxFailedLogins(), protection, now)
+	if ipUntil := lockedUntil(db.LoginFailuresForIPAddress, ipAddress, protection.maxFailedLoginsPerIP(), protection, now); ipUntil.After(until) {
+		until = ipUntil
+	}
+
+	return until
+}
+
+// recordLoginFailure saves a failed login, writing an audit entry when it locks out the email or IP address,
+// and returns how long to delay the response by
+func recordLoginFailure(protection LoginProtection, email, ipAddress string, user *db.User, now time.Time) time.Duration {
+	// failures older than this can't count towards a lockout any more
+	err := db.LoginFailuresDeleteBefore(now.Add(-protection.failureWindow() - protection.lockout()))
+	if err != nil {
+		panic(err)
+	}
+
+	loginFailure := db.LoginFailure{
+		Email:       email,
+		IPAddress:   ipAddress,
+		DateCreated: now,
+	}
+
+	err = loginFailure.Save()
+	if err != nil {
+		panic(err)
+	}
+
+	since := now.Add(-protection.failureWindow())
+
+	emailFailures, err := db.LoginFailuresForEmail(email, since)
+	if err != nil {
+		panic(err)
+	}
+
+	ipFailures, err := db.LoginFailuresForIPAddress(ipAddress, since)
+	if err != nil {
+		panic(err)
+	}
+
+	if emailFailures.Count == protection.maxFailedLogins() {
+		entry := db.AuditEntry{
+			Event:       db.AuditEventLoginLocked,
+			IPAddress:   ipAddress,
+			Detail:      fmt.Sprintf("%d failed logins for %s, locked for %s", emailFailures.Count, email, protection.lockout()),
+			DateCreated: now,
+		}
+		if user != nil {
+			entry.UserID = sql.NullString{String: user.ID, Valid: true}
+		}
+		saveAuditEntry(&entry)
+	}
+
+	if ipFailures.Count == protection.maxFailedLoginsPerIP() {
+		saveAuditEntry(&db.AuditEntry{
+			Event:       db.AuditEventLoginIPBlocked,
+			IPAddress:   ipAddress,
+			Detail:      fmt.Sprintf("%d failed logins from %s, blocked for %s", ipFailures.Count, ipAddress, protection.lockout()),
+			DateCreated: now,
+		})
+	}
+
+	return protection.delay(emailFailures.Count)
+}
+
+// saveAuditEntry saves an AuditEntry and logs it
+func saveAuditEntry(entry *db.AuditEntry) {
+	err := entry.Save()
+	if err != nil {
+		panic(err)
+	}
+
+	log.Printf("audit: %s: %s\n", entry.Event, entry.Detail)
+}
+
+// loginLockedOut responds to a login while it's locked out, with a Retry-After header for when it can be tried again
+func loginLockedOut(appContext *AppContext, res http.ResponseWriter, until, now time.Time) {
+	retryAfter := int(until.Sub(now)/time.Second) + 1
+
+	res.Header().Set("Retry-After", fmt.Sprintf("%d", retryAfter))
+	res.WriteHeader(http.StatusTooManyRequests)
+	appContext.Response = MessageResponse{
+		Message: fmt.Sprintf("Too many failed login attempts. Please try again in %d minute(s).", (retryAfter+59)/60),
+	}
+}
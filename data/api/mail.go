@@ -0,0 +1,110 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sironfoot/go-twitter-bot/data/db"
+	"github.com/sironfoot/go-twitter-bot/lib/mailer"
+)
+
+const (
+	verificationTokenLifetime  = 48 * time.Hour
+	passwordResetTokenLifetime = time.Hour
+	invitationLifetime         = 7 * 24 * time.Hour
+)
+
+// publicURL returns the full URL of a path on this server, for links in emails
+func (settings AppSettings) publicURL(path string, query url.Values) string {
+	base := settings.PublicURL
+	if base == "" {
+		base = "http://" + settings.ServerAddress
+	}
+
+	link := strings.TrimSuffix(base, "/") + path
+	if len(query) > 0 {
+		link += "?" + query.Encode()
+	}
+	return link
+}
+
+// newUserToken saves a new single use token for the User, replacing any they already have for 'purpose',
+// and returns the token to email to them
+func newUserToken(user *db.User, purpose string, lifetime time.Duration) (string, error) {
+	err := user.DeleteTokens(purpose)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC()
+	token := newSecretToken()
+
+	userToken := db.UserToken{
+		UserID:      user.ID,
+		Purpose:     purpose,
+		TokenHash:   hashSecretToken(token),
+		DateCreated: now,
+		ExpiresOn:   now.Add(lifetime),
+	}
+
+	return token, userToken.Save()
+}
+
+// sendVerificationEmail emails the User a link to verify their email address with
+func sendVerificationEmail(appContext *AppContext, user *db.User) error {
+	token, err := newUserToken(user, db.UserTokenPurposeVerifyEmail, verificationTokenLifetime)
+	if err != nil {
+		return err
+	}
+
+	link := appContext.Settings.AppSettings.publicURL("/account/verify", url.Values{"token": []string{token}})
+
+	return appContext.Mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease verify your email address for GoBot by following this link:\n\n%s\n\nThe link expires in %d hours. If you didn't sign up, you can ignore this email.\n",
+			user.Name, link, int(verificationTokenLifetime.Hours())),
+	})
+}
+
+// sendPasswordResetEmail emails the User a token to reset their password with, linking to
+// appSettings.resetPasswordUrl when there's a page for it
+func sendPasswordResetEmail(appContext *AppContext, user *db.User) error {
+	token, err := newUserToken(user, db.UserTokenPurposeResetPassword, passwordResetTokenLifetime)
+	if err != nil {
+		return err
+	}
+
+	instructions := fmt.Sprintf("Your password reset token is:\n\n%s\n\nSend it with your new password to POST: /account/reset-password", token)
+	if resetURL := appContext.Settings.AppSettings.ResetPasswordURL; resetURL != "" {
+		separator := "?"
+		if strings.Contains(resetURL, "?") {
+			separator = "&"
+		}
+		instructions = fmt.Sprintf("To choose a new password, follow this link:\n\n%s", resetURL+separator+url.Values{"token": []string{token}}.Encode())
+	}
+
+	return appContext.Mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password for your GoBot account. %s\n\nThis expires in %d minutes and can only be used once. If you didn't ask to reset your password, you can ignore this email.\n",
+			user.Name, instructions, int(passwordResetTokenLifetime.Minutes())),
+	})
+}
+
+// sendInvitationEmail emails whoever the OrganisationInvitation is for, asking them to accept it. The email holds the
+// invitation's token, which has to be sent along with accepting or declining it by a User logged in with the same email address.
+func sendInvitationEmail(appContext *AppContext, invitation *db.OrganisationInvitation, token string, organisation *db.Organisation, invitedBy *db.User) error {
+	query := url.Values{"token": []string{token}}
+	acceptURL := appContext.Settings.AppSettings.publicURL("/account/invitations/"+invitation.ID+"/accept", query)
+	declineURL := appContext.Settings.AppSettings.publicURL("/account/invitations/"+invitation.ID+"/decline", query)
+
+	return appContext.Mailer.Send(mailer.Message{
+		To:      invitation.Email,
+		Subject: fmt.Sprintf("You've been invited to join %s", organisation.Name),
+		Body: fmt.Sprintf("Hi,\n\n%s has invited you to join %s on GoBot with the %s role, sharing its Twitter accounts.\n\nSign up or log in with this email address, then accept the invitation with PUT:\n\n%s\n\nor decline it with PUT:\n\n%s\n\nThe invitation expires in %d days and can only be used once. If you don't want to join, you can ignore this email.\n",
+			invitedBy.Name, organisation.Name, invitation.Role, acceptURL, declineURL, int(invitationLifetime.Hours()/24)),
+	})
+}
@@ -8,19 +8,31 @@ import (
 
 	"github.com/sironfoot/go-twitter-bot/data/db"
 	"github.com/sironfoot/go-twitter-bot/data/models"
+	"github.com/sironfoot/go-twitter-bot/lib/authtoken"
+	"github.com/sironfoot/go-twitter-bot/lib/mailer"
 )
 
 // AppContext is an app specific context struct to hold per request variables
 type AppContext struct {
 	Settings Config
 	AuthUser *db.User
+	// Session is the AuthUser's current Session, nil when they authenticated with a legacy access token
+	Session *db.Session
+	// APIKey is set when a service User authenticated with an API key instead of logging in
+	APIKey   *db.APIKey
+	Mailer   mailer.Mailer
 	Response interface{}
 }
 
 // Config represents a configuration settings for the app
 type Config struct {
-	Database    Database    `json:"database"`
-	AppSettings AppSettings `json:"appSettings"`
+	Database    Database      `json:"database"`
+	AppSettings AppSettings   `json:"appSettings"`
+	Workers     Workers       `json:"workers"`
+	Webhooks    Webhooks      `json:"webhooks"`
+	Feeds       Feeds         `json:"feeds"`
+	Events      Events        `json:"events"`
+	Mail        mailer.Config `json:"mail"`
 }
 
 // Database represents database configuration settings for the app
@@ -31,9 +43,52 @@ type Database struct {
 
 // AppSettings represents general application settings for the app
 type AppSettings struct {
-	ServerAddress    string `json:"serverAddress"`
+	ServerAddress string `json:"serverAddress"`
+	// PublicURL is the server's address as seen by users, for links in emails
+	PublicURL string `json:"publicUrl"`
+	// ResetPasswordURL is an optional page for resetting passwords, linked to with ?token= in password reset emails
+	ResetPasswordURL string `json:"resetPasswordUrl"`
+	// EncryptionKey decrypts the access tokens issued before signed tokens, see AcceptLegacyTokens
 	EncryptionKey    string `json:"encryptionKey"`
 	BCryptWorkFactor int    `json:"bcryptWorkFactor"`
+	// TokenKeys sign and verify access tokens, new tokens are signed with the first key. To rotate keys,
+	// add the new key at the start and remove the old one once its tokens have expired.
+	TokenKeys            []authtoken.Key `json:"tokenKeys"`
+	TokenLifetimeMinutes int             `json:"tokenLifetimeMinutes"`
+	SessionLifetimeDays  int             `json:"sessionLifetimeDays"`
+	// AcceptLegacyTokens allows access tokens issued before signed tokens, until everyone has logged in again
+	AcceptLegacyTokens bool `json:"acceptLegacyTokens"`
+	// LoginProtection slows down and locks out repeated failed logins
+	LoginProtection LoginProtection `json:"loginProtection"`
+}
+
+// Workers represents background worker settings for the app, intervals of 0 disable a worker
+type Workers struct {
+	MentionsPollSeconds     int `json:"mentionsPollSeconds"`
+	MaxRepliesPerHour       int `json:"maxRepliesPerHour"`
+	FeedsPollSeconds        int `json:"feedsPollSeconds"`
+	PosterPollSeconds       int `json:"posterPollSeconds"`
+	EvergreenPollSeconds    int `json:"evergreenPollSeconds"`
+	EvergreenLookaheadHours int `json:"evergreenLookaheadHours"`
+	WebhooksPollSeconds     int `json:"webhooksPollSeconds"`
+}
+
+// Webhooks represents settings for delivering webhooks. AllowPrivateAddresses lets webhook URLs be loopback,
+// private and link-local addresses, which would let anyone who can manage webhooks reach the internal network.
+type Webhooks struct {
+	AllowPrivateAddresses bool `json:"allowPrivateAddresses"`
+}
+
+// Feeds represents settings for fetching feeds. AllowPrivateAddresses lets feed URLs be loopback, private
+// and link-local addresses, which would let anyone who can subscribe to feeds reach the internal network.
+type Feeds struct {
+	AllowPrivateAddresses bool `json:"allowPrivateAddresses"`
+}
+
+// Events represents settings for the live event stream
+type Events struct {
+	// PostgresNotify shares events between server instances with Postgres LISTEN/NOTIFY
+	PostgresNotify bool `json:"postgresNotify"`
 }
 
 // MessageResponse represents a standard JSON message response
@@ -58,14 +113,16 @@ type pagedResponse struct {
 }
 
 type createResponse struct {
-	Message string                   `json:"message"`
-	Errors  []models.ValidationError `json:"errors"`
-	ID      *string                  `json:"id"`
+	Message  string                   `json:"message"`
+	Errors   []models.ValidationError `json:"errors"`
+	Warnings []models.ValidationError `json:"warnings,omitempty"`
+	ID       *string                  `json:"id"`
 }
 
 type updateResponse struct {
-	Message string                   `json:"message"`
-	Errors  []models.ValidationError `json:"errors"`
+	Message  string                   `json:"message"`
+	Errors   []models.ValidationError `json:"errors"`
+	Warnings []models.ValidationError `json:"warnings,omitempty"`
 }
 
 func getPagingDefaults(orderBy string, asc bool, allowedOrderByColumns []string) PagingDefaults {
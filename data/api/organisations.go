@@ -0,0 +1,750 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"goji.io/pat"
+
+	"github.com/sironfoot/go-twitter-bot/data/db"
+	"github.com/sironfoot/go-twitter-bot/data/models"
+	"golang.org/x/net/context"
+)
+
+type organisation struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	DateCreated time.Time `json:"dateCreated"`
+	Role        string    `json:"role"`
+}
+
+type organisationMember struct {
+	UserID      string    `json:"userId"`
+	Name        string    `json:"name"`
+	Email       string    `json:"email"`
+	Role        string    `json:"role"`
+	DateCreated time.Time `json:"dateCreated"`
+}
+
+type organisationInvitation struct {
+	ID               string    `json:"id"`
+	OrganisationID   string    `json:"organisationId"`
+	OrganisationName string    `json:"organisationName,omitempty"`
+	Email            string    `json:"email"`
+	Role             string    `json:"role"`
+	DateCreated      time.Time `json:"dateCreated"`
+	ExpiresOn        time.Time `json:"expiresOn"`
+}
+
+func newOrganisation(user *db.User, organisationDB *db.Organisation) organisation {
+	return organisation{
+		ID:          organisationDB.ID,
+		Name:        organisationDB.Name,
+		DateCreated: organisationDB.DateCreated,
+		Role:        organisationRole(user, organisationDB),
+	}
+}
+
+func newOrganisationInvitation(invitationDB *db.OrganisationInvitation) organisationInvitation {
+	return organisationInvitation{
+		ID:             invitationDB.ID,
+		OrganisationID: invitationDB.OrganisationID,
+		Email:          invitationDB.Email,
+		Role:           invitationDB.Role,
+		DateCreated:    invitationDB.DateCreated,
+		ExpiresOn:      invitationDB.ExpiresOn,
+	}
+}
+
+// organisationFromParam returns the Organisation in the URL, responding with 404 Not Found if there isn't one
+func organisationFromParam(ctx context.Context, appContext *AppContext, res http.ResponseWriter) (db.Organisation, bool) {
+	organisationID := pat.Param(ctx, "organisationID")
+
+	organisationDB, err := db.OrganisationFromID(organisationID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("Organisation not found on ID: %s", organisationID),
+		}
+		return organisationDB, false
+	} else if err != nil {
+		panic(err)
+	}
+
+	return organisationDB, true
+}
+
+// isLastOwner determines if the OrganisationMember is the only owner of the Organisation,
+// who can't leave or stop being an owner as there'd be nobody left to manage it
+func isLastOwner(organisationDB *db.Organisation, member *db.OrganisationMember) bool {
+	if member.Role != db.TwitterAccountRoleOwner {
+		return false
+	}
+
+	owners, err := organisationDB.CountOwners()
+	if err != nil {
+		panic(err)
+	}
+
+	return owners <= 1
+}
+
+// OrganisationsAll = GET: /organisations
+// Lists the Organisations the User is a member of, admins see all of them
+func OrganisationsAll(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+
+	memberUserID := ""
+	if !appContext.AuthUser.IsAdmin {
+		memberUserID = appContext.AuthUser.ID
+	}
+
+	organisationsDB, err := db.OrganisationsAll(memberUserID)
+	if err != nil {
+		panic(err)
+	}
+
+	model := struct {
+		MessageResponse
+		Organisations []organisation `json:"organisations"`
+	}{}
+
+	model.Message = ok
+	model.Organisations = make([]organisation, 0)
+
+	for i := range organisationsDB {
+		model.Organisations = append(model.Organisations, newOrganisation(appContext.AuthUser, &organisationsDB[i]))
+	}
+
+	appContext.Response = model
+}
+
+// OrganisationCreate = POST: /organisations
+// Creates an Organisation with the User as its owner
+func OrganisationCreate(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+
+	var createOrganisation models.Organisation
+
+	defer req.Body.Close()
+	err := json.NewDecoder(io.LimitReader(req.Body, maxRequestLength)).Decode(&createOrganisation)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "JSON request body was not in a valid format",
+		}
+		return
+	}
+
+	createOrganisation.Sanitise()
+	validationErrors, err := createOrganisation.Validate()
+	if err != nil {
+		panic(err)
+	}
+
+	model := createResponse{}
+
+	if len(validationErrors) > 0 {
+		res.WriteHeader(http.StatusBadRequest)
+		model.Message = "Organisation model is invalid."
+		model.Errors = validationErrors
+		appContext.Response = model
+		return
+	}
+
+	organisationDB := db.Organisation{
+		Name:        createOrganisation.Name,
+		DateCreated: time.Now().UTC(),
+	}
+
+	err = db.OrganisationCreate(&organisationDB, appContext.AuthUser)
+	if err != nil {
+		panic(err)
+	}
+
+	model.Message = ok
+	model.ID = &organisationDB.ID
+	res.WriteHeader(http.StatusCreated)
+
+	appContext.Response = model
+}
+
+// OrganisationGet = GET: /organisations/:organisationID
+// Returns the Organisation with its members, for any member
+func OrganisationGet(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+
+	organisationDB, found := organisationFromParam(ctx, appContext, res)
+	if !found || !authorizeOrganisation(appContext, res, &organisationDB, permissionView) {
+		return
+	}
+
+	membersDB, err := organisationDB.GetMembers()
+	if err != nil {
+		panic(err)
+	}
+
+	model := struct {
+		MessageResponse
+		Organisation organisation         `json:"organisation"`
+		Members      []organisationMember `json:"members"`
+	}{}
+
+	model.Message = ok
+	model.Organisation = newOrganisation(appContext.AuthUser, &organisationDB)
+	model.Members = make([]organisationMember, 0)
+
+	for _, memberDB := range membersDB {
+		model.Members = append(model.Members, organisationMember{
+			UserID:      memberDB.UserID,
+			Name:        memberDB.UserName,
+			Email:       memberDB.UserEmail,
+			Role:        memberDB.Role,
+			DateCreated: memberDB.DateCreated,
+		})
+	}
+
+	appContext.Response = model
+}
+
+// OrganisationUpdate = PUT: /organisations/:organisationID
+// Renames the Organisation, for owners
+func OrganisationUpdate(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+
+	organisationDB, found := organisationFromParam(ctx, appContext, res)
+	if !found || !authorizeOrganisation(appContext, res, &organisationDB, permissionManage) {
+		return
+	}
+
+	var updateOrganisation models.Organisation
+
+	defer req.Body.Close()
+	err := json.NewDecoder(io.LimitReader(req.Body, maxRequestLength)).Decode(&updateOrganisation)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "JSON request body was not in a valid format",
+		}
+		return
+	}
+
+	updateOrganisation.Sanitise()
+	validationErrors, err := updateOrganisation.Validate()
+	if err != nil {
+		panic(err)
+	}
+
+	if len(validationErrors) > 0 {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = updateResponse{
+			Message: "Organisation model is invalid.",
+			Errors:  validationErrors,
+		}
+		return
+	}
+
+	organisationDB.Name = updateOrganisation.Name
+
+	err = organisationDB.Save()
+	if err != nil {
+		panic(err)
+	}
+
+	appContext.Response = MessageResponse{
+		Message: ok,
+	}
+}
+
+// OrganisationDelete = DELETE: /organisations/:organisationID
+// Deletes the Organisation, for owners. Its TwitterAccounts become personal TwitterAccounts of the Users that added them.
+func OrganisationDelete(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+
+	organisationDB, found := organisationFromParam(ctx, appContext, res)
+	if !found || !authorizeOrganisation(appContext, res, &organisationDB, permissionManage) {
+		return
+	}
+
+	err := organisationDB.Delete()
+	if err != nil {
+		panic(err)
+	}
+
+	appContext.Response = MessageResponse{
+		Message: ok,
+	}
+}
+
+// OrganisationMemberUpdate = PUT: /organisations/:organisationID/members/:userID
+// Changes a member's role, for owners. New members join by accepting an invitation.
+func OrganisationMemberUpdate(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	userID := pat.Param(ctx, "userID")
+
+	organisationDB, found := organisationFromParam(ctx, appContext, res)
+	if !found || !authorizeOrganisation(appContext, res, &organisationDB, permissionManage) {
+		return
+	}
+
+	member, err := organisationDB.GetMember(userID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("No member of the Organisation on User ID: %s", userID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	var updateMember models.TwitterAccountRole
+
+	defer req.Body.Close()
+	err = json.NewDecoder(io.LimitReader(req.Body, maxRequestLength)).Decode(&updateMember)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "JSON request body was not in a valid format",
+		}
+		return
+	}
+
+	updateMember.Sanitise()
+	validationErrors, err := updateMember.Validate()
+	if err != nil {
+		panic(err)
+	}
+
+	if len(validationErrors) > 0 {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = updateResponse{
+			Message: "OrganisationMember model is invalid.",
+			Errors:  validationErrors,
+		}
+		return
+	}
+
+	if updateMember.Role != db.TwitterAccountRoleOwner && isLastOwner(&organisationDB, &member) {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "An Organisation must have at least one owner.",
+		}
+		return
+	}
+
+	member.Role = updateMember.Role
+
+	err = member.Save()
+	if err != nil {
+		panic(err)
+	}
+
+	appContext.Response = MessageResponse{
+		Message: ok,
+	}
+}
+
+// OrganisationMemberDelete = DELETE: /organisations/:organisationID/members/:userID
+// Removes a member from the Organisation, for owners, or for members leaving it themselves
+func OrganisationMemberDelete(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	userID := pat.Param(ctx, "userID")
+
+	organisationDB, found := organisationFromParam(ctx, appContext, res)
+	if !found {
+		return
+	}
+
+	// members can leave, only owners can remove other members
+	required := permissionManage
+	if userID == appContext.AuthUser.ID {
+		required = permissionView
+	}
+
+	if !authorizeOrganisation(appContext, res, &organisationDB, required) {
+		return
+	}
+
+	member, err := organisationDB.GetMember(userID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("No member of the Organisation on User ID: %s", userID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if isLastOwner(&organisationDB, &member) {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "An Organisation must have at least one owner.",
+		}
+		return
+	}
+
+	err = member.Delete()
+	if err != nil {
+		panic(err)
+	}
+
+	appContext.Response = MessageResponse{
+		Message: ok,
+	}
+}
+
+// OrganisationInvitationsAll = GET: /organisations/:organisationID/invitations
+// Lists the Organisation's invitations that haven't been accepted or declined, for owners
+func OrganisationInvitationsAll(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+
+	organisationDB, found := organisationFromParam(ctx, appContext, res)
+	if !found || !authorizeOrganisation(appContext, res, &organisationDB, permissionManage) {
+		return
+	}
+
+	invitationsDB, err := organisationDB.GetInvitations()
+	if err != nil {
+		panic(err)
+	}
+
+	model := struct {
+		MessageResponse
+		Invitations []organisationInvitation `json:"invitations"`
+	}{}
+
+	model.Message = ok
+	model.Invitations = make([]organisationInvitation, 0)
+
+	for i := range invitationsDB {
+		model.Invitations = append(model.Invitations, newOrganisationInvitation(&invitationsDB[i]))
+	}
+
+	appContext.Response = model
+}
+
+// OrganisationInvitationCreate = POST: /organisations/:organisationID/invitations
+// Invites someone to join the Organisation by email address, for owners
+func OrganisationInvitationCreate(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+
+	organisationDB, found := organisationFromParam(ctx, appContext, res)
+	if !found || !authorizeOrganisation(appContext, res, &organisationDB, permissionManage) {
+		return
+	}
+
+	var createInvitation models.OrganisationInvitation
+
+	defer req.Body.Close()
+	err := json.NewDecoder(io.LimitReader(req.Body, maxRequestLength)).Decode(&createInvitation)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "JSON request body was not in a valid format",
+		}
+		return
+	}
+
+	createInvitation.Sanitise()
+	validationErrors, err := createInvitation.Validate()
+	if err != nil {
+		panic(err)
+	}
+
+	model := createResponse{}
+
+	if len(validationErrors) > 0 {
+		res.WriteHeader(http.StatusBadRequest)
+		model.Message = "OrganisationInvitation model is invalid."
+		model.Errors = validationErrors
+		appContext.Response = model
+		return
+	}
+
+	now := time.Now().UTC()
+	token := newSecretToken()
+
+	invitation := db.OrganisationInvitation{
+		OrganisationID: organisationDB.ID,
+		Email:          createInvitation.Email,
+		Role:           createInvitation.Role,
+		TokenHash:      hashSecretToken(token),
+		InvitedBy:      sql.NullString{String: appContext.AuthUser.ID, Valid: true},
+		DateCreated:    now,
+		ExpiresOn:      now.Add(invitationLifetime),
+	}
+
+	err = invitation.Save()
+	if err != nil {
+		panic(err)
+	}
+
+	err = sendInvitationEmail(appContext, &invitation, token, &organisationDB, appContext.AuthUser)
+	if err != nil {
+		panic(err)
+	}
+
+	model.Message = ok
+	model.ID = &invitation.ID
+	res.WriteHeader(http.StatusCreated)
+
+	appContext.Response = model
+}
+
+// OrganisationInvitationDelete = DELETE: /organisations/:organisationID/invitations/:invitationID
+// Withdraws an invitation to join the Organisation, for owners
+func OrganisationInvitationDelete(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	invitationID := pat.Param(ctx, "invitationID")
+
+	organisationDB, found := organisationFromParam(ctx, appContext, res)
+	if !found || !authorizeOrganisation(appContext, res, &organisationDB, permissionManage) {
+		return
+	}
+
+	invitation, err := db.OrganisationInvitationFromID(invitationID)
+	if err == db.ErrEntityNotFound || (err == nil && invitation.OrganisationID != organisationDB.ID) {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("OrganisationInvitation not found on ID: %s", invitationID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	err = invitation.Delete()
+	if err != nil {
+		panic(err)
+	}
+
+	appContext.Response = MessageResponse{
+		Message: ok,
+	}
+}
+
+// AccountInvitationsAll = GET: /account/invitations
+// Lists the invitations to join Organisations sent to the User's email address, that can still be accepted
+func AccountInvitationsAll(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+
+	invitationsDB, err := appContext.AuthUser.GetInvitations()
+	if err != nil {
+		panic(err)
+	}
+
+	model := struct {
+		MessageResponse
+		Invitations []organisationInvitation `json:"invitations"`
+	}{}
+
+	model.Message = ok
+	model.Invitations = make([]organisationInvitation, 0)
+
+	for i := range invitationsDB {
+		invitation := newOrganisationInvitation(&invitationsDB[i].OrganisationInvitation)
+		invitation.OrganisationName = invitationsDB[i].OrganisationName
+		model.Invitations = append(model.Invitations, invitation)
+	}
+
+	appContext.Response = model
+}
+
+// accountInvitationFromParam returns the invitation in the URL if it was sent to the User's verified email address with the
+// token in the URL, and can still be responded to. Otherwise it responds with 403 Forbidden until the User is verified, 404 Not
+// Found, or 400 Bad Request once it's been responded to or expired.
+func accountInvitationFromParam(ctx context.Context, appContext *AppContext, res http.ResponseWriter, req *http.Request) (db.OrganisationInvitation, bool) {
+	invitationID := pat.Param(ctx, "invitationID")
+
+	if !appContext.AuthUser.IsVerified {
+		res.WriteHeader(http.StatusForbidden)
+		appContext.Response = MessageResponse{
+			Message: "Please verify your email address before responding to invitations.",
+		}
+		return db.OrganisationInvitation{}, false
+	}
+
+	// the token from the invitation email proves it reached the User, not just that their email address matches
+	token := req.URL.Query().Get("token")
+
+	invitation, err := db.OrganisationInvitationFromID(invitationID)
+	if err == db.ErrEntityNotFound || (err == nil && (!strings.EqualFold(invitation.Email, appContext.AuthUser.Email) || hashSecretToken(token) != invitation.TokenHash)) {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("OrganisationInvitation not found on ID: %s", invitationID),
+		}
+		return invitation, false
+	} else if err != nil {
+		panic(err)
+	}
+
+	if !invitation.IsPending(time.Now().UTC()) {
+		invitationNotPending(appContext, res)
+		return invitation, false
+	}
+
+	return invitation, true
+}
+
+func invitationNotPending(appContext *AppContext, res http.ResponseWriter) {
+	res.WriteHeader(http.StatusBadRequest)
+	appContext.Response = MessageResponse{
+		Message: "OrganisationInvitation has expired or has already been accepted or declined.",
+	}
+}
+
+// AccountInvitationAccept = PUT: /account/invitations/:invitationID/accept?token=
+// Joins the Organisation with the invitation's role, or raises the User's role to it if they're already a member with less access
+func AccountInvitationAccept(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+
+	invitation, found := accountInvitationFromParam(ctx, appContext, res, req)
+	if !found {
+		return
+	}
+
+	err := invitation.Accept(appContext.AuthUser)
+	if err == db.ErrInvitationResponded {
+		invitationNotPending(appContext, res)
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	appContext.Response = MessageResponse{
+		Message: ok,
+	}
+}
+
+// AccountInvitationDecline = PUT: /account/invitations/:invitationID/decline?token=
+// Declines an invitation to join an Organisation
+func AccountInvitationDecline(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+
+	invitation, found := accountInvitationFromParam(ctx, appContext, res, req)
+	if !found {
+		return
+	}
+
+	err := invitation.Decline()
+	if err == db.ErrInvitationResponded {
+		invitationNotPending(appContext, res)
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	appContext.Response = MessageResponse{
+		Message: ok,
+	}
+}
+
+// TwitterAccountOrganisationUpdate = PUT: /twitterAccounts/:twitterAccountID/organisation
+// Moves the TwitterAccount to an Organisation, which needs the owner role on both, a null organisationId makes
+// it a personal TwitterAccount of the User that added it again
+func TwitterAccountOrganisationUpdate(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	twitterAccountID := pat.Param(ctx, "twitterAccountID")
+
+	account, err := db.TwitterAccountFromID(twitterAccountID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("TwitterAccount not found on ID: %s", twitterAccountID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if !authorize(appContext, res, &account.TwitterAccount, permissionManage) {
+		return
+	}
+
+	var updateOrganisation models.TwitterAccountOrganisation
+
+	defer req.Body.Close()
+	err = json.NewDecoder(io.LimitReader(req.Body, maxRequestLength)).Decode(&updateOrganisation)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "JSON request body was not in a valid format",
+		}
+		return
+	}
+
+	updateOrganisation.Sanitise()
+	validationErrors, err := updateOrganisation.Validate()
+	if err != nil {
+		panic(err)
+	}
+
+	if len(validationErrors) > 0 {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = updateResponse{
+			Message: "TwitterAccountOrganisation model is invalid.",
+			Errors:  validationErrors,
+		}
+		return
+	}
+
+	organisationID := sql.NullString{}
+
+	if updateOrganisation.OrganisationID != nil {
+		organisationDB, err := db.OrganisationFromID(*updateOrganisation.OrganisationID)
+		if err == db.ErrEntityNotFound {
+			res.WriteHeader(http.StatusNotFound)
+			appContext.Response = MessageResponse{
+				Message: fmt.Sprintf("Organisation not found on ID: %s", *updateOrganisation.OrganisationID),
+			}
+			return
+		} else if err != nil {
+			panic(err)
+		}
+
+		if !authorizeOrganisation(appContext, res, &organisationDB, permissionManage) {
+			return
+		}
+
+		organisationID = sql.NullString{String: organisationDB.ID, Valid: true}
+	} else if !account.UserID.Valid {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "The User that added this TwitterAccount has been deleted, so it can't be made a personal TwitterAccount.",
+		}
+		return
+	}
+
+	if updateOrganisation.OrganisationID == nil && account.OrganisationID.Valid {
+		// the User that added the TwitterAccount may have left the Organisation since, so only hand it back to an owner
+		organisation := db.Organisation{ID: account.OrganisationID.String}
+		member, err := organisation.GetMember(account.UserID.String)
+		if err != nil && err != db.ErrEntityNotFound {
+			panic(err)
+		}
+
+		if err == db.ErrEntityNotFound || member.Role != db.TwitterAccountRoleOwner {
+			res.WriteHeader(http.StatusBadRequest)
+			appContext.Response = MessageResponse{
+				Message: "The User that added this TwitterAccount is no longer an owner of its Organisation, so it can't be made their personal TwitterAccount.",
+			}
+			return
+		}
+	}
+
+	err = account.SetOrganisation(organisationID)
+	if err != nil {
+		panic(err)
+	}
+
+	appContext.Response = MessageResponse{
+		Message: ok,
+	}
+}
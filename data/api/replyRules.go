@@ -0,0 +1,262 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"goji.io/pat"
+
+	"golang.org/x/net/context"
+
+	"github.com/sironfoot/go-twitter-bot/data/db"
+	"github.com/sironfoot/go-twitter-bot/data/models"
+)
+
+type replyRule struct {
+	ID              string    `json:"id"`
+	MatchType       string    `json:"matchType"`
+	Pattern         string    `json:"pattern"`
+	ReplyTemplate   string    `json:"replyTemplate"`
+	CooldownMinutes int       `json:"cooldownMinutes"`
+	IsEnabled       bool      `json:"isEnabled"`
+	DateCreated     time.Time `json:"dateCreated"`
+}
+
+// TwitterAccountReplyRulesAll = GET: /twitterAccounts/:twitterAccountID/replyRules
+func TwitterAccountReplyRulesAll(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	twitterAccountID := pat.Param(ctx, "twitterAccountID")
+
+	account, err := db.TwitterAccountFromID(twitterAccountID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("TwitterAccount not found on ID: %s", twitterAccountID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if !authorize(appContext, res, &account.TwitterAccount, permissionView) {
+		return
+	}
+
+	rulesDB, err := account.GetReplyRules()
+	if err != nil {
+		panic(err)
+	}
+
+	model := struct {
+		MessageResponse
+		ReplyRules []replyRule `json:"replyRules"`
+	}{}
+
+	model.Message = ok
+	model.ReplyRules = make([]replyRule, 0)
+
+	for _, ruleDB := range rulesDB {
+		model.ReplyRules = append(model.ReplyRules, replyRule{
+			ID:              ruleDB.ID,
+			MatchType:       ruleDB.MatchType,
+			Pattern:         ruleDB.Pattern,
+			ReplyTemplate:   ruleDB.ReplyTemplate,
+			CooldownMinutes: ruleDB.CooldownMinutes,
+			IsEnabled:       ruleDB.IsEnabled,
+			DateCreated:     ruleDB.DateCreated,
+		})
+	}
+
+	appContext.Response = model
+}
+
+// TwitterAccountReplyRuleCreate = POST: /twitterAccounts/:twitterAccountID/replyRules
+func TwitterAccountReplyRuleCreate(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	twitterAccountID := pat.Param(ctx, "twitterAccountID")
+
+	account, err := db.TwitterAccountFromID(twitterAccountID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("TwitterAccount not found on ID: %s", twitterAccountID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if !authorize(appContext, res, &account.TwitterAccount, permissionEdit) {
+		return
+	}
+
+	var newRule models.ReplyRule
+
+	defer req.Body.Close()
+	err = json.NewDecoder(io.LimitReader(req.Body, maxRequestLength)).Decode(&newRule)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "JSON request body was not in a valid format",
+		}
+		return
+	}
+
+	newRule.Sanitise()
+	validationErrors, err := newRule.ValidateCreate()
+	if err != nil {
+		panic(err)
+	}
+
+	model := createResponse{}
+
+	if len(validationErrors) > 0 {
+		model.Message = "ReplyRule model is invalid."
+		model.Errors = validationErrors
+		appContext.Response = model
+
+		res.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	rule := &db.ReplyRule{
+		AccountID:       account.ID,
+		MatchType:       newRule.MatchType,
+		Pattern:         newRule.Pattern,
+		ReplyTemplate:   newRule.ReplyTemplate,
+		CooldownMinutes: newRule.CooldownMinutes,
+		IsEnabled:       newRule.IsEnabled,
+		DateCreated:     time.Now().UTC(),
+	}
+
+	err = rule.Save()
+	if err != nil {
+		panic(err)
+	}
+
+	model.Message = ok
+	model.ID = &rule.ID
+	res.WriteHeader(http.StatusCreated)
+
+	appContext.Response = model
+}
+
+// TwitterAccountReplyRuleUpdate = PUT: /twitterAccounts/:twitterAccountID/replyRules/:replyRuleID
+func TwitterAccountReplyRuleUpdate(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	twitterAccountID := pat.Param(ctx, "twitterAccountID")
+
+	account, err := db.TwitterAccountFromID(twitterAccountID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("TwitterAccount not found on ID: %s", twitterAccountID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if !authorize(appContext, res, &account.TwitterAccount, permissionEdit) {
+		return
+	}
+
+	replyRuleID := pat.Param(ctx, "replyRuleID")
+	rule, err := account.GetReplyRuleFromID(replyRuleID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("ReplyRule not found on ID: %s", replyRuleID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	var updateRule models.ReplyRule
+
+	defer req.Body.Close()
+	err = json.NewDecoder(io.LimitReader(req.Body, maxRequestLength)).Decode(&updateRule)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "JSON request body was not in a valid format",
+		}
+		return
+	}
+
+	updateRule.Sanitise()
+	validationErrors, err := updateRule.ValidateUpdate(replyRuleID)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(validationErrors) > 0 {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = updateResponse{
+			Message: "ReplyRule model is invalid.",
+			Errors:  validationErrors,
+		}
+		return
+	}
+
+	rule.MatchType = updateRule.MatchType
+	rule.Pattern = updateRule.Pattern
+	rule.ReplyTemplate = updateRule.ReplyTemplate
+	rule.CooldownMinutes = updateRule.CooldownMinutes
+	rule.IsEnabled = updateRule.IsEnabled
+
+	err = rule.Save()
+	if err != nil {
+		panic(err)
+	}
+
+	appContext.Response = MessageResponse{
+		Message: ok,
+	}
+}
+
+// TwitterAccountReplyRuleDelete = DELETE: /twitterAccounts/:twitterAccountID/replyRules/:replyRuleID
+func TwitterAccountReplyRuleDelete(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	twitterAccountID := pat.Param(ctx, "twitterAccountID")
+
+	account, err := db.TwitterAccountFromID(twitterAccountID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("TwitterAccount not found on ID: %s", twitterAccountID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if !authorize(appContext, res, &account.TwitterAccount, permissionEdit) {
+		return
+	}
+
+	replyRuleID := pat.Param(ctx, "replyRuleID")
+	rule, err := account.GetReplyRuleFromID(replyRuleID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("ReplyRule not found on ID: %s", replyRuleID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	err = rule.Delete()
+	if err != nil {
+		panic(err)
+	}
+
+	appContext.Response = MessageResponse{
+		Message: ok,
+	}
+}
@@ -0,0 +1,241 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"goji.io/pat"
+
+	"github.com/sironfoot/go-twitter-bot/data/db"
+	"github.com/sironfoot/go-twitter-bot/data/models"
+	"golang.org/x/net/context"
+)
+
+const ownersOnlyMessage = "Only the User a TwitterAccount belongs to and admins can grant or revoke the owner role."
+
+// canManageOwners determines if the User can grant and revoke the owner role on the TwitterAccount, which is kept
+// to its own User and admins so that an owner by grant can't hand out ownership or take it from other owners.
+// Granted roles don't apply to an Organisation's TwitterAccount, so any of its owners can revoke those left over.
+func canManageOwners(user *db.User, account *db.TwitterAccount) bool {
+	if account.OrganisationID.Valid {
+		return true
+	}
+	return user.IsAdmin || (account.UserID.Valid && user.ID == account.UserID.String)
+}
+
+type twitterAccountRole struct {
+	UserID      string    `json:"userId"`
+	Name        string    `json:"name"`
+	Email       string    `json:"email"`
+	Role        string    `json:"role"`
+	DateCreated time.Time `json:"dateCreated"`
+}
+
+// TwitterAccountRolesAll = GET: /twitterAccounts/:twitterAccountID/roles
+// Lists who has been granted access to the TwitterAccount, its own User and admins are owners without a grant
+func TwitterAccountRolesAll(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	twitterAccountID := pat.Param(ctx, "twitterAccountID")
+
+	account, err := db.TwitterAccountFromID(twitterAccountID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("TwitterAccount not found on ID: %s", twitterAccountID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if !authorize(appContext, res, &account.TwitterAccount, permissionManage) {
+		return
+	}
+
+	rolesDB, err := account.GetRoles()
+	if err != nil {
+		panic(err)
+	}
+
+	model := struct {
+		MessageResponse
+		Roles []twitterAccountRole `json:"roles"`
+	}{}
+
+	model.Message = ok
+	model.Roles = make([]twitterAccountRole, 0)
+
+	for _, roleDB := range rolesDB {
+		model.Roles = append(model.Roles, twitterAccountRole{
+			UserID:      roleDB.UserID,
+			Name:        roleDB.UserName,
+			Email:       roleDB.UserEmail,
+			Role:        roleDB.Role,
+			DateCreated: roleDB.DateCreated,
+		})
+	}
+
+	appContext.Response = model
+}
+
+// TwitterAccountRoleUpdate = PUT: /twitterAccounts/:twitterAccountID/roles/:userID
+// Grants a User a role on the TwitterAccount, replacing any role they already have
+func TwitterAccountRoleUpdate(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	twitterAccountID := pat.Param(ctx, "twitterAccountID")
+	userID := pat.Param(ctx, "userID")
+
+	account, err := db.TwitterAccountFromID(twitterAccountID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("TwitterAccount not found on ID: %s", twitterAccountID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if !authorize(appContext, res, &account.TwitterAccount, permissionManage) {
+		return
+	}
+
+	userDB, err := db.UserFromID(userID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("User not found on ID: %s", userID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if account.OrganisationID.Valid {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "Roles on an Organisation's TwitterAccount come from the Organisation, change the User's role there instead.",
+		}
+		return
+	}
+
+	if userDB.ID == account.UserID.String {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "The User a TwitterAccount belongs to is always an owner.",
+		}
+		return
+	}
+
+	var updateRole models.TwitterAccountRole
+
+	defer req.Body.Close()
+	err = json.NewDecoder(io.LimitReader(req.Body, maxRequestLength)).Decode(&updateRole)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "JSON request body was not in a valid format",
+		}
+		return
+	}
+
+	updateRole.Sanitise()
+	validationErrors, err := updateRole.Validate()
+	if err != nil {
+		panic(err)
+	}
+
+	if len(validationErrors) > 0 {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = updateResponse{
+			Message: "TwitterAccountRole model is invalid.",
+			Errors:  validationErrors,
+		}
+		return
+	}
+
+	role, err := account.GetRoleForUser(userDB.ID)
+	if err == db.ErrEntityNotFound {
+		role = db.TwitterAccountRole{
+			TwitterAccountID: account.ID,
+			UserID:           userDB.ID,
+			DateCreated:      time.Now().UTC(),
+		}
+	} else if err != nil {
+		panic(err)
+	}
+
+	if (updateRole.Role == db.TwitterAccountRoleOwner || role.Role == db.TwitterAccountRoleOwner) &&
+		!canManageOwners(appContext.AuthUser, &account.TwitterAccount) {
+		res.WriteHeader(http.StatusForbidden)
+		appContext.Response = MessageResponse{
+			Message: ownersOnlyMessage,
+		}
+		return
+	}
+
+	role.Role = updateRole.Role
+
+	err = role.Save()
+	if err != nil {
+		panic(err)
+	}
+
+	appContext.Response = MessageResponse{
+		Message: ok,
+	}
+}
+
+// TwitterAccountRoleDelete = DELETE: /twitterAccounts/:twitterAccountID/roles/:userID
+// Revokes a User's access to the TwitterAccount
+func TwitterAccountRoleDelete(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	twitterAccountID := pat.Param(ctx, "twitterAccountID")
+	userID := pat.Param(ctx, "userID")
+
+	account, err := db.TwitterAccountFromID(twitterAccountID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("TwitterAccount not found on ID: %s", twitterAccountID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if !authorize(appContext, res, &account.TwitterAccount, permissionManage) {
+		return
+	}
+
+	role, err := account.GetRoleForUser(userID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("No role granted to User on ID: %s", userID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if role.Role == db.TwitterAccountRoleOwner && !canManageOwners(appContext.AuthUser, &account.TwitterAccount) {
+		res.WriteHeader(http.StatusForbidden)
+		appContext.Response = MessageResponse{
+			Message: ownersOnlyMessage,
+		}
+		return
+	}
+
+	err = role.Delete()
+	if err != nil {
+		panic(err)
+	}
+
+	appContext.Response = MessageResponse{
+		Message: ok,
+	}
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"goji.io/pat"
+
+	"github.com/sironfoot/go-twitter-bot/data/db"
+	"golang.org/x/net/context"
+)
+
+const maxUserAgentLength = 512
+
+type session struct {
+	ID          string    `json:"id"`
+	UserAgent   string    `json:"userAgent"`
+	IPAddress   string    `json:"ipAddress"`
+	DateCreated time.Time `json:"dateCreated"`
+	LastSeen    time.Time `json:"lastSeen"`
+	ExpiresOn   time.Time `json:"expiresOn"`
+	IsCurrent   bool      `json:"isCurrent"`
+}
+
+type sessionsResponse struct {
+	Message  string    `json:"message"`
+	Sessions []session `json:"sessions"`
+}
+
+type sessionsDeletedResponse struct {
+	Message string `json:"message"`
+	Deleted int    `json:"deleted"`
+}
+
+// remoteIP returns the IP address a request came from
+func remoteIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
+func truncate(value string, length int) string {
+	if len(value) > length {
+		return value[:length]
+	}
+	return value
+}
+
+// newSessionsResponse lists the Sessions, marking the request's current Session
+func newSessionsResponse(appContext *AppContext, sessionsDB []db.Session) sessionsResponse {
+	model := sessionsResponse{
+		Message:  ok,
+		Sessions: make([]session, 0),
+	}
+
+	for _, sessionDB := range sessionsDB {
+		model.Sessions = append(model.Sessions, session{
+			ID:          sessionDB.ID,
+			UserAgent:   sessionDB.UserAgent,
+			IPAddress:   sessionDB.IPAddress,
+			DateCreated: sessionDB.DateCreated,
+			LastSeen:    sessionDB.LastSeen,
+			ExpiresOn:   sessionDB.ExpiresOn,
+			IsCurrent:   appContext.Session != nil && appContext.Session.ID == sessionDB.ID,
+		})
+	}
+
+	return model
+}
+
+// revokeSessions deletes all of the User's Sessions apart from 'exceptID', and their legacy access token
+func revokeSessions(user *db.User, exceptID string) int {
+	deleted, err := user.DeleteSessions(exceptID)
+	if err != nil {
+		panic(err)
+	}
+
+	if user.AuthToken.Valid {
+		user.AuthToken.Valid = false
+		user.AuthToken.String = ""
+
+		err = user.Save()
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	return deleted
+}
+
+// AccountSessionsAll = GET: /account/sessions
+func AccountSessionsAll(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+
+	sessionsDB, err := appContext.AuthUser.GetSessions()
+	if err != nil {
+		panic(err)
+	}
+
+	appContext.Response = newSessionsResponse(appContext, sessionsDB)
+}
+
+// AccountSessionDelete = DELETE: /account/sessions/:sessionID
+func AccountSessionDelete(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	sessionID := pat.Param(ctx, "sessionID")
+
+	sessionDB, err := appContext.AuthUser.GetSessionFromID(sessionID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("Session not found on ID: %s", sessionID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	err = sessionDB.Delete()
+	if err != nil {
+		panic(err)
+	}
+
+	appContext.Response = MessageResponse{
+		Message: ok,
+	}
+}
+
+// AccountSessionsDelete = DELETE: /account/sessions
+// Logs out everywhere, or everywhere else with ?exceptCurrent=true
+func AccountSessionsDelete(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+
+	exceptID := ""
+	if req.URL.Query().Get("exceptCurrent") == "true" && appContext.Session != nil {
+		exceptID = appContext.Session.ID
+	}
+
+	appContext.Response = sessionsDeletedResponse{
+		Message: ok,
+		Deleted: revokeSessions(appContext.AuthUser, exceptID),
+	}
+}
+
+// UserSessionsAll = GET: /users/:userID/sessions
+func UserSessionsAll(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	userID := pat.Param(ctx, "userID")
+
+	// non-admins can only view their own sessions
+	if !appContext.AuthUser.IsAdmin && appContext.AuthUser.ID != userID {
+		appContext.Response = MessageResponse{
+			Message: "This resource is only available to users with administrator rights.",
+		}
+		res.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	userDB, err := db.UserFromID(userID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("User not found on ID: %s", userID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	sessionsDB, err := userDB.GetSessions()
+	if err != nil {
+		panic(err)
+	}
+
+	appContext.Response = newSessionsResponse(appContext, sessionsDB)
+}
+
+// UserSessionsDelete = DELETE: /users/:userID/sessions
+// Logs the User out everywhere, for admins to lock out a compromised account
+func UserSessionsDelete(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	userID := pat.Param(ctx, "userID")
+
+	// admins only
+	if !appContext.AuthUser.IsAdmin {
+		appContext.Response = MessageResponse{
+			Message: "This resource is only available to users with administrator rights.",
+		}
+		res.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	userDB, err := db.UserFromID(userID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("User not found on ID: %s", userID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	appContext.Response = sessionsDeletedResponse{
+		Message: ok,
+		Deleted: revokeSessions(&userDB, ""),
+	}
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/sironfoot/go-twitter-bot/data/db"
+	"golang.org/x/net/context"
+)
+
+type settings struct {
+	SignupEnabled         bool `json:"signupEnabled"`
+	RequireAdminTwoFactor bool `json:"requireAdminTwoFactor"`
+}
+
+// SettingsGet = GET: /settings
+func SettingsGet(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+
+	// admins only
+	if !appContext.AuthUser.IsAdmin {
+		appContext.Response = MessageResponse{
+			Message: "This resource is only available to users with administrator rights.",
+		}
+		res.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	settingsDB, err := db.SettingsGet()
+	if err != nil {
+		panic(err)
+	}
+
+	model := struct {
+		MessageResponse
+		Settings settings `json:"settings"`
+	}{}
+
+	model.Message = ok
+	model.Settings = settings{
+		SignupEnabled:         settingsDB.SignupEnabled,
+		RequireAdminTwoFactor: settingsDB.RequireAdminTwoFactor,
+	}
+
+	appContext.Response = model
+}
+
+// SettingsUpdate = PUT: /settings
+func SettingsUpdate(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+
+	// admins only
+	if !appContext.AuthUser.IsAdmin {
+		appContext.Response = MessageResponse{
+			Message: "This resource is only available to users with administrator rights.",
+		}
+		res.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	settingsDB, err := db.SettingsGet()
+	if err != nil {
+		panic(err)
+	}
+
+	// start from the current settings, so fields missing from the request are left unchanged
+	model := settings{
+		SignupEnabled:         settingsDB.SignupEnabled,
+		RequireAdminTwoFactor: settingsDB.RequireAdminTwoFactor,
+	}
+
+	defer req.Body.Close()
+	err = json.NewDecoder(io.LimitReader(req.Body, maxRequestLength)).Decode(&model)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "JSON request body was not in a valid format",
+		}
+		return
+	}
+
+	settingsDB.SignupEnabled = model.SignupEnabled
+	settingsDB.RequireAdminTwoFactor = model.RequireAdminTwoFactor
+
+	err = settingsDB.Save()
+	if err != nil {
+		panic(err)
+	}
+
+	appContext.Response = MessageResponse{
+		Message: ok,
+	}
+}
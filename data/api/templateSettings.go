@@ -0,0 +1,135 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"goji.io/pat"
+
+	"golang.org/x/net/context"
+
+	"github.com/sironfoot/go-twitter-bot/data/db"
+	"github.com/sironfoot/go-twitter-bot/data/models"
+)
+
+type templateSettings struct {
+	TimeZone  string            `json:"timeZone"`
+	Variables map[string]string `json:"variables"`
+	Counters  map[string]int    `json:"counters"`
+}
+
+// TwitterAccountTemplateSettingsGet = GET: /twitterAccounts/:twitterAccountID/templateSettings
+func TwitterAccountTemplateSettingsGet(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	twitterAccountID := pat.Param(ctx, "twitterAccountID")
+
+	account, err := db.TwitterAccountFromID(twitterAccountID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("TwitterAccount not found on ID: %s", twitterAccountID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if !authorize(appContext, res, &account.TwitterAccount, permissionView) {
+		return
+	}
+
+	variables, err := account.GetTemplateVariables()
+	if err != nil {
+		panic(err)
+	}
+
+	counters, err := account.GetTemplateCounters()
+	if err != nil {
+		panic(err)
+	}
+
+	model := struct {
+		MessageResponse
+		TemplateSettings templateSettings `json:"templateSettings"`
+	}{}
+
+	model.Message = ok
+	model.TemplateSettings = templateSettings{
+		TimeZone:  account.TimeZone,
+		Variables: variables,
+		Counters:  counters,
+	}
+
+	appContext.Response = model
+}
+
+// TwitterAccountTemplateSettingsUpdate = PUT: /twitterAccounts/:twitterAccountID/templateSettings
+func TwitterAccountTemplateSettingsUpdate(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	twitterAccountID := pat.Param(ctx, "twitterAccountID")
+
+	account, err := db.TwitterAccountFromID(twitterAccountID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("TwitterAccount not found on ID: %s", twitterAccountID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if !authorize(appContext, res, &account.TwitterAccount, permissionManage) {
+		return
+	}
+
+	var updateSettings models.TemplateSettings
+
+	defer req.Body.Close()
+	err = json.NewDecoder(io.LimitReader(req.Body, maxRequestLength)).Decode(&updateSettings)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "JSON request body was not in a valid format",
+		}
+		return
+	}
+
+	updateSettings.Sanitise()
+	validationErrors, err := updateSettings.ValidateUpdate(account.ID)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(validationErrors) > 0 {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = updateResponse{
+			Message: "TemplateSettings model is invalid.",
+			Errors:  validationErrors,
+		}
+		return
+	}
+
+	account.TimeZone = updateSettings.TimeZone
+
+	err = account.Save()
+	if err != nil {
+		panic(err)
+	}
+
+	err = account.SetTemplateVariables(updateSettings.Variables)
+	if err != nil {
+		panic(err)
+	}
+
+	err = account.SetTemplateCounters(updateSettings.Counters)
+	if err != nil {
+		panic(err)
+	}
+
+	appContext.Response = MessageResponse{
+		Message: ok,
+	}
+}
@@ -0,0 +1,231 @@
+package api
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sironfoot/go-twitter-bot/data/db"
+	"github.com/sironfoot/go-twitter-bot/lib/authtoken"
+)
+
+const (
+	defaultTokenLifetime   = 15 * time.Minute
+	defaultSessionLifetime = 30 * 24 * time.Hour
+
+	// sessionTouchInterval limits how often a Session's last seen time is updated
+	sessionTouchInterval = time.Minute
+)
+
+// accessTokenResponse is returned when logging in or refreshing, the access token is sent back with each
+// request in an "Authorization: Bearer" header, and the refresh token exchanged for a new access token
+// before it expires with PUT: /account/refresh
+type accessTokenResponse struct {
+	Message      string    `json:"message"`
+	AccessToken  string    `json:"accessToken"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+	RefreshToken string    `json:"refreshToken"`
+}
+
+// errNoTokenKeys is returned when asked to issue an access token without any appSettings.tokenKeys configured
+var errNoTokenKeys = errors.New("api: no tokenKeys in appSettings to sign access tokens with")
+
+// tokenLifetime is how long access tokens are valid for after being issued
+func (settings AppSettings) tokenLifetime() time.Duration {
+	if settings.TokenLifetimeMinutes <= 0 {
+		return defaultTokenLifetime
+	}
+
+	return time.Duration(settings.TokenLifetimeMinutes) * time.Minute
+}
+
+// sessionLifetime is how long a Session lasts after logging in
+func (settings AppSettings) sessionLifetime() time.Duration {
+	if settings.SessionLifetimeDays <= 0 {
+		return defaultSessionLifetime
+	}
+
+	return time.Duration(settings.SessionLifetimeDays) * 24 * time.Hour
+}
+
+// issueAccessToken returns a signed access token for a Session, which expires with the Session if that's sooner.
+// Tokens are signed with the first of appSettings.tokenKeys, the rest are only used to verify tokens.
+func issueAccessToken(settings AppSettings, session *db.Session, now time.Time) (string, time.Time, error) {
+	if len(settings.TokenKeys) == 0 {
+		return "", time.Time{}, errNoTokenKeys
+	}
+
+	lifetime := settings.tokenLifetime()
+	if untilExpiry := session.ExpiresOn.Sub(now); untilExpiry < lifetime {
+		lifetime = untilExpiry
+	}
+
+	claims := authtoken.NewClaims(session.UserID, session.ID, now, lifetime)
+
+	token, err := authtoken.Sign(claims, settings.TokenKeys[0])
+	return token, claims.Expires(), err
+}
+
+// newSecretToken returns a random token, for giving to a client once and storing as a hash (see hashSecretToken)
+func newSecretToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// hashSecretToken returns the SHA-256 hash of a token from newSecretToken, for storing and looking it up by.
+// The tokens are random, so unlike passwords they don't need a slow hash.
+func hashSecretToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// issueTokens returns a new access token and refresh token for a Session, saving the refresh token
+func issueTokens(settings AppSettings, session *db.Session, now time.Time) (accessTokenResponse, error) {
+	refreshToken := newSecretToken()
+
+	refreshTokenDB := db.RefreshToken{
+		SessionID:   session.ID,
+		TokenHash:   hashSecretToken(refreshToken),
+		DateCreated: now,
+	}
+
+	if err := refreshTokenDB.Save(); err != nil {
+		return accessTokenResponse{}, err
+	}
+
+	accessToken, expiresAt, err := issueAccessToken(settings, session, now)
+
+	return accessTokenResponse{
+		Message:      ok,
+		AccessToken:  accessToken,
+		ExpiresAt:    expiresAt,
+		RefreshToken: refreshToken,
+	}, err
+}
+
+// requestToken returns the access token from the Authorization: Bearer header, or the accessToken header
+func requestToken(req *http.Request) string {
+	if authorization := req.Header.Get("Authorization"); strings.HasPrefix(authorization, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(authorization, "Bearer "))
+	}
+
+	return req.Header.Get("accessToken")
+}
+
+// Authenticate returns the logged in User and their Session for the request's access token, or nil if there's
+// no token or it isn't valid, has expired or its Session has been revoked. The Base64 AES-CFB tokens issued
+// before signed tokens are only accepted while appSettings.acceptLegacyTokens is on, and have no Session.
+func Authenticate(settings AppSettings, req *http.Request) (*db.User, *db.Session, error) {
+	token := requestToken(req)
+	if token == "" {
+		return nil, nil, nil
+	}
+
+	if isAPIKey(token) {
+		// see AuthenticateAPIKey
+		return nil, nil, nil
+	}
+
+	if !authtoken.IsSigned(token) {
+		if !settings.AcceptLegacyTokens {
+			return nil, nil, nil
+		}
+
+		user, err := userFromLegacyToken(settings.EncryptionKey, token)
+		return user, nil, err
+	}
+
+	now := time.Now().UTC()
+
+	claims, err := authtoken.Verify(token, settings.TokenKeys, now)
+	if err != nil {
+		// not a valid token, force user to log in again
+		return nil, nil, nil
+	}
+
+	session, err := db.SessionFromID(claims.SessionID)
+	if err == db.ErrEntityNotFound {
+		// revoked or logged out
+		return nil, nil, nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	if session.UserID != claims.Subject || session.IsExpired(now) {
+		return nil, nil, nil
+	}
+
+	user, err := db.UserFromID(session.UserID)
+	if err == db.ErrEntityNotFound {
+		return nil, nil, nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	if now.Sub(session.LastSeen) >= sessionTouchInterval {
+		if err = session.Touch(now); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return &user, &session, nil
+}
+
+// userFromLegacyToken returns the User for a token issued before signed tokens, if it's for their current AuthToken
+func userFromLegacyToken(encryptionKey, token string) (*db.User, error) {
+	userID, authToken, valid := decryptLegacyToken(encryptionKey, token)
+	if !valid {
+		return nil, nil
+	}
+
+	user, err := db.UserFromID(userID)
+	if err == db.ErrEntityNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !user.AuthToken.Valid || user.AuthToken.String != authToken {
+		// logged out since the token was issued
+		return nil, nil
+	}
+
+	return &user, nil
+}
+
+// decryptLegacyToken returns the UserID and AuthToken from a token issued before signed tokens, which is
+// UserID_AuthToken encrypted with appSettings.encryptionKey using AES-CFB, then Base64 encoded
+func decryptLegacyToken(encryptionKey, accessToken string) (string, string, bool) {
+	encryptedToken, err := base64.StdEncoding.DecodeString(accessToken)
+	if err != nil || len(encryptedToken) < aes.BlockSize {
+		return "", "", false
+	}
+
+	block, err := aes.NewCipher([]byte(encryptionKey))
+	if err != nil {
+		panic(err)
+	}
+
+	iv := encryptedToken[:aes.BlockSize]
+	encryptedToken = encryptedToken[aes.BlockSize:]
+
+	cfb := cipher.NewCFBDecrypter(block, iv)
+	cfb.XORKeyStream(encryptedToken, encryptedToken)
+
+	tokenParts := strings.Split(string(encryptedToken), "_")
+	if len(tokenParts) != 2 {
+		return "", "", false
+	}
+
+	return tokenParts[0], tokenParts[1], true
+}
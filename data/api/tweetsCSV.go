@@ -0,0 +1,184 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"goji.io/pat"
+
+	"golang.org/x/net/context"
+
+	"github.com/lib/pq"
+	"github.com/sironfoot/go-twitter-bot/data/db"
+	"github.com/sironfoot/go-twitter-bot/data/events"
+	"github.com/sironfoot/go-twitter-bot/data/models"
+	"github.com/sironfoot/go-twitter-bot/lib/tweetcsv"
+)
+
+type importResponse struct {
+	Message string                 `json:"message"`
+	DryRun  bool                   `json:"dryRun"`
+	Rows    int                    `json:"rows"`
+	Created int                    `json:"created"`
+	Errors  []models.RowValidation `json:"errors"`
+}
+
+// TwitterAccountTweetsImport = POST: /twitterAccounts/:twitterAccountID/tweets/import
+// Creates a Tweet for each row of a CSV request body (see tweetcsv.Read). Nothing is created if any row
+// is invalid, and with ?dryRun=true rows are only validated.
+func TwitterAccountTweetsImport(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	twitterAccountID := pat.Param(ctx, "twitterAccountID")
+
+	account, err := db.TwitterAccountFromID(twitterAccountID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("TwitterAccount not found on ID: %s", twitterAccountID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if !authorize(appContext, res, &account.TwitterAccount, permissionEdit) {
+		return
+	}
+
+	location, err := account.Location()
+	if err != nil {
+		panic(err)
+	}
+
+	rows, err := tweetcsv.Read(io.LimitReader(req.Body, maxRequestLength), location)
+	req.Body.Close()
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("CSV could not be read: %s", err),
+		}
+		return
+	}
+
+	model := importResponse{
+		DryRun: req.URL.Query().Get("dryRun") == "true",
+		Rows:   len(rows),
+		Errors: make([]models.RowValidation, 0),
+	}
+
+	var tweets []db.Tweet
+	for _, row := range rows {
+		newTweet := models.Tweet{
+			Text:   row.Text,
+			PostOn: row.PostOn,
+			Tags:   row.Tags,
+		}
+
+		validationErrors, err := newTweet.ValidateImport(row, account.Username)
+		if err != nil {
+			panic(err)
+		}
+
+		if len(validationErrors) == 0 && account.DuplicatePolicy == db.DuplicatePolicyReject {
+			validationErrors, err = newTweet.ValidateDuplicates(&account.TwitterAccount, "")
+			if err != nil {
+				panic(err)
+			}
+		}
+
+		if len(validationErrors) > 0 {
+			model.Errors = append(model.Errors, models.RowValidation{
+				Row:    row.Line,
+				Errors: validationErrors,
+			})
+			continue
+		}
+
+		tweets = append(tweets, db.Tweet{
+			AccountID:        account.ID,
+			Tweet:            newTweet.Text,
+			PostOn:           newTweet.PostOn,
+			VariantSelection: newTweet.VariantSelection,
+			Tags:             pq.StringArray(newTweet.Tags),
+			DateCreated:      time.Now().UTC(),
+		})
+	}
+
+	if len(model.Errors) > 0 {
+		model.Message = "CSV contains invalid rows, no Tweets were imported."
+		appContext.Response = model
+
+		res.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	model.Message = ok
+
+	if model.DryRun {
+		appContext.Response = model
+		return
+	}
+
+	err = db.TweetsSaveAll(tweets)
+	if err != nil {
+		panic(err)
+	}
+
+	for i := range tweets {
+		events.Publish(events.TweetCreated, &account.TwitterAccount, &tweets[i])
+	}
+
+	model.Created = len(tweets)
+	res.WriteHeader(http.StatusCreated)
+
+	appContext.Response = model
+}
+
+// TwitterAccountTweetsExport = GET: /twitterAccounts/:twitterAccountID/tweets/export
+// Downloads all of the TwitterAccount's Tweets as CSV, in the same format as TwitterAccountTweetsImport
+func TwitterAccountTweetsExport(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	twitterAccountID := pat.Param(ctx, "twitterAccountID")
+
+	account, err := db.TwitterAccountFromID(twitterAccountID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("TwitterAccount not found on ID: %s", twitterAccountID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if !authorize(appContext, res, &account.TwitterAccount, permissionView) {
+		return
+	}
+
+	tweets, err := account.GetAllTweets()
+	if err != nil {
+		panic(err)
+	}
+
+	var rows []tweetcsv.Row
+	for _, tweet := range tweets {
+		rows = append(rows, tweetcsv.Row{
+			Text:    tweet.Tweet,
+			PostOn:  tweet.PostOn,
+			Account: account.Username,
+			Tags:    tweet.Tags,
+		})
+	}
+
+	// written directly rather than through appContext.Response, which is always JSON
+	res.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	res.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-tweets.csv"`, strings.TrimPrefix(account.Username, "@")))
+
+	err = tweetcsv.Write(res, rows)
+	if err != nil {
+		panic(err)
+	}
+}
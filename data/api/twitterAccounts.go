@@ -10,19 +10,50 @@ import (
 
 	"golang.org/x/net/context"
 
+	"github.com/lib/pq"
 	"github.com/sironfoot/go-twitter-bot/data/db"
+	"github.com/sironfoot/go-twitter-bot/data/events"
 	"github.com/sironfoot/go-twitter-bot/data/models"
 )
 
 type twitterAccountBase struct {
 	ID                string    `json:"id"`
 	UserID            string    `json:"userId"`
+	OrganisationID    *string   `json:"organisationId"`
 	Username          string    `json:"username"`
 	DateCreated       time.Time `json:"dateCreated"`
 	ConsumerKey       string    `json:"consumerKey"`
 	ConsumerSecret    string    `json:"consumerSecret"`
 	AccessToken       string    `json:"accessToken"`
 	AccessTokenSecret string    `json:"accessTokenSecret"`
+	TimeZone          string    `json:"timeZone"`
+	Role              string    `json:"role"`
+}
+
+// newTwitterAccountBase returns a TwitterAccount as seen by a User with 'role' on it.
+// Only owners can see the Twitter credentials.
+func newTwitterAccountBase(role string, account *db.TwitterAccount) twitterAccountBase {
+	base := twitterAccountBase{
+		ID:          account.ID,
+		UserID:      account.UserID.String,
+		Username:    account.Username,
+		DateCreated: account.DateCreated,
+		TimeZone:    account.TimeZone,
+		Role:        role,
+	}
+
+	if account.OrganisationID.Valid {
+		base.OrganisationID = &account.OrganisationID.String
+	}
+
+	if roleAllows(base.Role, permissionManage) {
+		base.ConsumerKey = account.ConsumerKey
+		base.ConsumerSecret = account.ConsumerSecret
+		base.AccessToken = account.AccessToken
+		base.AccessTokenSecret = account.AccessTokenSecret
+	}
+
+	return base
 }
 
 type twitterAccount struct {
@@ -43,10 +74,23 @@ type childTweets struct {
 }
 
 type tweet struct {
-	ID       string    `json:"id"`
-	Text     string    `json:"text"`
-	PostOn   time.Time `json:"postOn"`
-	IsPosted bool      `json:"isPosted"`
+	ID               string         `json:"id"`
+	Text             string         `json:"text"`
+	PostOn           time.Time      `json:"postOn"`
+	IsPosted         bool           `json:"isPosted"`
+	IsDraft          bool           `json:"isDraft"`
+	VariantSelection string         `json:"variantSelection"`
+	Variants         []tweetVariant `json:"variants"`
+	PostedVariantID  *string        `json:"postedVariantId"`
+	StatusID         *string        `json:"statusId"`
+	Tags             []string       `json:"tags"`
+}
+
+type tweetVariant struct {
+	ID          string `json:"id"`
+	Text        string `json:"text"`
+	Weight      int    `json:"weight"`
+	TimesPosted int    `json:"timesPosted"`
 }
 
 // TwitterAccountsAll = GET: /twitterAccounts
@@ -71,33 +115,31 @@ func TwitterAccountsAll(ctx context.Context, res http.ResponseWriter, req *http.
 		query.HasTweetsToBePostedSince = dateTime
 	}
 
-	filterUserID := qs.Get("userID")
-	// filter TwitterAccounts to user's own if not an admin
+	query.UserID = qs.Get("userID")
+	query.OrganisationID = qs.Get("organisationID")
+	// filter TwitterAccounts to those the user owns, has a role on or shares through an Organisation if not an admin
 	if !appContext.AuthUser.IsAdmin {
-		filterUserID = appContext.AuthUser.ID
+		query.AccessibleByUserID = appContext.AuthUser.ID
 	}
-	query.UserID = filterUserID // TODO: implement UserID support in data access code
 
 	twitterAccounts, totalRecords, err := db.TwitterAccountsAll(query)
 	if err != nil {
 		panic(err)
 	}
 
+	roles, err := appContext.AuthUser.GetTwitterAccountRoles()
+	if err != nil {
+		panic(err)
+	}
+
 	var accounts []twitterAccount
 
 	for _, accountDB := range twitterAccounts {
+		role := accountRoleFrom(roles, appContext.AuthUser, &accountDB.TwitterAccount)
+
 		account := twitterAccount{
-			twitterAccountBase: twitterAccountBase{
-				ID:                accountDB.ID,
-				UserID:            accountDB.UserID,
-				Username:          accountDB.Username,
-				DateCreated:       accountDB.DateCreated,
-				ConsumerKey:       accountDB.ConsumerKey,
-				ConsumerSecret:    accountDB.ConsumerSecret,
-				AccessToken:       accountDB.AccessToken,
-				AccessTokenSecret: accountDB.AccessTokenSecret,
-			},
-			Tweets: accountDB.NumTweets,
+			twitterAccountBase: newTwitterAccountBase(role, &accountDB.TwitterAccount),
+			Tweets:             accountDB.NumTweets,
 		}
 
 		accounts = append(accounts, account)
@@ -138,21 +180,7 @@ func TwitterAccountGet(ctx context.Context, res http.ResponseWriter, req *http.R
 		panic(err)
 	}
 
-	// non-admins can only view their own TwitterAccounts
-	if !appContext.AuthUser.IsAdmin && appContext.AuthUser.ID != account.UserID {
-		appContext.Response = MessageResponse{
-			Message: "This resource is only available to users with administrator rights.",
-		}
-		res.WriteHeader(http.StatusForbidden)
-		return
-	}
-
-	// non-admins can only view their own TwitterAccounts
-	if !appContext.AuthUser.IsAdmin && account.UserID != appContext.AuthUser.ID {
-		appContext.Response = MessageResponse{
-			Message: "This resource is only available to users with administrator rights.",
-		}
-		res.WriteHeader(http.StatusForbidden)
+	if !authorize(appContext, res, &account.TwitterAccount, permissionView) {
 		return
 	}
 
@@ -163,17 +191,8 @@ func TwitterAccountGet(ctx context.Context, res http.ResponseWriter, req *http.R
 
 	model.Message = "OK"
 	model.TwitterAccount = twitterAccount{
-		twitterAccountBase: twitterAccountBase{
-			ID:                account.ID,
-			UserID:            account.UserID,
-			Username:          account.Username,
-			DateCreated:       account.DateCreated,
-			ConsumerKey:       account.ConsumerKey,
-			ConsumerSecret:    account.ConsumerSecret,
-			AccessToken:       account.AccessToken,
-			AccessTokenSecret: account.AccessTokenSecret,
-		},
-		Tweets: account.NumTweets,
+		twitterAccountBase: newTwitterAccountBase(accountRole(appContext.AuthUser, &account.TwitterAccount), &account.TwitterAccount),
+		Tweets:             account.NumTweets,
 	}
 
 	appContext.Response = model
@@ -195,12 +214,7 @@ func TwitterAccountGetWithTweets(ctx context.Context, res http.ResponseWriter, r
 		panic(err)
 	}
 
-	// non-admins can only view their own TwitterAccounts
-	if !appContext.AuthUser.IsAdmin && appContext.AuthUser.ID != account.UserID {
-		appContext.Response = MessageResponse{
-			Message: "This resource is only available to users with administrator rights.",
-		}
-		res.WriteHeader(http.StatusForbidden)
+	if !authorize(appContext, res, &account.TwitterAccount, permissionView) {
 		return
 	}
 
@@ -211,16 +225,7 @@ func TwitterAccountGetWithTweets(ctx context.Context, res http.ResponseWriter, r
 
 	model.Message = "OK"
 	model.TwitterAccount = twitterAccountWithTweets{
-		twitterAccountBase: twitterAccountBase{
-			ID:                account.ID,
-			UserID:            account.UserID,
-			Username:          account.Username,
-			DateCreated:       account.DateCreated,
-			ConsumerKey:       account.ConsumerKey,
-			ConsumerSecret:    account.ConsumerSecret,
-			AccessToken:       account.AccessToken,
-			AccessTokenSecret: account.AccessTokenSecret,
-		},
+		twitterAccountBase: newTwitterAccountBase(accountRole(appContext.AuthUser, &account.TwitterAccount), &account.TwitterAccount),
 		Tweets: childTweets{
 			Page:           1,
 			TotalRecords:   0,
@@ -252,14 +257,42 @@ func TwitterAccountGetWithTweets(ctx context.Context, res http.ResponseWriter, r
 
 	model.TwitterAccount.Tweets.TotalRecords = totalTweets
 
+	variants, err := db.TweetsGetVariants(tweets)
+	if err != nil {
+		panic(err)
+	}
+
 	if len(tweets) > 0 {
 		for _, tweetDB := range tweets {
-			model.TwitterAccount.Tweets.Records = append(model.TwitterAccount.Tweets.Records, tweet{
-				ID:       tweetDB.ID,
-				Text:     tweetDB.Tweet,
-				PostOn:   tweetDB.PostOn,
-				IsPosted: tweetDB.IsPosted,
-			})
+			record := tweet{
+				ID:               tweetDB.ID,
+				Text:             tweetDB.Tweet,
+				PostOn:           tweetDB.PostOn,
+				IsPosted:         tweetDB.IsPosted,
+				IsDraft:          tweetDB.IsDraft,
+				VariantSelection: tweetDB.VariantSelection,
+				Variants:         make([]tweetVariant, 0),
+				Tags:             append([]string{}, tweetDB.Tags...),
+			}
+
+			if tweetDB.PostedVariantID.Valid {
+				record.PostedVariantID = &tweetDB.PostedVariantID.String
+			}
+
+			if tweetDB.StatusID.Valid {
+				record.StatusID = &tweetDB.StatusID.String
+			}
+
+			for _, variantDB := range variants[tweetDB.ID] {
+				record.Variants = append(record.Variants, tweetVariant{
+					ID:          variantDB.ID,
+					Text:        variantDB.Tweet,
+					Weight:      variantDB.Weight,
+					TimesPosted: variantDB.TimesPosted,
+				})
+			}
+
+			model.TwitterAccount.Tweets.Records = append(model.TwitterAccount.Tweets.Records, record)
 		}
 	} else {
 		model.TwitterAccount.Tweets.Records = make([]tweet, 0)
@@ -284,12 +317,7 @@ func TwitterAccountTweetCreate(ctx context.Context, res http.ResponseWriter, req
 		panic(err)
 	}
 
-	// non-admins can only create Tweets for their own TwitterAccounts
-	if !appContext.AuthUser.IsAdmin && appContext.AuthUser.ID != account.UserID {
-		appContext.Response = MessageResponse{
-			Message: "This resource is only available to users with administrator rights.",
-		}
-		res.WriteHeader(http.StatusForbidden)
+	if !authorize(appContext, res, &account.TwitterAccount, permissionEdit) {
 		return
 	}
 
@@ -309,6 +337,19 @@ func TwitterAccountTweetCreate(ctx context.Context, res http.ResponseWriter, req
 
 	model := createResponse{}
 
+	if len(validationErrors) == 0 && account.DuplicatePolicy != db.DuplicatePolicyOff {
+		duplicateErrors, err := newTweet.ValidateDuplicates(&account.TwitterAccount, "")
+		if err != nil {
+			panic(err)
+		}
+
+		if account.DuplicatePolicy == db.DuplicatePolicyReject {
+			validationErrors = append(validationErrors, duplicateErrors...)
+		} else {
+			model.Warnings = duplicateErrors
+		}
+	}
+
 	if len(validationErrors) > 0 {
 		model.Message = "Tweet model is invalid."
 		model.Errors = validationErrors
@@ -319,11 +360,14 @@ func TwitterAccountTweetCreate(ctx context.Context, res http.ResponseWriter, req
 	}
 
 	tweet := &db.Tweet{
-		AccountID:   account.ID,
-		Tweet:       newTweet.Text,
-		PostOn:      newTweet.PostOn,
-		IsPosted:    newTweet.IsPosted,
-		DateCreated: time.Now().UTC(),
+		AccountID:        account.ID,
+		Tweet:            newTweet.Text,
+		PostOn:           newTweet.PostOn,
+		IsPosted:         newTweet.IsPosted,
+		IsDraft:          newTweet.IsDraft,
+		VariantSelection: newTweet.VariantSelection,
+		Tags:             pq.StringArray(newTweet.Tags),
+		DateCreated:      time.Now().UTC(),
 	}
 
 	err = tweet.Save()
@@ -331,6 +375,13 @@ func TwitterAccountTweetCreate(ctx context.Context, res http.ResponseWriter, req
 		panic(err)
 	}
 
+	err = saveTweetVariants(tweet, newTweet.Variants)
+	if err != nil {
+		panic(err)
+	}
+
+	events.Publish(events.TweetCreated, &account.TwitterAccount, tweet)
+
 	model.Message = ok
 	model.ID = &tweet.ID
 	res.WriteHeader(http.StatusCreated)
@@ -354,12 +405,7 @@ func TwitterAccountTweetUpdate(ctx context.Context, res http.ResponseWriter, req
 		panic(err)
 	}
 
-	// non-admins can only edit their own Tweets
-	if !appContext.AuthUser.IsAdmin && appContext.AuthUser.ID != account.UserID {
-		appContext.Response = MessageResponse{
-			Message: "This resource is only available to users with administrator rights.",
-		}
-		res.WriteHeader(http.StatusForbidden)
+	if !authorize(appContext, res, &account.TwitterAccount, permissionEdit) {
 		return
 	}
 
@@ -385,6 +431,24 @@ func TwitterAccountTweetUpdate(ctx context.Context, res http.ResponseWriter, req
 
 	updateTweet.Sanitise()
 	validationErrors, err := updateTweet.ValidateUpdate(tweetID)
+	if err != nil {
+		panic(err)
+	}
+
+	var warnings []models.ValidationError
+
+	if len(validationErrors) == 0 && account.DuplicatePolicy != db.DuplicatePolicyOff {
+		duplicateErrors, err := updateTweet.ValidateDuplicates(&account.TwitterAccount, tweet.ID)
+		if err != nil {
+			panic(err)
+		}
+
+		if account.DuplicatePolicy == db.DuplicatePolicyReject {
+			validationErrors = append(validationErrors, duplicateErrors...)
+		} else {
+			warnings = duplicateErrors
+		}
+	}
 
 	if len(validationErrors) > 0 {
 		res.WriteHeader(http.StatusBadRequest)
@@ -398,19 +462,37 @@ func TwitterAccountTweetUpdate(ctx context.Context, res http.ResponseWriter, req
 	tweet.Tweet = updateTweet.Text
 	tweet.PostOn = updateTweet.PostOn
 	tweet.IsPosted = updateTweet.IsPosted
+	tweet.IsDraft = updateTweet.IsDraft
+	tweet.VariantSelection = updateTweet.VariantSelection
+	tweet.Tags = pq.StringArray(updateTweet.Tags)
 
 	err = tweet.Save()
 	if err != nil {
 		panic(err)
 	}
 
+	err = saveTweetVariants(&tweet, updateTweet.Variants)
+	if err != nil {
+		panic(err)
+	}
+
+	events.Publish(events.TweetUpdated, &account.TwitterAccount, &tweet)
+
+	if len(warnings) > 0 {
+		appContext.Response = updateResponse{
+			Message:  ok,
+			Warnings: warnings,
+		}
+		return
+	}
+
 	appContext.Response = MessageResponse{
 		Message: ok,
 	}
 }
 
-// TwitterAccountTweetDelete = DELETE: /twitterAccounts/:twitterAccountID/tweets/:tweetID
-func TwitterAccountTweetDelete(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+// TwitterAccountTweetApprove = PUT: /twitterAccounts/:twitterAccountID/tweets/:tweetID/approve
+func TwitterAccountTweetApprove(ctx context.Context, res http.ResponseWriter, req *http.Request) {
 	appContext := ctx.Value("appContext").(*AppContext)
 	twitterAccountID := pat.Param(ctx, "twitterAccountID")
 
@@ -425,12 +507,51 @@ func TwitterAccountTweetDelete(ctx context.Context, res http.ResponseWriter, req
 		panic(err)
 	}
 
-	// non-admins can only delete their own Tweets
-	if !appContext.AuthUser.IsAdmin && appContext.AuthUser.ID != account.UserID {
+	if !authorize(appContext, res, &account.TwitterAccount, permissionReview) {
+		return
+	}
+
+	tweetID := pat.Param(ctx, "tweetID")
+	tweet, err := account.GetTweetFromID(tweetID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
 		appContext.Response = MessageResponse{
-			Message: "This resource is only available to users with administrator rights.",
+			Message: fmt.Sprintf("Tweet not found on ID: %s", tweetID),
 		}
-		res.WriteHeader(http.StatusForbidden)
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	err = tweet.Approve()
+	if err != nil {
+		panic(err)
+	}
+
+	events.Publish(events.TweetUpdated, &account.TwitterAccount, &tweet)
+
+	appContext.Response = MessageResponse{
+		Message: ok,
+	}
+}
+
+// TwitterAccountTweetDelete = DELETE: /twitterAccounts/:twitterAccountID/tweets/:tweetID
+func TwitterAccountTweetDelete(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	twitterAccountID := pat.Param(ctx, "twitterAccountID")
+
+	account, err := db.TwitterAccountFromID(twitterAccountID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("TwitterAccount not found on ID: %s", twitterAccountID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if !authorize(appContext, res, &account.TwitterAccount, permissionEdit) {
 		return
 	}
 
@@ -451,7 +572,69 @@ func TwitterAccountTweetDelete(ctx context.Context, res http.ResponseWriter, req
 		panic(err)
 	}
 
+	events.Publish(events.TweetDeleted, &account.TwitterAccount, &tweet)
+
 	appContext.Response = MessageResponse{
 		Message: ok,
 	}
 }
+
+// saveTweetVariants syncs a Tweet's variants with those posted to the API, variants posted with
+// an ID are updated (keeping their TimesPosted), those without are created and any not posted are deleted
+func saveTweetVariants(tweet *db.Tweet, variants []models.TweetVariant) error {
+	existingVariants, err := tweet.GetVariants()
+	if err != nil {
+		return err
+	}
+
+	for i := range existingVariants {
+		existing := &existingVariants[i]
+		found := false
+
+		for _, variant := range variants {
+			if variant.ID == existing.ID {
+				existing.Tweet = variant.Text
+				existing.Weight = variant.Weight
+				found = true
+				break
+			}
+		}
+
+		if found {
+			err = existing.Save()
+		} else {
+			err = existing.Delete()
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, variant := range variants {
+		isExisting := false
+		for _, existing := range existingVariants {
+			if variant.ID == existing.ID {
+				isExisting = true
+				break
+			}
+		}
+
+		if isExisting {
+			continue
+		}
+
+		newVariant := db.TweetVariant{
+			TweetID:     tweet.ID,
+			Tweet:       variant.Text,
+			Weight:      variant.Weight,
+			DateCreated: time.Now().UTC(),
+		}
+
+		if err = newVariant.Save(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,524 @@
+package api
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"goji.io/pat"
+
+	"github.com/sironfoot/go-twitter-bot/data/db"
+	"github.com/sironfoot/go-twitter-bot/data/models"
+	"github.com/sironfoot/go-twitter-bot/lib/totp"
+	"golang.org/x/net/context"
+)
+
+const (
+	// twoFactorIssuer names the server in authenticator apps
+	twoFactorIssuer             = "GoBot"
+	twoFactorLoginTokenLifetime = 5 * time.Minute
+	recoveryCodeCount           = 10
+	// recoveryCodeLength is the number of characters in a recovery code, not counting dashes
+	recoveryCodeLength = 16
+)
+
+// twoFactorRequiredResponse is returned by PUT: /account/login instead of tokens when the User has
+// two-factor authentication on, the token is sent with a code to PUT: /account/login/two-factor
+type twoFactorRequiredResponse struct {
+	Message           string `json:"message"`
+	TwoFactorRequired bool   `json:"twoFactorRequired"`
+	Token             string `json:"token"`
+}
+
+type recoveryCodesResponse struct {
+	Message       string   `json:"message"`
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// TwoFactorSetupRequired determines if a User has to turn on two-factor authentication before using
+// the API, which admins do when the requireAdminTwoFactor setting is on
+func TwoFactorSetupRequired(user *db.User) (bool, error) {
+	if !user.IsAdmin || user.TwoFactorEnabled {
+		return false, nil
+	}
+
+	settings, err := db.SettingsGet()
+	return settings.RequireAdminTwoFactor, err
+}
+
+// newRecoveryCodes returns new recovery codes to show to the User once, and their hashes to store
+func newRecoveryCodes() ([]string, []string) {
+	var codes, hashes []string
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, recoveryCodeLength*5/8)
+		if _, err := rand.Read(b); err != nil {
+			panic(err)
+		}
+
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(b))
+
+		var groups []string
+		for start := 0; start < len(code); start += 4 {
+			groups = append(groups, code[start:start+4])
+		}
+
+		codes = append(codes, strings.Join(groups, "-"))
+		hashes = append(hashes, hashSecretToken(code))
+	}
+
+	return codes, hashes
+}
+
+// normaliseRecoveryCode returns a recovery code as it's hashed, so dashes, spaces and case don't matter
+func normaliseRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// checkTwoFactorCode determines if code is the User's current two-factor code, or one of their unused
+// recovery codes, marking it as used either way so it can't be used again
+func checkTwoFactorCode(user *db.User, code string, ipAddress string, now time.Time) bool {
+	if recoveryCode := normaliseRecoveryCode(code); len(recoveryCode) == recoveryCodeLength {
+		used, err := user.UseRecoveryCode(hashSecretToken(recoveryCode))
+		if err != nil {
+			panic(err)
+		}
+
+		if used {
+			saveAuditEntry(&db.AuditEntry{
+				UserID:      sql.NullString{String: user.ID, Valid: true},
+				Event:       db.AuditEventRecoveryCodeUsed,
+				IPAddress:   ipAddress,
+				Detail:      fmt.Sprintf("recovery code used by %s", user.Email),
+				DateCreated: now,
+			})
+		}
+		return used
+	}
+
+	if !user.TwoFactorSecret.Valid {
+		return false
+	}
+
+	step, valid := totp.Validate(user.TwoFactorSecret.String, code, now)
+	if !valid {
+		return false
+	}
+
+	used, err := user.UseTwoFactorStep(step)
+	if err != nil {
+		panic(err)
+	}
+
+	return used
+}
+
+// decodeTwoFactorCode decodes and validates a models.TwoFactorCode from the request body, responding
+// with the errors and returning false if it isn't valid
+func decodeTwoFactorCode(appContext *AppContext, res http.ResponseWriter, req *http.Request) (models.TwoFactorCode, bool) {
+	var twoFactorCode models.TwoFactorCode
+
+	defer req.Body.Close()
+	err := json.NewDecoder(io.LimitReader(req.Body, maxRequestLength)).Decode(&twoFactorCode)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "JSON request body was not in a valid format",
+		}
+		return twoFactorCode, false
+	}
+
+	twoFactorCode.Sanitise()
+	validationErrors, err := twoFactorCode.Validate()
+	if err != nil {
+		panic(err)
+	}
+
+	if len(validationErrors) > 0 {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = updateResponse{
+			Message: "TwoFactorCode model is invalid.",
+			Errors:  validationErrors,
+		}
+		return twoFactorCode, false
+	}
+
+	return twoFactorCode, true
+}
+
+func twoFactorCodeNotCorrect(appContext *AppContext, res http.ResponseWriter) {
+	res.WriteHeader(http.StatusForbidden)
+	appContext.Response = MessageResponse{
+		Message: "Two-factor code is not correct.",
+	}
+}
+
+// verifyTwoFactorCode checks the logged in User's code with checkTwoFactorCode under the same lockout as logging in,
+// so someone with a stolen access token can't guess it. It responds with 429 Too Many Requests while locked out,
+// or 403 Forbidden when the code isn't correct.
+func verifyTwoFactorCode(appContext *AppContext, res http.ResponseWriter, req *http.Request, code string, now time.Time) bool {
+	user := appContext.AuthUser
+	protection := appContext.Settings.AppSettings.LoginProtection
+	email := loginEmail(user.Email)
+	ipAddress := remoteIP(req)
+
+	if until := loginLockedUntil(protection, email, ipAddress, now); !until.IsZero() {
+		loginLockedOut(appContext, res, until, now)
+		return false
+	}
+
+	if !checkTwoFactorCode(user, code, ipAddress, now) {
+		time.Sleep(recordLoginFailure(protection, email, ipAddress, user, now))
+		twoFactorCodeNotCorrect(appContext, res)
+		return false
+	}
+
+	return true
+}
+
+// AccountLoginTwoFactor = PUT: /account/login/two-factor
+// The second step of logging in for Users with two-factor authentication on, exchanging the token
+// from PUT: /account/login and a code, or a recovery code, for an access token and refresh token
+func AccountLoginTwoFactor(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	var twoFactorLogin models.TwoFactorLogin
+
+	defer req.Body.Close()
+	err := json.NewDecoder(io.LimitReader(req.Body, maxRequestLength)).Decode(&twoFactorLogin)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "JSON request body was not in a valid format",
+		}
+		return
+	}
+
+	twoFactorLogin.Sanitise()
+	validationErrors, err := twoFactorLogin.Validate()
+	if err != nil {
+		panic(err)
+	}
+
+	if len(validationErrors) > 0 {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = updateResponse{
+			Message: "TwoFactorLogin model is invalid.",
+			Errors:  validationErrors,
+		}
+		return
+	}
+
+	var notValid = func() {
+		res.WriteHeader(http.StatusUnauthorized)
+		appContext.Response = MessageResponse{
+			Message: "Two-factor login token is not valid or has expired. Please log in again with PUT: /account/login",
+		}
+	}
+
+	userToken, err := db.UserTokenFromHash(db.UserTokenPurposeTwoFactorLogin, hashSecretToken(twoFactorLogin.Token))
+	if err == db.ErrEntityNotFound {
+		notValid()
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	user, err := db.UserFromID(userToken.UserID)
+	if err == db.ErrEntityNotFound {
+		notValid()
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	protection := appContext.Settings.AppSettings.LoginProtection
+	email := loginEmail(user.Email)
+	ipAddress := remoteIP(req)
+	now := time.Now().UTC()
+
+	if until := loginLockedUntil(protection, email, ipAddress, now); !until.IsZero() {
+		loginLockedOut(appContext, res, until, now)
+		return
+	}
+
+	if !user.TwoFactorEnabled || !checkTwoFactorCode(&user, twoFactorLogin.Code, ipAddress, now) {
+		time.Sleep(recordLoginFailure(protection, email, ipAddress, &user, now))
+		twoFactorCodeNotCorrect(appContext, res)
+		return
+	}
+
+	used, err := userToken.Use()
+	if err != nil {
+		panic(err)
+	} else if !used {
+		notValid()
+		return
+	}
+
+	appContext.Response = startSession(appContext, req, &user, now)
+}
+
+// AccountTwoFactorGet = GET: /account/two-factor
+func AccountTwoFactorGet(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	authUser := appContext.AuthUser
+
+	required, err := TwoFactorSetupRequired(authUser)
+	if err != nil {
+		panic(err)
+	}
+
+	remaining := 0
+	if authUser.TwoFactorEnabled {
+		remaining, err = authUser.CountRecoveryCodes()
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	appContext.Response = struct {
+		Message                string `json:"message"`
+		Enabled                bool   `json:"enabled"`
+		SetupRequired          bool   `json:"setupRequired"`
+		RecoveryCodesRemaining int    `json:"recoveryCodesRemaining"`
+	}{ok, authUser.TwoFactorEnabled, required, remaining}
+}
+
+// AccountTwoFactorEnrol = POST: /account/two-factor
+// Starts turning on two-factor authentication, returning a new secret to add to an authenticator app,
+// which isn't used until a code from the app is sent to PUT: /account/two-factor/confirm
+func AccountTwoFactorEnrol(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	authUser := appContext.AuthUser
+
+	if authUser.TwoFactorEnabled {
+		res.WriteHeader(http.StatusConflict)
+		appContext.Response = MessageResponse{
+			Message: "Two-factor authentication is already on. Turn it off first with PUT: /account/two-factor/disable",
+		}
+		return
+	}
+
+	secret, err := totp.NewSecret()
+	if err != nil {
+		panic(err)
+	}
+
+	authUser.TwoFactorSecret = sql.NullString{String: secret, Valid: true}
+
+	err = authUser.Save()
+	if err != nil {
+		panic(err)
+	}
+
+	appContext.Response = struct {
+		Message string `json:"message"`
+		Secret  string `json:"secret"`
+		URI     string `json:"uri"`
+	}{ok, secret, totp.URI(twoFactorIssuer, authUser.Email, secret)}
+}
+
+// AccountTwoFactorConfirm = PUT: /account/two-factor/confirm
+// Turns on two-factor authentication once the User sends a code from their authenticator app,
+// returning recovery codes that are only shown this once
+func AccountTwoFactorConfirm(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	authUser := appContext.AuthUser
+
+	twoFactorCode, valid := decodeTwoFactorCode(appContext, res, req)
+	if !valid {
+		return
+	}
+
+	if authUser.TwoFactorEnabled {
+		res.WriteHeader(http.StatusConflict)
+		appContext.Response = MessageResponse{
+			Message: "Two-factor authentication is already on.",
+		}
+		return
+	}
+
+	if !authUser.TwoFactorSecret.Valid {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "Two-factor authentication hasn't been started. Please start with POST: /account/two-factor",
+		}
+		return
+	}
+
+	now := time.Now().UTC()
+
+	step, valid := totp.Validate(authUser.TwoFactorSecret.String, twoFactorCode.Code, now)
+	if !valid {
+		twoFactorCodeNotCorrect(appContext, res)
+		return
+	}
+
+	codes, hashes := newRecoveryCodes()
+
+	err := authUser.EnableTwoFactor(step, hashes)
+	if err != nil {
+		panic(err)
+	}
+
+	saveAuditEntry(&db.AuditEntry{
+		UserID:      sql.NullString{String: authUser.ID, Valid: true},
+		Event:       db.AuditEventTwoFactorEnabled,
+		IPAddress:   remoteIP(req),
+		Detail:      fmt.Sprintf("two-factor authentication turned on by %s", authUser.Email),
+		DateCreated: now,
+	})
+
+	appContext.Response = recoveryCodesResponse{
+		Message:       ok,
+		RecoveryCodes: codes,
+	}
+}
+
+// AccountTwoFactorDisable = PUT: /account/two-factor/disable
+func AccountTwoFactorDisable(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	authUser := appContext.AuthUser
+
+	twoFactorCode, valid := decodeTwoFactorCode(appContext, res, req)
+	if !valid {
+		return
+	}
+
+	if !authUser.TwoFactorEnabled {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "Two-factor authentication is not on.",
+		}
+		return
+	}
+
+	if authUser.IsAdmin {
+		settings, err := db.SettingsGet()
+		if err != nil {
+			panic(err)
+		}
+
+		if settings.RequireAdminTwoFactor {
+			res.WriteHeader(http.StatusForbidden)
+			appContext.Response = MessageResponse{
+				Message: "Two-factor authentication is required for administrators.",
+			}
+			return
+		}
+	}
+
+	now := time.Now().UTC()
+	ipAddress := remoteIP(req)
+
+	if !verifyTwoFactorCode(appContext, res, req, twoFactorCode.Code, now) {
+		return
+	}
+
+	err := authUser.DisableTwoFactor()
+	if err != nil {
+		panic(err)
+	}
+
+	saveAuditEntry(&db.AuditEntry{
+		UserID:      sql.NullString{String: authUser.ID, Valid: true},
+		Event:       db.AuditEventTwoFactorDisabled,
+		IPAddress:   ipAddress,
+		Detail:      fmt.Sprintf("two-factor authentication turned off by %s", authUser.Email),
+		DateCreated: now,
+	})
+
+	appContext.Response = MessageResponse{
+		Message: ok,
+	}
+}
+
+// AccountRecoveryCodesCreate = POST: /account/two-factor/recovery-codes
+// Replaces the User's recovery codes with new ones, for when they've used or lost them
+func AccountRecoveryCodesCreate(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	authUser := appContext.AuthUser
+
+	twoFactorCode, valid := decodeTwoFactorCode(appContext, res, req)
+	if !valid {
+		return
+	}
+
+	if !authUser.TwoFactorEnabled {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "Two-factor authentication is not on.",
+		}
+		return
+	}
+
+	if !verifyTwoFactorCode(appContext, res, req, twoFactorCode.Code, time.Now().UTC()) {
+		return
+	}
+
+	codes, hashes := newRecoveryCodes()
+
+	err := authUser.ReplaceRecoveryCodes(hashes)
+	if err != nil {
+		panic(err)
+	}
+
+	appContext.Response = recoveryCodesResponse{
+		Message:       ok,
+		RecoveryCodes: codes,
+	}
+}
+
+// UserTwoFactorReset = DELETE: /users/:userID/two-factor
+// Turns off two-factor authentication for a User who can't log in without it, such as when they've
+// lost their phone and recovery codes, and logs them out everywhere
+func UserTwoFactorReset(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	userID := pat.Param(ctx, "userID")
+
+	// admins only
+	if !appContext.AuthUser.IsAdmin {
+		appContext.Response = MessageResponse{
+			Message: "This resource is only available to users with administrator rights.",
+		}
+		res.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	userDB, err := db.UserFromID(userID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("User not found on ID: %s", userID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	err = userDB.DisableTwoFactor()
+	if err != nil {
+		panic(err)
+	}
+
+	revokeSessions(&userDB, "")
+
+	saveAuditEntry(&db.AuditEntry{
+		UserID:      sql.NullString{String: userDB.ID, Valid: true},
+		ActorID:     sql.NullString{String: appContext.AuthUser.ID, Valid: true},
+		Event:       db.AuditEventTwoFactorReset,
+		IPAddress:   remoteIP(req),
+		Detail:      fmt.Sprintf("two-factor authentication for %s reset by %s", userDB.Email, appContext.AuthUser.Email),
+		DateCreated: time.Now().UTC(),
+	})
+
+	appContext.Response = MessageResponse{
+		Message: ok,
+	}
+}
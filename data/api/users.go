@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"goji.io/pat"
@@ -172,6 +173,8 @@ func UserCreate(ctx context.Context, res http.ResponseWriter, req *http.Request)
 		DateCreated:    time.Now().UTC(),
 		IsAdmin:        newUser.IsAdmin,
 		IsService:      newUser.IsService,
+		// created by an admin, so there's no need to verify the email address
+		IsVerified: true,
 	}
 
 	err = user.Save()
@@ -240,9 +243,16 @@ func UserUpdate(ctx context.Context, res http.ResponseWriter, req *http.Request)
 		return
 	}
 
+	// Users changing their own email address need to verify the new one, admins are trusted as they are with POST: /users
+	emailChanged := !strings.EqualFold(user.Email, updateUser.Email) && !appContext.AuthUser.IsAdmin
+
 	user.Name = updateUser.Name
 	user.Email = updateUser.Email
 
+	if emailChanged {
+		user.IsVerified = false
+	}
+
 	if appContext.AuthUser.IsAdmin {
 		user.IsAdmin = updateUser.IsAdmin
 	}
@@ -262,6 +272,19 @@ func UserUpdate(ctx context.Context, res http.ResponseWriter, req *http.Request)
 		panic(err)
 	}
 
+	if emailChanged {
+		// a password reset sent to the old address would verify the new one
+		err = user.DeleteTokens(db.UserTokenPurposeResetPassword)
+		if err != nil {
+			panic(err)
+		}
+
+		err = sendVerificationEmail(appContext, &user)
+		if err != nil {
+			panic(err)
+		}
+	}
+
 	appContext.Response = MessageResponse{
 		Message: ok,
 	}
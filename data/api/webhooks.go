@@ -0,0 +1,424 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"goji.io/pat"
+
+	"golang.org/x/net/context"
+
+	"github.com/lib/pq"
+	"github.com/sironfoot/go-twitter-bot/data/db"
+	"github.com/sironfoot/go-twitter-bot/data/models"
+)
+
+// maxDeliveries is how many of a Webhook's most recent deliveries are listed
+const maxDeliveries = 50
+
+type webhookResponse struct {
+	ID          string    `json:"id"`
+	URL         string    `json:"url"`
+	Events      []string  `json:"events"`
+	IsEnabled   bool      `json:"isEnabled"`
+	DateCreated time.Time `json:"dateCreated"`
+}
+
+type webhookDelivery struct {
+	ID          string     `json:"id"`
+	Event       string     `json:"event"`
+	Payload     string     `json:"payload"`
+	Attempts    int        `json:"attempts"`
+	IsDelivered bool       `json:"isDelivered"`
+	StatusCode  *int64     `json:"statusCode"`
+	LastError   *string    `json:"lastError"`
+	LastAttempt *time.Time `json:"lastAttempt"`
+	NextAttempt *time.Time `json:"nextAttempt"`
+	DateCreated time.Time  `json:"dateCreated"`
+}
+
+// TwitterAccountWebhooksAll = GET: /twitterAccounts/:twitterAccountID/webhooks
+func TwitterAccountWebhooksAll(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	twitterAccountID := pat.Param(ctx, "twitterAccountID")
+
+	account, err := db.TwitterAccountFromID(twitterAccountID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("TwitterAccount not found on ID: %s", twitterAccountID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if !authorize(appContext, res, &account.TwitterAccount, permissionManage) {
+		return
+	}
+
+	webhooksDB, err := account.GetWebhooks()
+	if err != nil {
+		panic(err)
+	}
+
+	model := struct {
+		MessageResponse
+		Webhooks []webhookResponse `json:"webhooks"`
+	}{}
+
+	model.Message = ok
+	model.Webhooks = make([]webhookResponse, 0)
+
+	for _, hookDB := range webhooksDB {
+		model.Webhooks = append(model.Webhooks, webhookResponse{
+			ID:          hookDB.ID,
+			URL:         hookDB.URL,
+			Events:      hookDB.Events,
+			IsEnabled:   hookDB.IsEnabled,
+			DateCreated: hookDB.DateCreated,
+		})
+	}
+
+	appContext.Response = model
+}
+
+// TwitterAccountWebhookCreate = POST: /twitterAccounts/:twitterAccountID/webhooks
+func TwitterAccountWebhookCreate(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	twitterAccountID := pat.Param(ctx, "twitterAccountID")
+
+	account, err := db.TwitterAccountFromID(twitterAccountID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("TwitterAccount not found on ID: %s", twitterAccountID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if !authorize(appContext, res, &account.TwitterAccount, permissionManage) {
+		return
+	}
+
+	var newWebhook models.Webhook
+
+	defer req.Body.Close()
+	err = json.NewDecoder(io.LimitReader(req.Body, maxRequestLength)).Decode(&newWebhook)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "JSON request body was not in a valid format",
+		}
+		return
+	}
+
+	newWebhook.Sanitise()
+	validationErrors, err := newWebhook.ValidateCreate()
+	if err != nil {
+		panic(err)
+	}
+
+	model := createResponse{}
+
+	if len(validationErrors) > 0 {
+		model.Message = "Webhook model is invalid."
+		model.Errors = validationErrors
+		appContext.Response = model
+
+		res.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	hook := &db.Webhook{
+		AccountID:   account.ID,
+		URL:         newWebhook.URL,
+		Secret:      newWebhook.Secret,
+		Events:      newWebhook.Events,
+		IsEnabled:   newWebhook.IsEnabled,
+		DateCreated: time.Now().UTC(),
+	}
+
+	err = hook.Save()
+	if err != nil {
+		panic(err)
+	}
+
+	model.Message = ok
+	model.ID = &hook.ID
+	res.WriteHeader(http.StatusCreated)
+
+	appContext.Response = model
+}
+
+// TwitterAccountWebhookUpdate = PUT: /twitterAccounts/:twitterAccountID/webhooks/:webhookID
+func TwitterAccountWebhookUpdate(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	twitterAccountID := pat.Param(ctx, "twitterAccountID")
+
+	account, err := db.TwitterAccountFromID(twitterAccountID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("TwitterAccount not found on ID: %s", twitterAccountID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if !authorize(appContext, res, &account.TwitterAccount, permissionManage) {
+		return
+	}
+
+	webhookID := pat.Param(ctx, "webhookID")
+	hook, err := account.GetWebhookFromID(webhookID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("Webhook not found on ID: %s", webhookID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	var updateWebhook models.Webhook
+
+	defer req.Body.Close()
+	err = json.NewDecoder(io.LimitReader(req.Body, maxRequestLength)).Decode(&updateWebhook)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = MessageResponse{
+			Message: "JSON request body was not in a valid format",
+		}
+		return
+	}
+
+	updateWebhook.Sanitise()
+	validationErrors, err := updateWebhook.ValidateUpdate(webhookID)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(validationErrors) > 0 {
+		res.WriteHeader(http.StatusBadRequest)
+		appContext.Response = updateResponse{
+			Message: "Webhook model is invalid.",
+			Errors:  validationErrors,
+		}
+		return
+	}
+
+	hook.URL = updateWebhook.URL
+	hook.Events = updateWebhook.Events
+	hook.IsEnabled = updateWebhook.IsEnabled
+
+	// the secret is never returned by the API, so it's only changed when a new one is given
+	if updateWebhook.Secret != "" {
+		hook.Secret = updateWebhook.Secret
+	}
+
+	err = hook.Save()
+	if err != nil {
+		panic(err)
+	}
+
+	appContext.Response = MessageResponse{
+		Message: ok,
+	}
+}
+
+// TwitterAccountWebhookDelete = DELETE: /twitterAccounts/:twitterAccountID/webhooks/:webhookID
+func TwitterAccountWebhookDelete(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	twitterAccountID := pat.Param(ctx, "twitterAccountID")
+
+	account, err := db.TwitterAccountFromID(twitterAccountID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("TwitterAccount not found on ID: %s", twitterAccountID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if !authorize(appContext, res, &account.TwitterAccount, permissionManage) {
+		return
+	}
+
+	webhookID := pat.Param(ctx, "webhookID")
+	hook, err := account.GetWebhookFromID(webhookID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("Webhook not found on ID: %s", webhookID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	err = hook.Delete()
+	if err != nil {
+		panic(err)
+	}
+
+	appContext.Response = MessageResponse{
+		Message: ok,
+	}
+}
+
+// TwitterAccountWebhookDeliveries = GET: /twitterAccounts/:twitterAccountID/webhooks/:webhookID/deliveries
+func TwitterAccountWebhookDeliveries(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	twitterAccountID := pat.Param(ctx, "twitterAccountID")
+
+	account, err := db.TwitterAccountFromID(twitterAccountID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("TwitterAccount not found on ID: %s", twitterAccountID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if !authorize(appContext, res, &account.TwitterAccount, permissionManage) {
+		return
+	}
+
+	webhookID := pat.Param(ctx, "webhookID")
+	hook, err := account.GetWebhookFromID(webhookID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("Webhook not found on ID: %s", webhookID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	deliveriesDB, err := hook.GetDeliveries(maxDeliveries)
+	if err != nil {
+		panic(err)
+	}
+
+	model := struct {
+		MessageResponse
+		Deliveries []webhookDelivery `json:"deliveries"`
+	}{}
+
+	model.Message = ok
+	model.Deliveries = make([]webhookDelivery, 0)
+
+	for _, deliveryDB := range deliveriesDB {
+		delivery := webhookDelivery{
+			ID:          deliveryDB.ID,
+			Event:       deliveryDB.Event,
+			Payload:     deliveryDB.Payload,
+			Attempts:    deliveryDB.Attempts,
+			IsDelivered: deliveryDB.IsDelivered,
+			DateCreated: deliveryDB.DateCreated,
+		}
+
+		if deliveryDB.StatusCode.Valid {
+			statusCode := deliveryDB.StatusCode.Int64
+			delivery.StatusCode = &statusCode
+		}
+
+		if deliveryDB.LastError.Valid {
+			lastError := deliveryDB.LastError.String
+			delivery.LastError = &lastError
+		}
+
+		if deliveryDB.LastAttempt.Valid {
+			lastAttempt := deliveryDB.LastAttempt.Time
+			delivery.LastAttempt = &lastAttempt
+		}
+
+		if deliveryDB.NextAttempt.Valid {
+			nextAttempt := deliveryDB.NextAttempt.Time
+			delivery.NextAttempt = &nextAttempt
+		}
+
+		model.Deliveries = append(model.Deliveries, delivery)
+	}
+
+	appContext.Response = model
+}
+
+// TwitterAccountWebhookRedeliver = POST: /twitterAccounts/:twitterAccountID/webhooks/:webhookID/deliveries/:deliveryID/redeliver
+func TwitterAccountWebhookRedeliver(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+	appContext := ctx.Value("appContext").(*AppContext)
+	twitterAccountID := pat.Param(ctx, "twitterAccountID")
+
+	account, err := db.TwitterAccountFromID(twitterAccountID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("TwitterAccount not found on ID: %s", twitterAccountID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if !authorize(appContext, res, &account.TwitterAccount, permissionManage) {
+		return
+	}
+
+	webhookID := pat.Param(ctx, "webhookID")
+	hook, err := account.GetWebhookFromID(webhookID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("Webhook not found on ID: %s", webhookID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	deliveryID := pat.Param(ctx, "deliveryID")
+	delivery, err := hook.GetDeliveryFromID(deliveryID)
+	if err == db.ErrEntityNotFound {
+		res.WriteHeader(http.StatusNotFound)
+		appContext.Response = MessageResponse{
+			Message: fmt.Sprintf("WebhookDelivery not found on ID: %s", deliveryID),
+		}
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	// queue a copy of the original payload, so the original delivery's log is kept intact
+	redelivery := &db.WebhookDelivery{
+		WebhookID:   hook.ID,
+		Event:       delivery.Event,
+		Payload:     delivery.Payload,
+		NextAttempt: pq.NullTime{Time: time.Now().UTC(), Valid: true},
+		DateCreated: time.Now().UTC(),
+	}
+
+	err = redelivery.Save()
+	if err != nil {
+		panic(err)
+	}
+
+	model := createResponse{}
+	model.Message = ok
+	model.ID = &redelivery.ID
+	res.WriteHeader(http.StatusCreated)
+
+	appContext.Response = model
+}
@@ -0,0 +1,156 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sironfoot/go-twitter-bot/lib/sqlboiler"
+)
+
+const (
+	// APIKeyScopeRead allows an APIKey to make GET requests
+	APIKeyScopeRead = "read"
+	// APIKeyScopeWrite allows an APIKey to create, update and delete as well
+	APIKeyScopeWrite = "write"
+)
+
+// APIKeyScopes is a list of the scopes an APIKey can have
+var APIKeyScopes = []string{
+	APIKeyScopeRead,
+	APIKeyScopeWrite,
+}
+
+// APIKey maps to api_keys table, a key for a service User to authenticate with instead of logging in.
+// Only a hash of the key is stored, Prefix is the start of the key, for telling keys apart.
+type APIKey struct {
+	ID          string         `db:"id"`
+	UserID      string         `db:"user_id"`
+	Name        string         `db:"name"`
+	Prefix      string         `db:"prefix"`
+	KeyHash     string         `db:"key_hash"`
+	Scopes      pq.StringArray `db:"scopes"`
+	DateCreated time.Time      `db:"date_created"`
+	LastUsed    pq.NullTime    `db:"last_used"`
+	ExpiresOn   pq.NullTime    `db:"expires_on"`
+}
+
+// IsTransient determines if APIKey record has been saved to the database,
+// true means APIKey struct has NOT been saved, false means it has.
+func (apiKey *APIKey) IsTransient() bool {
+	return len(apiKey.ID) == 0
+}
+
+// MetaData returns meta data information about the APIKey entity
+func (apiKey *APIKey) MetaData() sqlboiler.EntityMetaData {
+	return sqlboiler.EntityMetaData{
+		TableName:      "api_keys",
+		PrimaryKeyName: "id",
+	}
+}
+
+// IsExpired determines if the APIKey has an expiry date that has passed at 'now'
+func (apiKey *APIKey) IsExpired(now time.Time) bool {
+	return apiKey.ExpiresOn.Valid && !now.Before(apiKey.ExpiresOn.Time)
+}
+
+// HasScope determines if the APIKey was given 'scope'
+func (apiKey *APIKey) HasScope(scope string) bool {
+	for _, s := range apiKey.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeySave saves the APIKey struct to the database.
+var APIKeySave = func(apiKey *APIKey) error {
+	return sqlboiler.EntitySave(apiKey, dbx)
+}
+
+// Save saves the APIKey struct to the database.
+func (apiKey *APIKey) Save() error {
+	return APIKeySave(apiKey)
+}
+
+// APIKeyDelete deletes the APIKey from the database
+var APIKeyDelete = func(apiKey *APIKey) error {
+	return sqlboiler.EntityDelete(apiKey, dbx)
+}
+
+// Delete deletes the APIKey from the database
+func (apiKey *APIKey) Delete() error {
+	return APIKeyDelete(apiKey)
+}
+
+// APIKeyFromHash returns the APIKey with the hash of a key
+var APIKeyFromHash = func(keyHash string) (APIKey, error) {
+	var apiKey APIKey
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&apiKey, "") + `
+			FROM api_keys
+			WHERE key_hash = $1`
+
+	err := dbx.Get(&apiKey, cmd, keyHash)
+	if err == sql.ErrNoRows {
+		return apiKey, ErrEntityNotFound
+	}
+	return apiKey, err
+}
+
+// APIKeyTouch updates when the APIKey was last used
+var APIKeyTouch = func(apiKey *APIKey, now time.Time) error {
+	_, err := dbx.Exec(`UPDATE api_keys SET last_used = $1 WHERE id = $2`, now, apiKey.ID)
+	if err == nil {
+		apiKey.LastUsed = pq.NullTime{Time: now, Valid: true}
+	}
+	return err
+}
+
+// Touch updates when the APIKey was last used
+func (apiKey *APIKey) Touch(now time.Time) error {
+	return APIKeyTouch(apiKey, now)
+}
+
+// UserGetAPIKeys returns the User's APIKeys, newest first
+var UserGetAPIKeys = func(user *User) ([]APIKey, error) {
+	var apiKeys []APIKey
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&APIKey{}, "") + `
+			FROM api_keys
+			WHERE user_id = $1
+			ORDER BY date_created DESC`
+
+	err := dbx.Select(&apiKeys, cmd, user.ID)
+	return apiKeys, err
+}
+
+// GetAPIKeys returns the User's APIKeys, newest first
+func (user *User) GetAPIKeys() ([]APIKey, error) {
+	return UserGetAPIKeys(user)
+}
+
+// UserGetAPIKeyFromID returns one of the User's APIKeys
+var UserGetAPIKeyFromID = func(user *User, id string) (APIKey, error) {
+	var apiKey APIKey
+
+	if !isUUID.MatchString(id) {
+		return apiKey, ErrEntityNotFound
+	}
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&apiKey, "") + `
+			FROM api_keys
+			WHERE id = $1 AND user_id = $2`
+
+	err := dbx.Get(&apiKey, cmd, id, user.ID)
+	if err == sql.ErrNoRows {
+		return apiKey, ErrEntityNotFound
+	}
+	return apiKey, err
+}
+
+// GetAPIKeyFromID returns one of the User's APIKeys
+func (user *User) GetAPIKeyFromID(id string) (APIKey, error) {
+	return UserGetAPIKeyFromID(user, id)
+}
@@ -0,0 +1,124 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/sironfoot/go-twitter-bot/lib/sqlboiler"
+)
+
+const (
+	// AuditEventLoginLocked is recorded when too many failed logins lock an email address
+	AuditEventLoginLocked = "login_locked"
+	// AuditEventLoginIPBlocked is recorded when too many failed logins block an IP address
+	AuditEventLoginIPBlocked = "login_ip_blocked"
+	// AuditEventLoginUnlocked is recorded when an admin unlocks a User's account
+	AuditEventLoginUnlocked = "login_unlocked"
+	// AuditEventTwoFactorEnabled is recorded when a User turns on two-factor authentication
+	AuditEventTwoFactorEnabled = "two_factor_enabled"
+	// AuditEventTwoFactorDisabled is recorded when a User turns off two-factor authentication
+	AuditEventTwoFactorDisabled = "two_factor_disabled"
+	// AuditEventTwoFactorReset is recorded when an admin turns off two-factor authentication for a User
+	AuditEventTwoFactorReset = "two_factor_reset"
+	// AuditEventRecoveryCodeUsed is recorded when a User logs in with a recovery code
+	AuditEventRecoveryCodeUsed = "recovery_code_used"
+)
+
+// AuditEntry maps to audit_entries table, a security related event. UserID is the User it happened to,
+// and ActorID the User that did it when that's someone else, such as an admin.
+type AuditEntry struct {
+	ID          string         `db:"id"`
+	UserID      sql.NullString `db:"user_id"`
+	ActorID     sql.NullString `db:"actor_id"`
+	Event       string         `db:"event"`
+	IPAddress   string         `db:"ip_address"`
+	Detail      string         `db:"detail"`
+	DateCreated time.Time      `db:"date_created"`
+}
+
+// IsTransient determines if AuditEntry record has been saved to the database,
+// true means AuditEntry struct has NOT been saved, false means it has.
+func (auditEntry *AuditEntry) IsTransient() bool {
+	return len(auditEntry.ID) == 0
+}
+
+// MetaData returns meta data information about the AuditEntry entity
+func (auditEntry *AuditEntry) MetaData() sqlboiler.EntityMetaData {
+	return sqlboiler.EntityMetaData{
+		TableName:      "audit_entries",
+		PrimaryKeyName: "id",
+	}
+}
+
+// AuditEntrySave saves the AuditEntry struct to the database.
+var AuditEntrySave = func(auditEntry *AuditEntry) error {
+	return sqlboiler.EntitySave(auditEntry, dbx)
+}
+
+// Save saves the AuditEntry struct to the database.
+func (auditEntry *AuditEntry) Save() error {
+	return AuditEntrySave(auditEntry)
+}
+
+const (
+	// AuditEntriesOrderByDateCreated is for ordering audit entries by DateCreated
+	AuditEntriesOrderByDateCreated = "date_created"
+	// AuditEntriesOrderByEvent is for ordering audit entries by Event
+	AuditEntriesOrderByEvent = "event"
+)
+
+// AuditEntriesSortableColumns is a list of allowed sortable columns
+var AuditEntriesSortableColumns = []string{
+	AuditEntriesOrderByDateCreated,
+	AuditEntriesOrderByEvent,
+}
+
+// AuditEntriesAll returns all AuditEntry records from the database, or only those for a User when userID isn't empty
+var AuditEntriesAll = func(userID string, query PagingInfo) ([]AuditEntry, int, error) {
+	var auditEntries []AuditEntry
+	recordCount := 0
+
+	builder := sq.
+		Select(sqlboiler.GetFullColumnList(&AuditEntry{}, "")...).
+		From("audit_entries").
+		OrderBy(query.BuildOrderBy()).
+		Limit(uint64(query.Limit())).Offset(uint64(query.Offset()))
+
+	countBuilder := sq.Select("COUNT(*)").From("audit_entries")
+
+	if userID != "" {
+		builder = builder.Where(sq.Eq{"user_id": userID})
+		countBuilder = countBuilder.Where(sq.Eq{"user_id": userID})
+	}
+
+	cmd, args, err := builder.PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return nil, recordCount, err
+	}
+
+	rows, err := dbx.Queryx(cmd, args...)
+	if err != nil {
+		return nil, recordCount, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		auditEntry := AuditEntry{}
+		err = rows.StructScan(&auditEntry)
+		if err != nil {
+			return nil, recordCount, err
+		}
+
+		auditEntries = append(auditEntries, auditEntry)
+	}
+
+	countCmd, countArgs, err := countBuilder.PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return nil, recordCount, err
+	}
+
+	err = dbx.Get(&recordCount, countCmd, countArgs...)
+	return auditEntries, recordCount, err
+}
@@ -0,0 +1,141 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/sironfoot/go-twitter-bot/lib/sqlboiler"
+)
+
+// ErrAccountOwnedByAnotherUser is returned by ImportBotSchedule when the TwitterAccount being
+// imported already exists but belongs to a different User
+var ErrAccountOwnedByAnotherUser = errors.New("db: TwitterAccount belongs to another User")
+
+// ImportTweet is a Tweet to be imported by ImportBotSchedule along with its variants,
+// PostedVariant is the index of the variant that was posted, or -1
+type ImportTweet struct {
+	Tweet
+	Variants      []TweetVariant
+	PostedVariant int
+}
+
+// ImportResult reports what ImportBotSchedule did
+type ImportResult struct {
+	Account        TwitterAccount
+	AccountCreated bool
+	// CredentialsDiffer is true when the TwitterAccount already existed with different
+	// credentials, the existing credentials are kept
+	CredentialsDiffer bool
+	Created           []Tweet
+	// Skipped Tweets already exist with the same text and PostOn, i.e. they were imported before
+	Skipped []Tweet
+	// Conflicted Tweets weren't imported as another Tweet with different text already exists with the
+	// same PostOn, e.g. a Tweet that's been edited since it was imported
+	Conflicted []Tweet
+}
+
+// ImportBotSchedule imports a standalone bot's schedule in a single transaction, creating the TwitterAccount
+// (matched on Username) if it doesn't exist. Running it again with the same Tweets creates nothing new.
+var ImportBotSchedule = func(account TwitterAccount, tweets []ImportTweet) (ImportResult, error) {
+	var result ImportResult
+
+	tx, err := dbx.Beginx()
+	if err != nil {
+		return result, err
+	}
+
+	var existing TwitterAccount
+	err = tx.Get(&existing, `SELECT `+sqlboiler.GetFullColumnListString(&TwitterAccount{}, "")+`
+			FROM twitter_accounts
+			WHERE username = $1
+			FOR UPDATE`, account.Username)
+
+	if err == sql.ErrNoRows {
+		account.DateCreated = time.Now().UTC()
+
+		if err = sqlboiler.EntitySave(&account, tx); err != nil {
+			tx.Rollback()
+			return result, err
+		}
+
+		result.AccountCreated = true
+	} else if err != nil {
+		tx.Rollback()
+		return result, err
+	} else if existing.UserID != account.UserID {
+		tx.Rollback()
+		return result, ErrAccountOwnedByAnotherUser
+	} else {
+		result.CredentialsDiffer = existing.ConsumerKey != account.ConsumerKey ||
+			existing.ConsumerSecret != account.ConsumerSecret ||
+			existing.AccessToken != account.AccessToken ||
+			existing.AccessTokenSecret != account.AccessTokenSecret
+
+		account = existing
+	}
+
+	result.Account = account
+
+	var existingTweets []Tweet
+	err = tx.Select(&existingTweets, `SELECT `+sqlboiler.GetFullColumnListString(&Tweet{}, "")+`
+			FROM tweets
+			WHERE twitter_account_id = $1`, account.ID)
+	if err != nil {
+		tx.Rollback()
+		return result, err
+	}
+
+	for _, importTweet := range tweets {
+		tweet := importTweet.Tweet
+		tweet.AccountID = account.ID
+
+		matched, conflicted := false, false
+		for _, existingTweet := range existingTweets {
+			if !existingTweet.PostOn.Equal(tweet.PostOn) {
+				continue
+			}
+
+			if existingTweet.Tweet == tweet.Tweet {
+				matched = true
+				break
+			}
+			conflicted = true
+		}
+
+		if matched {
+			result.Skipped = append(result.Skipped, tweet)
+			continue
+		} else if conflicted {
+			result.Conflicted = append(result.Conflicted, tweet)
+			continue
+		}
+
+		if err = sqlboiler.EntitySave(&tweet, tx); err != nil {
+			tx.Rollback()
+			return result, err
+		}
+
+		for i, variant := range importTweet.Variants {
+			variant.TweetID = tweet.ID
+
+			if err = sqlboiler.EntitySave(&variant, tx); err != nil {
+				tx.Rollback()
+				return result, err
+			}
+
+			if i == importTweet.PostedVariant {
+				tweet.PostedVariantID = sql.NullString{String: variant.ID, Valid: true}
+
+				if err = sqlboiler.EntitySave(&tweet, tx); err != nil {
+					tx.Rollback()
+					return result, err
+				}
+			}
+		}
+
+		result.Created = append(result.Created, tweet)
+	}
+
+	return result, tx.Commit()
+}
@@ -0,0 +1,195 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sironfoot/go-twitter-bot/lib/sqlboiler"
+)
+
+// EvergreenItem maps to evergreen_items table, a reusable post in a TwitterAccount's
+// evergreen library that is drawn on to fill empty schedule slots
+type EvergreenItem struct {
+	ID            string      `db:"id"`
+	AccountID     string      `db:"twitter_account_id"`
+	Tweet         string      `db:"tweet"`
+	Category      string      `db:"category"`
+	MinRepostDays int         `db:"min_repost_days"`
+	IsPaused      bool        `db:"is_paused"`
+	LastUsed      pq.NullTime `db:"last_used"`
+	TimesUsed     int         `db:"times_used"`
+	DateCreated   time.Time   `db:"date_created"`
+}
+
+// IsTransient determines if EvergreenItem record has been saved to the database,
+// true means EvergreenItem struct has NOT been saved, false means it has.
+func (item *EvergreenItem) IsTransient() bool {
+	return len(item.ID) == 0
+}
+
+// MetaData returns meta data information about the EvergreenItem entity
+func (item *EvergreenItem) MetaData() sqlboiler.EntityMetaData {
+	return sqlboiler.EntityMetaData{
+		TableName:      "evergreen_items",
+		PrimaryKeyName: "id",
+	}
+}
+
+// EvergreenItemSave saves the EvergreenItem struct to the database.
+var EvergreenItemSave = func(item *EvergreenItem) error {
+	return sqlboiler.EntitySave(item, dbx)
+}
+
+// Save saves the EvergreenItem struct to the database.
+func (item *EvergreenItem) Save() error {
+	return EvergreenItemSave(item)
+}
+
+// EvergreenItemDelete deletes the EvergreenItem from the database
+var EvergreenItemDelete = func(item *EvergreenItem) error {
+	return sqlboiler.EntityDelete(item, dbx)
+}
+
+// Delete deletes the EvergreenItem from the database
+func (item *EvergreenItem) Delete() error {
+	return EvergreenItemDelete(item)
+}
+
+// TwitterAccountGetEvergreenItems loads EvergreenItem child entities for TwitterAccount
+var TwitterAccountGetEvergreenItems = func(account *TwitterAccount) ([]EvergreenItem, error) {
+	var items []EvergreenItem
+
+	if account.IsTransient() {
+		return items, nil
+	}
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&EvergreenItem{}, "") + `
+			FROM evergreen_items
+			WHERE twitter_account_id = $1
+			ORDER BY date_created ASC, id ASC`
+
+	err := dbx.Select(&items, cmd, account.ID)
+	return items, err
+}
+
+// GetEvergreenItems loads EvergreenItem child entities for TwitterAccount
+func (account *TwitterAccount) GetEvergreenItems() ([]EvergreenItem, error) {
+	return TwitterAccountGetEvergreenItems(account)
+}
+
+// TwitterAccountGetEvergreenItemFromID gets a TwitterAccount's EvergreenItem by its ID
+var TwitterAccountGetEvergreenItemFromID = func(account *TwitterAccount, itemID string) (EvergreenItem, error) {
+	var item EvergreenItem
+
+	if !isUUID.MatchString(itemID) {
+		return item, ErrEntityNotFound
+	}
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&item, "") + `
+			FROM evergreen_items
+			WHERE twitter_account_id = $1 AND id = $2`
+
+	err := dbx.Get(&item, cmd, account.ID, itemID)
+	if err == sql.ErrNoRows {
+		return item, ErrEntityNotFound
+	}
+	return item, err
+}
+
+// GetEvergreenItemFromID gets this TwitterAccount's EvergreenItem by ID
+func (account *TwitterAccount) GetEvergreenItemFromID(id string) (EvergreenItem, error) {
+	return TwitterAccountGetEvergreenItemFromID(account, id)
+}
+
+// TwitterAccountsWithEvergreenSlots returns all TwitterAccount records that have at least
+// one evergreen slot and one unpaused EvergreenItem to fill it with
+var TwitterAccountsWithEvergreenSlots = func() ([]TwitterAccount, error) {
+	var accounts []TwitterAccount
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&TwitterAccount{}, "ta") + `
+			FROM twitter_accounts ta
+			WHERE cardinality(ta.evergreen_slots) > 0
+				AND EXISTS (
+					SELECT 1 FROM evergreen_items ei
+					WHERE ei.twitter_account_id = ta.id AND ei.is_paused = false
+				)`
+
+	err := dbx.Select(&accounts, cmd)
+	return accounts, err
+}
+
+// TwitterAccountHasTweetBetween determines if the TwitterAccount has a Tweet (excluding drafts)
+// scheduled to post between 'from' and 'to', i.e. whether that part of the schedule is taken
+var TwitterAccountHasTweetBetween = func(account *TwitterAccount, from, to time.Time) (bool, error) {
+	exists := false
+
+	cmd := `SELECT EXISTS (
+				SELECT 1 FROM tweets
+				WHERE twitter_account_id = $1 AND is_draft = false AND post_on BETWEEN $2 AND $3
+			)`
+
+	err := dbx.Get(&exists, cmd, account.ID, from, to)
+	return exists, err
+}
+
+// HasTweetBetween determines if this TwitterAccount has a Tweet scheduled between 'from' and 'to'
+func (account *TwitterAccount) HasTweetBetween(from, to time.Time) (bool, error) {
+	return TwitterAccountHasTweetBetween(account, from, to)
+}
+
+// EvergreenItemUse maps to evergreen_item_uses table, the history of when an
+// EvergreenItem was used to fill a schedule slot and the Tweet it created
+type EvergreenItemUse struct {
+	ID              string         `db:"id"`
+	EvergreenItemID string         `db:"evergreen_item_id"`
+	TweetID         sql.NullString `db:"tweet_id"`
+	UsedFor         time.Time      `db:"used_for"`
+	DateCreated     time.Time      `db:"date_created"`
+}
+
+// IsTransient determines if EvergreenItemUse record has been saved to the database,
+// true means EvergreenItemUse struct has NOT been saved, false means it has.
+func (use *EvergreenItemUse) IsTransient() bool {
+	return len(use.ID) == 0
+}
+
+// MetaData returns meta data information about the EvergreenItemUse entity
+func (use *EvergreenItemUse) MetaData() sqlboiler.EntityMetaData {
+	return sqlboiler.EntityMetaData{
+		TableName:      "evergreen_item_uses",
+		PrimaryKeyName: "id",
+	}
+}
+
+// EvergreenItemUseSave saves the EvergreenItemUse struct to the database.
+var EvergreenItemUseSave = func(use *EvergreenItemUse) error {
+	return sqlboiler.EntitySave(use, dbx)
+}
+
+// Save saves the EvergreenItemUse struct to the database.
+func (use *EvergreenItemUse) Save() error {
+	return EvergreenItemUseSave(use)
+}
+
+// EvergreenItemGetUses loads the usage history for an EvergreenItem, most recent first
+var EvergreenItemGetUses = func(item *EvergreenItem) ([]EvergreenItemUse, error) {
+	var uses []EvergreenItemUse
+
+	if item.IsTransient() {
+		return uses, nil
+	}
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&EvergreenItemUse{}, "") + `
+			FROM evergreen_item_uses
+			WHERE evergreen_item_id = $1
+			ORDER BY used_for DESC`
+
+	err := dbx.Select(&uses, cmd, item.ID)
+	return uses, err
+}
+
+// GetUses loads the usage history for this EvergreenItem, most recent first
+func (item *EvergreenItem) GetUses() ([]EvergreenItemUse, error) {
+	return EvergreenItemGetUses(item)
+}
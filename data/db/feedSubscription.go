@@ -0,0 +1,188 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sironfoot/go-twitter-bot/lib/sqlboiler"
+)
+
+// FeedSubscription maps to feed_subscriptions table. IsSeeded is set once the items already in the feed
+// have been recorded without tweeting them, by the first successful poll of its URL.
+type FeedSubscription struct {
+	ID                  string      `db:"id"`
+	AccountID           string      `db:"twitter_account_id"`
+	URL                 string      `db:"url"`
+	PollIntervalMinutes int         `db:"poll_interval_minutes"`
+	Template            string      `db:"template"`
+	CreateAsDraft       bool        `db:"create_as_draft"`
+	LastPolled          pq.NullTime `db:"last_polled"`
+	IsSeeded            bool        `db:"is_seeded"`
+	DateCreated         time.Time   `db:"date_created"`
+}
+
+// IsTransient determines if FeedSubscription record has been saved to the database,
+// true means FeedSubscription struct has NOT been saved, false means it has.
+func (subscription *FeedSubscription) IsTransient() bool {
+	return len(subscription.ID) == 0
+}
+
+// MetaData returns meta data information about the FeedSubscription entity
+func (subscription *FeedSubscription) MetaData() sqlboiler.EntityMetaData {
+	return sqlboiler.EntityMetaData{
+		TableName:      "feed_subscriptions",
+		PrimaryKeyName: "id",
+	}
+}
+
+// FeedSubscriptionSave saves the FeedSubscription struct to the database.
+var FeedSubscriptionSave = func(subscription *FeedSubscription) error {
+	return sqlboiler.EntitySave(subscription, dbx)
+}
+
+// Save saves the FeedSubscription struct to the database.
+func (subscription *FeedSubscription) Save() error {
+	return FeedSubscriptionSave(subscription)
+}
+
+// FeedSubscriptionDelete deletes the FeedSubscription from the database
+var FeedSubscriptionDelete = func(subscription *FeedSubscription) error {
+	return sqlboiler.EntityDelete(subscription, dbx)
+}
+
+// Delete deletes the FeedSubscription from the database
+func (subscription *FeedSubscription) Delete() error {
+	return FeedSubscriptionDelete(subscription)
+}
+
+// TwitterAccountGetFeedSubscriptions loads FeedSubscription child entities for TwitterAccount
+var TwitterAccountGetFeedSubscriptions = func(account *TwitterAccount) ([]FeedSubscription, error) {
+	var subscriptions []FeedSubscription
+
+	if account.IsTransient() {
+		return subscriptions, nil
+	}
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&FeedSubscription{}, "") + `
+			FROM feed_subscriptions
+			WHERE twitter_account_id = $1
+			ORDER BY date_created ASC`
+
+	err := dbx.Select(&subscriptions, cmd, account.ID)
+	return subscriptions, err
+}
+
+// GetFeedSubscriptions loads FeedSubscription child entities for TwitterAccount
+func (account *TwitterAccount) GetFeedSubscriptions() ([]FeedSubscription, error) {
+	return TwitterAccountGetFeedSubscriptions(account)
+}
+
+// TwitterAccountGetFeedSubscriptionFromID gets a TwitterAccount's FeedSubscription by its ID
+var TwitterAccountGetFeedSubscriptionFromID = func(account *TwitterAccount, subscriptionID string) (FeedSubscription, error) {
+	var subscription FeedSubscription
+
+	if !isUUID.MatchString(subscriptionID) {
+		return subscription, ErrEntityNotFound
+	}
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&subscription, "") + `
+			FROM feed_subscriptions
+			WHERE twitter_account_id = $1 AND id = $2`
+
+	err := dbx.Get(&subscription, cmd, account.ID, subscriptionID)
+	if err == sql.ErrNoRows {
+		return subscription, ErrEntityNotFound
+	}
+	return subscription, err
+}
+
+// GetFeedSubscriptionFromID gets this TwitterAccount's FeedSubscription by ID
+func (account *TwitterAccount) GetFeedSubscriptionFromID(id string) (FeedSubscription, error) {
+	return TwitterAccountGetFeedSubscriptionFromID(account, id)
+}
+
+// FeedSubscriptionsDue returns all FeedSubscription records that have never been
+// polled, or were last polled more than their PollIntervalMinutes before 'now'
+var FeedSubscriptionsDue = func(now time.Time) ([]FeedSubscription, error) {
+	var subscriptions []FeedSubscription
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&FeedSubscription{}, "") + `
+			FROM feed_subscriptions
+			WHERE last_polled IS NULL
+				OR last_polled + (poll_interval_minutes * INTERVAL '1 minute') <= $1
+			ORDER BY last_polled ASC NULLS FIRST`
+
+	err := dbx.Select(&subscriptions, cmd, now)
+	return subscriptions, err
+}
+
+// FeedSubscriptionSetPolled records that the FeedSubscription was polled at 'now', and that it's been seeded if the
+// poll succeeded. Only those columns are updated, and only while the URL is still the one that was polled, so changes
+// made while it was being polled aren't overwritten and a new URL is seeded by a poll of its own.
+var FeedSubscriptionSetPolled = func(subscription *FeedSubscription, now time.Time, seeded bool) error {
+	_, err := dbx.Exec(`UPDATE feed_subscriptions SET last_polled = $1, is_seeded = is_seeded OR $2
+			WHERE id = $3 AND url = $4`, now, seeded, subscription.ID, subscription.URL)
+	if err == nil {
+		subscription.LastPolled = pq.NullTime{Time: now, Valid: true}
+		subscription.IsSeeded = subscription.IsSeeded || seeded
+	}
+	return err
+}
+
+// SetPolled records that the FeedSubscription was polled, see FeedSubscriptionSetPolled
+func (subscription *FeedSubscription) SetPolled(now time.Time, seeded bool) error {
+	return FeedSubscriptionSetPolled(subscription, now, seeded)
+}
+
+// FeedItem maps to feed_items table, it records each feed entry that has been
+// seen for a FeedSubscription so the same entry is never tweeted twice
+type FeedItem struct {
+	ID                 string         `db:"id"`
+	FeedSubscriptionID string         `db:"feed_subscription_id"`
+	GUID               string         `db:"guid"`
+	TweetID            sql.NullString `db:"tweet_id"`
+	DateCreated        time.Time      `db:"date_created"`
+}
+
+// IsTransient determines if FeedItem record has been saved to the database,
+// true means FeedItem struct has NOT been saved, false means it has.
+func (item *FeedItem) IsTransient() bool {
+	return len(item.ID) == 0
+}
+
+// MetaData returns meta data information about the FeedItem entity
+func (item *FeedItem) MetaData() sqlboiler.EntityMetaData {
+	return sqlboiler.EntityMetaData{
+		TableName:      "feed_items",
+		PrimaryKeyName: "id",
+	}
+}
+
+// FeedItemSave saves the FeedItem struct to the database.
+var FeedItemSave = func(item *FeedItem) error {
+	return sqlboiler.EntitySave(item, dbx)
+}
+
+// Save saves the FeedItem struct to the database.
+func (item *FeedItem) Save() error {
+	return FeedItemSave(item)
+}
+
+// FeedSubscriptionHasItem determines if an item with the given GUID has already been seen for the FeedSubscription
+var FeedSubscriptionHasItem = func(subscription *FeedSubscription, guid string) (bool, error) {
+	exists := false
+
+	cmd := `SELECT EXISTS (
+				SELECT 1 FROM feed_items
+				WHERE feed_subscription_id = $1 AND guid = $2
+			)`
+
+	err := dbx.Get(&exists, cmd, subscription.ID, guid)
+	return exists, err
+}
+
+// HasItem determines if an item with the given GUID has already been seen for this FeedSubscription
+func (subscription *FeedSubscription) HasItem(guid string) (bool, error) {
+	return FeedSubscriptionHasItem(subscription, guid)
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sironfoot/go-twitter-bot/lib/sqlboiler"
+)
+
+// LoginFailure maps to login_failures table, a failed login attempt for an email address from an IP address.
+// The email address is recorded whether or not there's a User for it, so lockouts don't reveal which exist.
+type LoginFailure struct {
+	ID          string    `db:"id"`
+	Email       string    `db:"email"`
+	IPAddress   string    `db:"ip_address"`
+	DateCreated time.Time `db:"date_created"`
+}
+
+// LoginFailures is the number of failed logins since a point in time, and when the latest one was
+type LoginFailures struct {
+	Count  int         `db:"count"`
+	Latest pq.NullTime `db:"latest"`
+}
+
+// IsTransient determines if LoginFailure record has been saved to the database,
+// true means LoginFailure struct has NOT been saved, false means it has.
+func (loginFailure *LoginFailure) IsTransient() bool {
+	return len(loginFailure.ID) == 0
+}
+
+// MetaData returns meta data information about the LoginFailure entity
+func (loginFailure *LoginFailure) MetaData() sqlboiler.EntityMetaData {
+	return sqlboiler.EntityMetaData{
+		TableName:      "login_failures",
+		PrimaryKeyName: "id",
+	}
+}
+
+// LoginFailureSave saves the LoginFailure struct to the database.
+var LoginFailureSave = func(loginFailure *LoginFailure) error {
+	return sqlboiler.EntitySave(loginFailure, dbx)
+}
+
+// Save saves the LoginFailure struct to the database.
+func (loginFailure *LoginFailure) Save() error {
+	return LoginFailureSave(loginFailure)
+}
+
+// LoginFailuresForEmail returns the failed logins for an email address since 'since'
+var LoginFailuresForEmail = func(email string, since time.Time) (LoginFailures, error) {
+	var failures LoginFailures
+
+	cmd := `SELECT COUNT(*) AS count, MAX(date_created) AS latest
+			FROM login_failures
+			WHERE email = $1 AND date_created > $2`
+
+	err := dbx.Get(&failures, cmd, email, since)
+	return failures, err
+}
+
+// LoginFailuresForIPAddress returns the failed logins from an IP address since 'since', for any email address
+var LoginFailuresForIPAddress = func(ipAddress string, since time.Time) (LoginFailures, error) {
+	var failures LoginFailures
+
+	cmd := `SELECT COUNT(*) AS count, MAX(date_created) AS latest
+			FROM login_failures
+			WHERE ip_address = $1 AND date_created > $2`
+
+	err := dbx.Get(&failures, cmd, ipAddress, since)
+	return failures, err
+}
+
+// LoginFailuresDeleteForEmail deletes the failed logins for an email address, unlocking it
+var LoginFailuresDeleteForEmail = func(email string) error {
+	_, err := dbx.Exec(`DELETE FROM login_failures WHERE email = $1`, email)
+	return err
+}
+
+// LoginFailuresDeleteBefore deletes failed logins older than 'before', which no longer count towards a lockout
+var LoginFailuresDeleteBefore = func(before time.Time) error {
+	_, err := dbx.Exec(`DELETE FROM login_failures WHERE date_created < $1`, before)
+	return err
+}
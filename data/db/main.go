@@ -65,3 +65,9 @@ func (paging PagingInfo) BuildOrderBy() string {
 var ErrEntityNotFound = errors.New("db: Entity not found")
 
 var isUUID = regexp.MustCompile(`(?i)^[a-f0-9]{8}\-[a-f0-9]{4}\-[a-f0-9]{4}\-[a-f0-9]{4}\-[a-f0-9]{12}$`)
+
+// Notify sends a Postgres NOTIFY with payload on channel, to be received by any connection LISTENing to it
+var Notify = func(channel, payload string) error {
+	_, err := dbx.Exec(`SELECT pg_notify($1, $2)`, channel, payload)
+	return err
+}
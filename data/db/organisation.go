@@ -0,0 +1,253 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/sironfoot/go-twitter-bot/lib/sqlboiler"
+)
+
+// Organisation maps to organisations table, a team of Users sharing TwitterAccounts. Members have one of the
+// TwitterAccountRoles, which applies to all of the Organisation's TwitterAccounts, owners also manage the team.
+type Organisation struct {
+	ID          string    `db:"id"`
+	Name        string    `db:"name"`
+	DateCreated time.Time `db:"date_created"`
+}
+
+// OrganisationMember maps to organisation_members table, a User's membership of an Organisation
+type OrganisationMember struct {
+	ID             string    `db:"id"`
+	OrganisationID string    `db:"organisation_id"`
+	UserID         string    `db:"user_id"`
+	Role           string    `db:"role"`
+	DateCreated    time.Time `db:"date_created"`
+}
+
+// OrganisationMemberWithUser is an OrganisationMember with the name and email address of its User, for listing
+type OrganisationMemberWithUser struct {
+	OrganisationMember
+	UserName  string `db:"user_name"`
+	UserEmail string `db:"user_email"`
+}
+
+// IsTransient determines if Organisation record has been saved to the database,
+// true means Organisation struct has NOT been saved, false means it has.
+func (organisation *Organisation) IsTransient() bool {
+	return len(organisation.ID) == 0
+}
+
+// MetaData returns meta data information about the Organisation entity
+func (organisation *Organisation) MetaData() sqlboiler.EntityMetaData {
+	return sqlboiler.EntityMetaData{
+		TableName:      "organisations",
+		PrimaryKeyName: "id",
+	}
+}
+
+// IsTransient determines if OrganisationMember record has been saved to the database,
+// true means OrganisationMember struct has NOT been saved, false means it has.
+func (member *OrganisationMember) IsTransient() bool {
+	return len(member.ID) == 0
+}
+
+// MetaData returns meta data information about the OrganisationMember entity
+func (member *OrganisationMember) MetaData() sqlboiler.EntityMetaData {
+	return sqlboiler.EntityMetaData{
+		TableName:      "organisation_members",
+		PrimaryKeyName: "id",
+	}
+}
+
+// OrganisationCreate saves a new Organisation with 'owner' as its first member, an owner
+var OrganisationCreate = func(organisation *Organisation, owner *User) error {
+	tx, err := dbx.Beginx()
+	if err != nil {
+		return err
+	}
+
+	if err = sqlboiler.EntitySave(organisation, tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	member := OrganisationMember{
+		OrganisationID: organisation.ID,
+		UserID:         owner.ID,
+		Role:           TwitterAccountRoleOwner,
+		DateCreated:    organisation.DateCreated,
+	}
+
+	if err = sqlboiler.EntitySave(&member, tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// OrganisationSave saves the Organisation struct to the database.
+var OrganisationSave = func(organisation *Organisation) error {
+	return sqlboiler.EntitySave(organisation, dbx)
+}
+
+// Save saves the Organisation struct to the database.
+func (organisation *Organisation) Save() error {
+	return OrganisationSave(organisation)
+}
+
+// OrganisationDelete deletes the Organisation from the database, its TwitterAccounts become personal
+// TwitterAccounts of the Users that added them, or are deleted when those Users have been deleted
+var OrganisationDelete = func(organisation *Organisation) error {
+	tx, err := dbx.Beginx()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM twitter_accounts WHERE organisation_id = $1 AND user_id IS NULL`, organisation.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err = sqlboiler.EntityDelete(organisation, tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Delete deletes the Organisation from the database
+func (organisation *Organisation) Delete() error {
+	return OrganisationDelete(organisation)
+}
+
+// OrganisationFromID returns an Organisation record with given ID
+var OrganisationFromID = func(id string) (Organisation, error) {
+	var organisation Organisation
+
+	if !isUUID.MatchString(id) {
+		return organisation, ErrEntityNotFound
+	}
+
+	err := sqlboiler.EntityGetByID(&organisation, id, dbx)
+	if err == sqlboiler.ErrEntityNotFound {
+		return organisation, ErrEntityNotFound
+	}
+	return organisation, err
+}
+
+// OrganisationsAll returns all Organisations by name, or only those a User is a member of when memberUserID isn't empty
+var OrganisationsAll = func(memberUserID string) ([]Organisation, error) {
+	var organisations []Organisation
+
+	builder := sq.
+		Select(sqlboiler.GetFullColumnList(&Organisation{}, "o")...).
+		From("organisations o").
+		OrderBy("o.name")
+
+	if memberUserID != "" {
+		builder = builder.Where("EXISTS (SELECT 1 FROM organisation_members m WHERE m.organisation_id = o.id AND m.user_id = ?)", memberUserID)
+	}
+
+	cmd, args, err := builder.PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	err = dbx.Select(&organisations, cmd, args...)
+	return organisations, err
+}
+
+// OrganisationMemberSave saves the OrganisationMember struct to the database.
+var OrganisationMemberSave = func(member *OrganisationMember) error {
+	return sqlboiler.EntitySave(member, dbx)
+}
+
+// Save saves the OrganisationMember struct to the database.
+func (member *OrganisationMember) Save() error {
+	return OrganisationMemberSave(member)
+}
+
+// OrganisationMemberDelete deletes the OrganisationMember from the database
+var OrganisationMemberDelete = func(member *OrganisationMember) error {
+	return sqlboiler.EntityDelete(member, dbx)
+}
+
+// Delete deletes the OrganisationMember from the database
+func (member *OrganisationMember) Delete() error {
+	return OrganisationMemberDelete(member)
+}
+
+// OrganisationGetMember returns a User's membership of the Organisation
+var OrganisationGetMember = func(organisation *Organisation, userID string) (OrganisationMember, error) {
+	var member OrganisationMember
+
+	if !isUUID.MatchString(userID) {
+		return member, ErrEntityNotFound
+	}
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&member, "") + `
+			FROM organisation_members
+			WHERE organisation_id = $1 AND user_id = $2`
+
+	err := dbx.Get(&member, cmd, organisation.ID, userID)
+	if err == sql.ErrNoRows {
+		return member, ErrEntityNotFound
+	}
+	return member, err
+}
+
+// GetMember returns a User's membership of the Organisation
+func (organisation *Organisation) GetMember(userID string) (OrganisationMember, error) {
+	return OrganisationGetMember(organisation, userID)
+}
+
+// OrganisationGetMembers returns the Organisation's members, with their Users' details
+var OrganisationGetMembers = func(organisation *Organisation) ([]OrganisationMemberWithUser, error) {
+	var members []OrganisationMemberWithUser
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&OrganisationMember{}, "m") + `, u.name AS user_name, u.email AS user_email
+			FROM organisation_members m
+			INNER JOIN users u ON m.user_id = u.id
+			WHERE m.organisation_id = $1
+			ORDER BY m.date_created`
+
+	err := dbx.Select(&members, cmd, organisation.ID)
+	return members, err
+}
+
+// GetMembers returns the Organisation's members, with their Users' details
+func (organisation *Organisation) GetMembers() ([]OrganisationMemberWithUser, error) {
+	return OrganisationGetMembers(organisation)
+}
+
+// OrganisationCountOwners returns how many owners the Organisation has
+var OrganisationCountOwners = func(organisation *Organisation) (int, error) {
+	count := 0
+	err := dbx.Get(&count, `SELECT COUNT(*) FROM organisation_members WHERE organisation_id = $1 AND role = $2`,
+		organisation.ID, TwitterAccountRoleOwner)
+	return count, err
+}
+
+// CountOwners returns how many owners the Organisation has
+func (organisation *Organisation) CountOwners() (int, error) {
+	return OrganisationCountOwners(organisation)
+}
+
+// TwitterAccountSetOrganisation moves the TwitterAccount to an Organisation, or makes it
+// a personal TwitterAccount again when organisationID isn't valid
+var TwitterAccountSetOrganisation = func(account *TwitterAccount, organisationID sql.NullString) error {
+	_, err := dbx.Exec(`UPDATE twitter_accounts SET organisation_id = $1 WHERE id = $2`, organisationID, account.ID)
+	if err == nil {
+		account.OrganisationID = organisationID
+	}
+	return err
+}
+
+// SetOrganisation moves the TwitterAccount to an Organisation, see TwitterAccountSetOrganisation
+func (account *TwitterAccount) SetOrganisation(organisationID sql.NullString) error {
+	return TwitterAccountSetOrganisation(account, organisationID)
+}
@@ -0,0 +1,204 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sironfoot/go-twitter-bot/lib/sqlboiler"
+)
+
+// ErrInvitationResponded is returned by OrganisationInvitationAccept and OrganisationInvitationDecline
+// when the OrganisationInvitation has already been accepted or declined
+var ErrInvitationResponded = errors.New("db: OrganisationInvitation has already been responded to")
+
+// OrganisationInvitation maps to organisation_invitations table, an invitation for whoever has
+// an email address to join an Organisation with a Role
+type OrganisationInvitation struct {
+	ID             string         `db:"id"`
+	OrganisationID string         `db:"organisation_id"`
+	Email          string         `db:"email"`
+	Role           string         `db:"role"`
+	TokenHash      string         `db:"token_hash"`
+	InvitedBy      sql.NullString `db:"invited_by"`
+	DateCreated    time.Time      `db:"date_created"`
+	ExpiresOn      time.Time      `db:"expires_on"`
+	DateResponded  pq.NullTime    `db:"date_responded"`
+	IsAccepted     bool           `db:"is_accepted"`
+}
+
+// OrganisationInvitationWithName is an OrganisationInvitation with the name of its Organisation, for listing
+type OrganisationInvitationWithName struct {
+	OrganisationInvitation
+	OrganisationName string `db:"organisation_name"`
+}
+
+// IsTransient determines if OrganisationInvitation record has been saved to the database,
+// true means OrganisationInvitation struct has NOT been saved, false means it has.
+func (invitation *OrganisationInvitation) IsTransient() bool {
+	return len(invitation.ID) == 0
+}
+
+// MetaData returns meta data information about the OrganisationInvitation entity
+func (invitation *OrganisationInvitation) MetaData() sqlboiler.EntityMetaData {
+	return sqlboiler.EntityMetaData{
+		TableName:      "organisation_invitations",
+		PrimaryKeyName: "id",
+	}
+}
+
+// IsPending determines if the OrganisationInvitation can still be accepted or declined at 'now'
+func (invitation *OrganisationInvitation) IsPending(now time.Time) bool {
+	return !invitation.DateResponded.Valid && now.Before(invitation.ExpiresOn)
+}
+
+// OrganisationInvitationSave saves the OrganisationInvitation struct to the database.
+var OrganisationInvitationSave = func(invitation *OrganisationInvitation) error {
+	return sqlboiler.EntitySave(invitation, dbx)
+}
+
+// Save saves the OrganisationInvitation struct to the database.
+func (invitation *OrganisationInvitation) Save() error {
+	return OrganisationInvitationSave(invitation)
+}
+
+// OrganisationInvitationDelete deletes the OrganisationInvitation from the database
+var OrganisationInvitationDelete = func(invitation *OrganisationInvitation) error {
+	return sqlboiler.EntityDelete(invitation, dbx)
+}
+
+// Delete deletes the OrganisationInvitation from the database
+func (invitation *OrganisationInvitation) Delete() error {
+	return OrganisationInvitationDelete(invitation)
+}
+
+// OrganisationInvitationFromID returns an OrganisationInvitation record with given ID
+var OrganisationInvitationFromID = func(id string) (OrganisationInvitation, error) {
+	var invitation OrganisationInvitation
+
+	if !isUUID.MatchString(id) {
+		return invitation, ErrEntityNotFound
+	}
+
+	err := sqlboiler.EntityGetByID(&invitation, id, dbx)
+	if err == sqlboiler.ErrEntityNotFound {
+		return invitation, ErrEntityNotFound
+	}
+	return invitation, err
+}
+
+// OrganisationGetInvitations returns the Organisation's OrganisationInvitations that haven't been responded to, newest first
+var OrganisationGetInvitations = func(organisation *Organisation) ([]OrganisationInvitation, error) {
+	var invitations []OrganisationInvitation
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&OrganisationInvitation{}, "") + `
+			FROM organisation_invitations
+			WHERE organisation_id = $1 AND date_responded IS NULL
+			ORDER BY date_created DESC`
+
+	err := dbx.Select(&invitations, cmd, organisation.ID)
+	return invitations, err
+}
+
+// GetInvitations returns the Organisation's OrganisationInvitations that haven't been responded to, newest first
+func (organisation *Organisation) GetInvitations() ([]OrganisationInvitation, error) {
+	return OrganisationGetInvitations(organisation)
+}
+
+// UserGetInvitations returns the pending OrganisationInvitations for the User's email address, newest first
+var UserGetInvitations = func(user *User) ([]OrganisationInvitationWithName, error) {
+	var invitations []OrganisationInvitationWithName
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&OrganisationInvitation{}, "i") + `, o.name AS organisation_name
+			FROM organisation_invitations i
+			INNER JOIN organisations o ON i.organisation_id = o.id
+			WHERE LOWER(i.email) = LOWER($1) AND i.date_responded IS NULL AND i.expires_on > $2
+			ORDER BY i.date_created DESC`
+
+	err := dbx.Select(&invitations, cmd, user.Email, time.Now().UTC())
+	return invitations, err
+}
+
+// GetInvitations returns the pending OrganisationInvitations for the User's email address, newest first
+func (user *User) GetInvitations() ([]OrganisationInvitationWithName, error) {
+	return UserGetInvitations(user)
+}
+
+// OrganisationInvitationAccept accepts the OrganisationInvitation for the User, making them a member of the
+// Organisation with its Role. Members keep their Role unless the invitation's has more access, so accepting
+// never demotes anyone, including the Organisation's last owner.
+var OrganisationInvitationAccept = func(invitation *OrganisationInvitation, user *User) error {
+	tx, err := dbx.Beginx()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	result, err := tx.Exec(`UPDATE organisation_invitations SET date_responded = $1, is_accepted = true
+			WHERE id = $2 AND date_responded IS NULL`, now, invitation.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return err
+	} else if updated == 0 {
+		tx.Rollback()
+		return ErrInvitationResponded
+	}
+
+	// TwitterAccountRoles runs from most to least access
+	_, err = tx.Exec(`INSERT INTO organisation_members (organisation_id, user_id, role, date_created)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (organisation_id, user_id) DO UPDATE SET role = EXCLUDED.role
+			WHERE array_position($5::TEXT[], EXCLUDED.role) < array_position($5::TEXT[], organisation_members.role)`,
+		invitation.OrganisationID, user.ID, invitation.Role, now, pq.StringArray(TwitterAccountRoles))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	invitation.DateResponded = pq.NullTime{Time: now, Valid: true}
+	invitation.IsAccepted = true
+	return nil
+}
+
+// Accept accepts the OrganisationInvitation for the User, see OrganisationInvitationAccept
+func (invitation *OrganisationInvitation) Accept(user *User) error {
+	return OrganisationInvitationAccept(invitation, user)
+}
+
+// OrganisationInvitationDecline declines the OrganisationInvitation
+var OrganisationInvitationDecline = func(invitation *OrganisationInvitation) error {
+	now := time.Now().UTC()
+
+	result, err := dbx.Exec(`UPDATE organisation_invitations SET date_responded = $1, is_accepted = false
+			WHERE id = $2 AND date_responded IS NULL`, now, invitation.ID)
+	if err != nil {
+		return err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	} else if updated == 0 {
+		return ErrInvitationResponded
+	}
+
+	invitation.DateResponded = pq.NullTime{Time: now, Valid: true}
+	return nil
+}
+
+// Decline declines the OrganisationInvitation
+func (invitation *OrganisationInvitation) Decline() error {
+	return OrganisationInvitationDecline(invitation)
+}
@@ -0,0 +1,191 @@
+package db
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/sironfoot/go-twitter-bot/lib/sqlboiler"
+)
+
+// RecoveryCode maps to recovery_codes table, a single use code for logging in without a two-factor code,
+// such as when a User loses their phone. Only a hash of the code is stored.
+type RecoveryCode struct {
+	ID          string      `db:"id"`
+	UserID      string      `db:"user_id"`
+	CodeHash    string      `db:"code_hash"`
+	DateCreated time.Time   `db:"date_created"`
+	DateUsed    pq.NullTime `db:"date_used"`
+}
+
+// IsTransient determines if RecoveryCode record has been saved to the database,
+// true means RecoveryCode struct has NOT been saved, false means it has.
+func (recoveryCode *RecoveryCode) IsTransient() bool {
+	return len(recoveryCode.ID) == 0
+}
+
+// MetaData returns meta data information about the RecoveryCode entity
+func (recoveryCode *RecoveryCode) MetaData() sqlboiler.EntityMetaData {
+	return sqlboiler.EntityMetaData{
+		TableName:      "recovery_codes",
+		PrimaryKeyName: "id",
+	}
+}
+
+// UserEnableTwoFactor turns on two-factor authentication for the User with their TwoFactorSecret, recording
+// 'step' as the last code used, and replaces their RecoveryCodes with new ones for 'codeHashes'
+var UserEnableTwoFactor = func(user *User, step int64, codeHashes []string) error {
+	tx, err := dbx.Beginx()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE users SET two_factor_enabled = true, two_factor_last_step = $1 WHERE id = $2`, step, user.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err = replaceRecoveryCodes(tx, user, codeHashes); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	user.TwoFactorEnabled = true
+	user.TwoFactorLastStep = step
+	return nil
+}
+
+// EnableTwoFactor turns on two-factor authentication for the User, see UserEnableTwoFactor
+func (user *User) EnableTwoFactor(step int64, codeHashes []string) error {
+	return UserEnableTwoFactor(user, step, codeHashes)
+}
+
+// UserDisableTwoFactor turns off two-factor authentication for the User, removing their secret and RecoveryCodes
+var UserDisableTwoFactor = func(user *User) error {
+	tx, err := dbx.Beginx()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE users SET two_factor_enabled = false, two_factor_secret = NULL, two_factor_last_step = 0 WHERE id = $1`, user.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err = tx.Exec(`DELETE FROM recovery_codes WHERE user_id = $1`, user.ID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	user.TwoFactorEnabled = false
+	user.TwoFactorSecret.Valid = false
+	user.TwoFactorLastStep = 0
+	return nil
+}
+
+// DisableTwoFactor turns off two-factor authentication for the User, see UserDisableTwoFactor
+func (user *User) DisableTwoFactor() error {
+	return UserDisableTwoFactor(user)
+}
+
+// UserUseTwoFactorStep records 'step' as the User's last used two-factor code, returning false if it
+// isn't later than the last one, so each code can only be used once
+var UserUseTwoFactorStep = func(user *User, step int64) (bool, error) {
+	result, err := dbx.Exec(`UPDATE users SET two_factor_last_step = $1 WHERE id = $2 AND two_factor_last_step < $1`, step, user.ID)
+	if err != nil {
+		return false, err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil || updated == 0 {
+		return false, err
+	}
+
+	user.TwoFactorLastStep = step
+	return true, nil
+}
+
+// UseTwoFactorStep records 'step' as the User's last used two-factor code, see UserUseTwoFactorStep
+func (user *User) UseTwoFactorStep(step int64) (bool, error) {
+	return UserUseTwoFactorStep(user, step)
+}
+
+// UserReplaceRecoveryCodes replaces the User's RecoveryCodes with new ones for 'codeHashes'
+var UserReplaceRecoveryCodes = func(user *User, codeHashes []string) error {
+	tx, err := dbx.Beginx()
+	if err != nil {
+		return err
+	}
+
+	if err = replaceRecoveryCodes(tx, user, codeHashes); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ReplaceRecoveryCodes replaces the User's RecoveryCodes with new ones for 'codeHashes'
+func (user *User) ReplaceRecoveryCodes(codeHashes []string) error {
+	return UserReplaceRecoveryCodes(user, codeHashes)
+}
+
+func replaceRecoveryCodes(tx *sqlx.Tx, user *User, codeHashes []string) error {
+	if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id = $1`, user.ID); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	for _, codeHash := range codeHashes {
+		recoveryCode := RecoveryCode{
+			UserID:      user.ID,
+			CodeHash:    codeHash,
+			DateCreated: now,
+		}
+
+		if err := sqlboiler.EntitySave(&recoveryCode, tx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UserUseRecoveryCode marks the User's unused RecoveryCode with 'codeHash' as used, returning false if there isn't one
+var UserUseRecoveryCode = func(user *User, codeHash string) (bool, error) {
+	result, err := dbx.Exec(`UPDATE recovery_codes SET date_used = $1 WHERE user_id = $2 AND code_hash = $3 AND date_used IS NULL`,
+		time.Now().UTC(), user.ID, codeHash)
+	if err != nil {
+		return false, err
+	}
+
+	updated, err := result.RowsAffected()
+	return err == nil && updated > 0, err
+}
+
+// UseRecoveryCode marks the User's unused RecoveryCode with 'codeHash' as used, see UserUseRecoveryCode
+func (user *User) UseRecoveryCode(codeHash string) (bool, error) {
+	return UserUseRecoveryCode(user, codeHash)
+}
+
+// UserCountRecoveryCodes returns how many of the User's RecoveryCodes haven't been used
+var UserCountRecoveryCodes = func(user *User) (int, error) {
+	count := 0
+	err := dbx.Get(&count, `SELECT COUNT(*) FROM recovery_codes WHERE user_id = $1 AND date_used IS NULL`, user.ID)
+	return count, err
+}
+
+// CountRecoveryCodes returns how many of the User's RecoveryCodes haven't been used
+func (user *User) CountRecoveryCodes() (int, error) {
+	return UserCountRecoveryCodes(user)
+}
@@ -0,0 +1,108 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sironfoot/go-twitter-bot/lib/sqlboiler"
+)
+
+// ErrRefreshTokenUsed is returned by RefreshTokenRotate when the RefreshToken has already been used
+var ErrRefreshTokenUsed = errors.New("db: RefreshToken has already been used")
+
+// RefreshToken maps to refresh_tokens table. A Session's RefreshTokens are a family, each one can be used
+// once to get a new access token and the next RefreshToken. Only a hash of the token is stored.
+type RefreshToken struct {
+	ID          string      `db:"id"`
+	SessionID   string      `db:"session_id"`
+	TokenHash   string      `db:"token_hash"`
+	DateCreated time.Time   `db:"date_created"`
+	DateUsed    pq.NullTime `db:"date_used"`
+}
+
+// IsTransient determines if RefreshToken record has been saved to the database,
+// true means RefreshToken struct has NOT been saved, false means it has.
+func (refreshToken *RefreshToken) IsTransient() bool {
+	return len(refreshToken.ID) == 0
+}
+
+// MetaData returns meta data information about the RefreshToken entity
+func (refreshToken *RefreshToken) MetaData() sqlboiler.EntityMetaData {
+	return sqlboiler.EntityMetaData{
+		TableName:      "refresh_tokens",
+		PrimaryKeyName: "id",
+	}
+}
+
+// RefreshTokenSave saves the RefreshToken struct to the database.
+var RefreshTokenSave = func(refreshToken *RefreshToken) error {
+	return sqlboiler.EntitySave(refreshToken, dbx)
+}
+
+// Save saves the RefreshToken struct to the database.
+func (refreshToken *RefreshToken) Save() error {
+	return RefreshTokenSave(refreshToken)
+}
+
+// RefreshTokenFromHash gets a RefreshToken by the hash of the token
+var RefreshTokenFromHash = func(tokenHash string) (RefreshToken, error) {
+	var refreshToken RefreshToken
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&refreshToken, "") + `
+			FROM refresh_tokens
+			WHERE token_hash = $1`
+
+	err := dbx.Get(&refreshToken, cmd, tokenHash)
+	if err == sql.ErrNoRows {
+		return refreshToken, ErrEntityNotFound
+	}
+	return refreshToken, err
+}
+
+// RefreshTokenRotate marks 'used' as used and saves 'next' in its place, sliding the Session's expiry on to
+// 'expiresOn', in a single transaction. Returns ErrRefreshTokenUsed if 'used' was used first by another request.
+var RefreshTokenRotate = func(used *RefreshToken, next *RefreshToken, session *Session, expiresOn time.Time) error {
+	tx, err := dbx.Beginx()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	result, err := tx.Exec(`UPDATE refresh_tokens SET date_used = $1 WHERE id = $2 AND date_used IS NULL`, now, used.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return err
+	} else if updated == 0 {
+		tx.Rollback()
+		return ErrRefreshTokenUsed
+	}
+
+	next.SessionID = session.ID
+	if err = sqlboiler.EntitySave(next, tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err = tx.Exec(`UPDATE sessions SET expires_on = $1, last_seen = $2 WHERE id = $3`, expiresOn, now, session.ID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	used.DateUsed = pq.NullTime{Time: now, Valid: true}
+	session.ExpiresOn = expiresOn
+	session.LastSeen = now
+	return nil
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/sironfoot/go-twitter-bot/lib/sqlboiler"
+)
+
+// Reply maps to replies table, it records each automatic reply made to a mention
+type Reply struct {
+	ID              string         `db:"id"`
+	AccountID       string         `db:"twitter_account_id"`
+	ReplyRuleID     sql.NullString `db:"reply_rule_id"`
+	MentionID       string         `db:"mention_id"`
+	MentionUsername string         `db:"mention_username"`
+	ReplyID         string         `db:"reply_id"`
+	DateCreated     time.Time      `db:"date_created"`
+}
+
+// IsTransient determines if Reply record has been saved to the database,
+// true means Reply struct has NOT been saved, false means it has.
+func (reply *Reply) IsTransient() bool {
+	return len(reply.ID) == 0
+}
+
+// MetaData returns meta data information about the Reply entity
+func (reply *Reply) MetaData() sqlboiler.EntityMetaData {
+	return sqlboiler.EntityMetaData{
+		TableName:      "replies",
+		PrimaryKeyName: "id",
+	}
+}
+
+// ReplySave saves the Reply struct to the database.
+var ReplySave = func(reply *Reply) error {
+	return sqlboiler.EntitySave(reply, dbx)
+}
+
+// Save saves the Reply struct to the database.
+func (reply *Reply) Save() error {
+	return ReplySave(reply)
+}
+
+// RepliesCountSince returns the number of replies made by all TwitterAccounts since a given time
+var RepliesCountSince = func(since time.Time) (int, error) {
+	count := 0
+	err := dbx.Get(&count, `SELECT COUNT(*) FROM replies WHERE date_created > $1`, since)
+	return count, err
+}
+
+// TwitterAccountLastReplyTo returns the most recent Reply a TwitterAccount made to a Twitter username
+var TwitterAccountLastReplyTo = func(account *TwitterAccount, username string) (Reply, error) {
+	var reply Reply
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&reply, "") + `
+			FROM replies
+			WHERE twitter_account_id = $1 AND LOWER(mention_username) = LOWER($2)
+			ORDER BY date_created DESC
+			LIMIT 1`
+
+	err := dbx.Get(&reply, cmd, account.ID, username)
+	if err == sql.ErrNoRows {
+		return reply, ErrEntityNotFound
+	}
+	return reply, err
+}
+
+// LastReplyTo returns the most recent Reply this TwitterAccount made to a Twitter username
+func (account *TwitterAccount) LastReplyTo(username string) (Reply, error) {
+	return TwitterAccountLastReplyTo(account, username)
+}
@@ -0,0 +1,131 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/sironfoot/go-twitter-bot/lib/sqlboiler"
+)
+
+const (
+	// ReplyRuleMatchKeyword matches mentions containing the Pattern as a whole word (case insensitive)
+	ReplyRuleMatchKeyword = "keyword"
+	// ReplyRuleMatchRegex matches mentions where the Pattern regular expression matches the text
+	ReplyRuleMatchRegex = "regex"
+	// ReplyRuleMatchFromUser matches mentions sent by the Twitter username in Pattern
+	ReplyRuleMatchFromUser = "from_user"
+)
+
+// ReplyRuleMatchTypes is a list of allowed ReplyRule MatchType values
+var ReplyRuleMatchTypes = []string{
+	ReplyRuleMatchKeyword,
+	ReplyRuleMatchRegex,
+	ReplyRuleMatchFromUser,
+}
+
+// ReplyRule maps to reply_rules table
+type ReplyRule struct {
+	ID              string    `db:"id"`
+	AccountID       string    `db:"twitter_account_id"`
+	MatchType       string    `db:"match_type"`
+	Pattern         string    `db:"pattern"`
+	ReplyTemplate   string    `db:"reply_template"`
+	CooldownMinutes int       `db:"cooldown_minutes"`
+	IsEnabled       bool      `db:"is_enabled"`
+	DateCreated     time.Time `db:"date_created"`
+}
+
+// IsTransient determines if ReplyRule record has been saved to the database,
+// true means ReplyRule struct has NOT been saved, false means it has.
+func (rule *ReplyRule) IsTransient() bool {
+	return len(rule.ID) == 0
+}
+
+// MetaData returns meta data information about the ReplyRule entity
+func (rule *ReplyRule) MetaData() sqlboiler.EntityMetaData {
+	return sqlboiler.EntityMetaData{
+		TableName:      "reply_rules",
+		PrimaryKeyName: "id",
+	}
+}
+
+// ReplyRuleSave saves the ReplyRule struct to the database.
+var ReplyRuleSave = func(rule *ReplyRule) error {
+	return sqlboiler.EntitySave(rule, dbx)
+}
+
+// Save saves the ReplyRule struct to the database.
+func (rule *ReplyRule) Save() error {
+	return ReplyRuleSave(rule)
+}
+
+// ReplyRuleDelete deletes the ReplyRule from the database
+var ReplyRuleDelete = func(rule *ReplyRule) error {
+	return sqlboiler.EntityDelete(rule, dbx)
+}
+
+// Delete deletes the ReplyRule from the database
+func (rule *ReplyRule) Delete() error {
+	return ReplyRuleDelete(rule)
+}
+
+// TwitterAccountGetReplyRules loads ReplyRule child entities for TwitterAccount, in the order they were created
+var TwitterAccountGetReplyRules = func(account *TwitterAccount) ([]ReplyRule, error) {
+	var rules []ReplyRule
+
+	if account.IsTransient() {
+		return rules, nil
+	}
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&ReplyRule{}, "") + `
+			FROM reply_rules
+			WHERE twitter_account_id = $1
+			ORDER BY date_created ASC`
+
+	err := dbx.Select(&rules, cmd, account.ID)
+	return rules, err
+}
+
+// GetReplyRules loads ReplyRule child entities for TwitterAccount
+func (account *TwitterAccount) GetReplyRules() ([]ReplyRule, error) {
+	return TwitterAccountGetReplyRules(account)
+}
+
+// TwitterAccountGetReplyRuleFromID gets a TwitterAccount's ReplyRule by its ID
+var TwitterAccountGetReplyRuleFromID = func(account *TwitterAccount, ruleID string) (ReplyRule, error) {
+	var rule ReplyRule
+
+	if !isUUID.MatchString(ruleID) {
+		return rule, ErrEntityNotFound
+	}
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&rule, "") + `
+			FROM reply_rules
+			WHERE twitter_account_id = $1 AND id = $2`
+
+	err := dbx.Get(&rule, cmd, account.ID, ruleID)
+	if err == sql.ErrNoRows {
+		return rule, ErrEntityNotFound
+	}
+	return rule, err
+}
+
+// GetReplyRuleFromID gets this TwitterAccount's ReplyRule by ID
+func (account *TwitterAccount) GetReplyRuleFromID(id string) (ReplyRule, error) {
+	return TwitterAccountGetReplyRuleFromID(account, id)
+}
+
+// TwitterAccountsWithReplyRules returns all TwitterAccount records that have at least one enabled ReplyRule
+var TwitterAccountsWithReplyRules = func() ([]TwitterAccount, error) {
+	var accounts []TwitterAccount
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&TwitterAccount{}, "ta") + `
+			FROM twitter_accounts ta
+			WHERE EXISTS (
+				SELECT 1 FROM reply_rules rr
+				WHERE rr.twitter_account_id = ta.id AND rr.is_enabled = true
+			)`
+
+	err := dbx.Select(&accounts, cmd)
+	return accounts, err
+}
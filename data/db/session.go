@@ -0,0 +1,163 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/sironfoot/go-twitter-bot/lib/sqlboiler"
+)
+
+// Session maps to sessions table, a login on one of a User's devices. Revoking a Session deletes it,
+// and the access tokens issued for it stop working.
+type Session struct {
+	ID          string    `db:"id"`
+	UserID      string    `db:"user_id"`
+	UserAgent   string    `db:"user_agent"`
+	IPAddress   string    `db:"ip_address"`
+	DateCreated time.Time `db:"date_created"`
+	LastSeen    time.Time `db:"last_seen"`
+	ExpiresOn   time.Time `db:"expires_on"`
+}
+
+// IsTransient determines if Session record has been saved to the database,
+// true means Session struct has NOT been saved, false means it has.
+func (session *Session) IsTransient() bool {
+	return len(session.ID) == 0
+}
+
+// MetaData returns meta data information about the Session entity
+func (session *Session) MetaData() sqlboiler.EntityMetaData {
+	return sqlboiler.EntityMetaData{
+		TableName:      "sessions",
+		PrimaryKeyName: "id",
+	}
+}
+
+// IsExpired determines if the Session has expired at 'now'
+func (session *Session) IsExpired(now time.Time) bool {
+	return !now.Before(session.ExpiresOn)
+}
+
+// SessionSave saves the Session struct to the database.
+var SessionSave = func(session *Session) error {
+	return sqlboiler.EntitySave(session, dbx)
+}
+
+// Save saves the Session struct to the database.
+func (session *Session) Save() error {
+	return SessionSave(session)
+}
+
+// SessionDelete deletes the Session from the database
+var SessionDelete = func(session *Session) error {
+	return sqlboiler.EntityDelete(session, dbx)
+}
+
+// Delete deletes the Session from the database
+func (session *Session) Delete() error {
+	return SessionDelete(session)
+}
+
+// SessionFromID gets a Session by its ID
+var SessionFromID = func(id string) (Session, error) {
+	var session Session
+
+	if !isUUID.MatchString(id) {
+		return session, ErrEntityNotFound
+	}
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&session, "") + `
+			FROM sessions
+			WHERE id = $1`
+
+	err := dbx.Get(&session, cmd, id)
+	if err == sql.ErrNoRows {
+		return session, ErrEntityNotFound
+	}
+	return session, err
+}
+
+// SessionTouch updates when the Session was last used
+var SessionTouch = func(session *Session, now time.Time) error {
+	_, err := dbx.Exec(`UPDATE sessions SET last_seen = $1 WHERE id = $2`, now, session.ID)
+	if err == nil {
+		session.LastSeen = now
+	}
+	return err
+}
+
+// Touch updates when the Session was last used
+func (session *Session) Touch(now time.Time) error {
+	return SessionTouch(session, now)
+}
+
+// UserGetSessions loads the User's unexpired Sessions, most recently used first
+var UserGetSessions = func(user *User) ([]Session, error) {
+	var sessions []Session
+
+	if user.IsTransient() {
+		return sessions, nil
+	}
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&Session{}, "") + `
+			FROM sessions
+			WHERE user_id = $1 AND expires_on > $2
+			ORDER BY last_seen DESC`
+
+	err := dbx.Select(&sessions, cmd, user.ID, time.Now().UTC())
+	return sessions, err
+}
+
+// GetSessions loads the User's unexpired Sessions, most recently used first
+func (user *User) GetSessions() ([]Session, error) {
+	return UserGetSessions(user)
+}
+
+// UserGetSessionFromID gets one of the User's Sessions by its ID
+var UserGetSessionFromID = func(user *User, sessionID string) (Session, error) {
+	var session Session
+
+	if !isUUID.MatchString(sessionID) {
+		return session, ErrEntityNotFound
+	}
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&session, "") + `
+			FROM sessions
+			WHERE user_id = $1 AND id = $2`
+
+	err := dbx.Get(&session, cmd, user.ID, sessionID)
+	if err == sql.ErrNoRows {
+		return session, ErrEntityNotFound
+	}
+	return session, err
+}
+
+// GetSessionFromID gets one of the User's Sessions by its ID
+func (user *User) GetSessionFromID(id string) (Session, error) {
+	return UserGetSessionFromID(user, id)
+}
+
+// UserDeleteSessions deletes all of the User's Sessions apart from 'exceptID' (blank deletes them all),
+// returning how many were deleted
+var UserDeleteSessions = func(user *User, exceptID string) (int, error) {
+	cmd := `DELETE FROM sessions WHERE user_id = $1`
+	args := []interface{}{user.ID}
+
+	if exceptID != "" {
+		cmd += ` AND id <> $2`
+		args = append(args, exceptID)
+	}
+
+	result, err := dbx.Exec(cmd, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := result.RowsAffected()
+	return int(deleted), err
+}
+
+// DeleteSessions deletes all of the User's Sessions apart from 'exceptID' (blank deletes them all)
+func (user *User) DeleteSessions(exceptID string) (int, error) {
+	return UserDeleteSessions(user, exceptID)
+}
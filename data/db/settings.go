@@ -0,0 +1,42 @@
+package db
+
+import "database/sql"
+
+// Settings maps to the single row settings table, server wide settings changed by admins
+type Settings struct {
+	SignupEnabled bool `db:"signup_enabled"`
+	// RequireAdminTwoFactor stops admins using the API until they've turned on two-factor authentication
+	RequireAdminTwoFactor bool `db:"require_admin_two_factor"`
+}
+
+// DefaultSettings are used when the settings table has no row
+var DefaultSettings = Settings{
+	SignupEnabled: true,
+}
+
+// SettingsGet returns the server wide Settings
+var SettingsGet = func() (Settings, error) {
+	settings := DefaultSettings
+
+	err := dbx.Get(&settings, `SELECT signup_enabled, require_admin_two_factor FROM settings WHERE id = 1`)
+	if err == sql.ErrNoRows {
+		return DefaultSettings, nil
+	}
+	return settings, err
+}
+
+// SettingsSave saves the server wide Settings
+var SettingsSave = func(settings *Settings) error {
+	_, err := dbx.Exec(`INSERT INTO settings (id, signup_enabled, require_admin_two_factor)
+			VALUES (1, $1, $2)
+			ON CONFLICT (id) DO UPDATE SET
+				signup_enabled = EXCLUDED.signup_enabled,
+				require_admin_two_factor = EXCLUDED.require_admin_two_factor`,
+		settings.SignupEnabled, settings.RequireAdminTwoFactor)
+	return err
+}
+
+// Save saves the server wide Settings
+func (settings *Settings) Save() error {
+	return SettingsSave(settings)
+}
@@ -0,0 +1,118 @@
+package db
+
+// TwitterAccountGetTemplateVariables returns the user defined variables available to the TwitterAccount's Tweet templates
+var TwitterAccountGetTemplateVariables = func(account *TwitterAccount) (map[string]string, error) {
+	variables := make(map[string]string)
+
+	rows, err := dbx.Queryx(`SELECT name, value FROM template_variables WHERE twitter_account_id = $1`, account.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, value string
+		if err = rows.Scan(&name, &value); err != nil {
+			return nil, err
+		}
+
+		variables[name] = value
+	}
+
+	return variables, rows.Err()
+}
+
+// GetTemplateVariables returns the user defined variables available to this TwitterAccount's Tweet templates
+func (account *TwitterAccount) GetTemplateVariables() (map[string]string, error) {
+	return TwitterAccountGetTemplateVariables(account)
+}
+
+// TwitterAccountSetTemplateVariables replaces all of the TwitterAccount's template variables
+var TwitterAccountSetTemplateVariables = func(account *TwitterAccount, variables map[string]string) error {
+	tx, err := dbx.Beginx()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM template_variables WHERE twitter_account_id = $1`, account.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for name, value := range variables {
+		_, err = tx.Exec(`INSERT INTO template_variables(twitter_account_id, name, value) VALUES($1, $2, $3)`,
+			account.ID, name, value)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SetTemplateVariables replaces all of this TwitterAccount's template variables
+func (account *TwitterAccount) SetTemplateVariables(variables map[string]string) error {
+	return TwitterAccountSetTemplateVariables(account, variables)
+}
+
+// TwitterAccountGetTemplateCounters returns the current value of the TwitterAccount's template counters
+var TwitterAccountGetTemplateCounters = func(account *TwitterAccount) (map[string]int, error) {
+	counters := make(map[string]int)
+
+	rows, err := dbx.Queryx(`SELECT name, value FROM template_counters WHERE twitter_account_id = $1`, account.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		var value int
+		if err = rows.Scan(&name, &value); err != nil {
+			return nil, err
+		}
+
+		counters[name] = value
+	}
+
+	return counters, rows.Err()
+}
+
+// GetTemplateCounters returns the current value of this TwitterAccount's template counters
+func (account *TwitterAccount) GetTemplateCounters() (map[string]int, error) {
+	return TwitterAccountGetTemplateCounters(account)
+}
+
+// TwitterAccountSetTemplateCounters replaces all of the TwitterAccount's template counters
+var TwitterAccountSetTemplateCounters = func(account *TwitterAccount, counters map[string]int) error {
+	tx, err := dbx.Beginx()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM template_counters WHERE twitter_account_id = $1`, account.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for name, value := range counters {
+		_, err = tx.Exec(`INSERT INTO template_counters(twitter_account_id, name, value) VALUES($1, $2, $3)`,
+			account.ID, name, value)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SetTemplateCounters replaces all of this TwitterAccount's template counters
+func (account *TwitterAccount) SetTemplateCounters(counters map[string]int) error {
+	return TwitterAccountSetTemplateCounters(account, counters)
+}
@@ -1,19 +1,31 @@
 package db
 
 import (
+	"database/sql"
+	"sort"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/sironfoot/go-twitter-bot/lib/sqlboiler"
 )
 
-// Tweet maps to tweets table
+// Tweet maps to tweets table. ClaimedUntil is set while a worker is posting the Tweet, see TweetsClaimDue.
 type Tweet struct {
-	ID          string    `db:"id"`
-	AccountID   string    `db:"twitter_account_id"`
-	Tweet       string    `db:"tweet"`
-	PostOn      time.Time `db:"post_on"`
-	IsPosted    bool      `db:"is_posted"`
-	DateCreated time.Time `db:"date_created"`
+	ID               string         `db:"id"`
+	AccountID        string         `db:"twitter_account_id"`
+	Tweet            string         `db:"tweet"`
+	PostOn           time.Time      `db:"post_on"`
+	IsPosted         bool           `db:"is_posted"`
+	IsDraft          bool           `db:"is_draft"`
+	VariantSelection string         `db:"variant_selection"`
+	PostedVariantID  sql.NullString `db:"posted_variant_id"`
+	StatusID         sql.NullString `db:"status_id"`
+	Attempts         int            `db:"attempts"`
+	LastError        sql.NullString `db:"last_error"`
+	RetryAfter       pq.NullTime    `db:"retry_after"`
+	ClaimedUntil     pq.NullTime    `db:"claimed_until"`
+	Tags             pq.StringArray `db:"tags"`
+	DateCreated      time.Time      `db:"date_created"`
 }
 
 // IsTransient determines if Tweet record has been saved to the database,
@@ -40,6 +52,25 @@ func (tweet *Tweet) Save() error {
 	return TweetSave(tweet)
 }
 
+// TweetsSaveAll saves many Tweet structs to the database in a single transaction,
+// if any fail to save then none are saved
+var TweetsSaveAll = func(tweets []Tweet) error {
+	tx, err := dbx.Beginx()
+	if err != nil {
+		return err
+	}
+
+	for i := range tweets {
+		err = sqlboiler.EntitySave(&tweets[i], tx)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
 // TweetDelete deletes the Tweet from the database
 var TweetDelete = func(tweet *Tweet) error {
 	return sqlboiler.EntityDelete(tweet, dbx)
@@ -49,3 +80,94 @@ var TweetDelete = func(tweet *Tweet) error {
 func (tweet *Tweet) Delete() error {
 	return TweetDelete(tweet)
 }
+
+// TweetsClaimDue claims all Tweets across all TwitterAccounts that are due to be posted at 'now', i.e. not posted,
+// not drafts, PostOn has passed, not waiting to retry a failure and not claimed by another worker, oldest first.
+// They stay claimed until 'until', so other workers don't post them too, unless released by TweetReleaseClaim.
+var TweetsClaimDue = func(now, until time.Time) ([]Tweet, error) {
+	var tweets []Tweet
+
+	cmd := `UPDATE tweets SET claimed_until = $2
+			WHERE id IN (
+				SELECT id FROM tweets
+				WHERE is_posted = false AND is_draft = false AND post_on <= $1
+					AND (retry_after IS NULL OR retry_after <= $1)
+					AND (claimed_until IS NULL OR claimed_until <= $1)
+				FOR UPDATE SKIP LOCKED)
+			RETURNING ` + sqlboiler.GetFullColumnListString(&Tweet{}, "")
+
+	err := dbx.Select(&tweets, cmd, now, until)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(tweets, func(i, j int) bool {
+		return tweets[i].PostOn.Before(tweets[j].PostOn)
+	})
+	return tweets, nil
+}
+
+// TweetReleaseClaim releases a Tweet claimed by TweetsClaimDue without posting it, so it can be claimed again
+var TweetReleaseClaim = func(tweet *Tweet) error {
+	_, err := dbx.Exec(`UPDATE tweets SET claimed_until = NULL WHERE id = $1`, tweet.ID)
+	if err == nil {
+		tweet.ClaimedUntil = pq.NullTime{}
+	}
+	return err
+}
+
+// ReleaseClaim releases a Tweet claimed by TweetsClaimDue without posting it
+func (tweet *Tweet) ReleaseClaim() error {
+	return TweetReleaseClaim(tweet)
+}
+
+// TweetApprove approves a draft Tweet so it will be posted, without touching any other column
+var TweetApprove = func(tweet *Tweet) error {
+	_, err := dbx.Exec(`UPDATE tweets SET is_draft = false WHERE id = $1`, tweet.ID)
+	if err == nil {
+		tweet.IsDraft = false
+	}
+	return err
+}
+
+// Approve approves a draft Tweet so it will be posted
+func (tweet *Tweet) Approve() error {
+	return TweetApprove(tweet)
+}
+
+// TwitterAccountGetRecentTweets returns the TwitterAccount's Tweets that are still to be
+// posted (including drafts) along with those posted since 'since', for duplicate checks
+var TwitterAccountGetRecentTweets = func(account *TwitterAccount, since time.Time) ([]Tweet, error) {
+	var tweets []Tweet
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&Tweet{}, "") + `
+			FROM tweets
+			WHERE twitter_account_id = $1 AND (is_posted = false OR post_on >= $2)
+			ORDER BY post_on DESC`
+
+	err := dbx.Select(&tweets, cmd, account.ID, since)
+	return tweets, err
+}
+
+// GetRecentTweets returns this TwitterAccount's pending Tweets and those posted since 'since'
+func (account *TwitterAccount) GetRecentTweets(since time.Time) ([]Tweet, error) {
+	return TwitterAccountGetRecentTweets(account, since)
+}
+
+// TwitterAccountGetAllTweets returns all of the TwitterAccount's Tweets, in the order they're to be posted
+var TwitterAccountGetAllTweets = func(account *TwitterAccount) ([]Tweet, error) {
+	var tweets []Tweet
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&Tweet{}, "") + `
+			FROM tweets
+			WHERE twitter_account_id = $1
+			ORDER BY post_on ASC`
+
+	err := dbx.Select(&tweets, cmd, account.ID)
+	return tweets, err
+}
+
+// GetAllTweets returns all of this TwitterAccount's Tweets, in the order they're to be posted
+func (account *TwitterAccount) GetAllTweets() ([]Tweet, error) {
+	return TwitterAccountGetAllTweets(account)
+}
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sironfoot/go-twitter-bot/lib/sqlboiler"
+)
+
+// TweetVariant maps to tweet_variants table, an alternative text for a Tweet
+type TweetVariant struct {
+	ID          string    `db:"id"`
+	TweetID     string    `db:"tweet_id"`
+	Tweet       string    `db:"tweet"`
+	Weight      int       `db:"weight"`
+	TimesPosted int       `db:"times_posted"`
+	DateCreated time.Time `db:"date_created"`
+}
+
+// IsTransient determines if TweetVariant record has been saved to the database,
+// true means TweetVariant struct has NOT been saved, false means it has.
+func (variant *TweetVariant) IsTransient() bool {
+	return len(variant.ID) == 0
+}
+
+// MetaData returns meta data information about the TweetVariant entity
+func (variant *TweetVariant) MetaData() sqlboiler.EntityMetaData {
+	return sqlboiler.EntityMetaData{
+		TableName:      "tweet_variants",
+		PrimaryKeyName: "id",
+	}
+}
+
+// TweetVariantSave saves the TweetVariant struct to the database.
+var TweetVariantSave = func(variant *TweetVariant) error {
+	return sqlboiler.EntitySave(variant, dbx)
+}
+
+// Save saves the TweetVariant struct to the database.
+func (variant *TweetVariant) Save() error {
+	return TweetVariantSave(variant)
+}
+
+// TweetVariantDelete deletes the TweetVariant from the database
+var TweetVariantDelete = func(variant *TweetVariant) error {
+	return sqlboiler.EntityDelete(variant, dbx)
+}
+
+// Delete deletes the TweetVariant from the database
+func (variant *TweetVariant) Delete() error {
+	return TweetVariantDelete(variant)
+}
+
+// TweetGetVariants loads TweetVariant child entities for Tweet, in the order they were created
+var TweetGetVariants = func(tweet *Tweet) ([]TweetVariant, error) {
+	var variants []TweetVariant
+
+	if tweet.IsTransient() {
+		return variants, nil
+	}
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&TweetVariant{}, "") + `
+			FROM tweet_variants
+			WHERE tweet_id = $1
+			ORDER BY date_created ASC, id ASC`
+
+	err := dbx.Select(&variants, cmd, tweet.ID)
+	return variants, err
+}
+
+// GetVariants loads TweetVariant child entities for Tweet
+func (tweet *Tweet) GetVariants() ([]TweetVariant, error) {
+	return TweetGetVariants(tweet)
+}
+
+// TweetsGetVariants loads TweetVariant child entities for many Tweets at once, keyed on Tweet ID
+var TweetsGetVariants = func(tweets []Tweet) (map[string][]TweetVariant, error) {
+	variants := make(map[string][]TweetVariant)
+
+	var tweetIDs []string
+	for _, tweet := range tweets {
+		tweetIDs = append(tweetIDs, tweet.ID)
+	}
+
+	if len(tweetIDs) == 0 {
+		return variants, nil
+	}
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&TweetVariant{}, "") + `
+			FROM tweet_variants
+			WHERE tweet_id = ANY($1::uuid[])
+			ORDER BY date_created ASC, id ASC`
+
+	var rows []TweetVariant
+	err := dbx.Select(&rows, cmd, pq.Array(tweetIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, variant := range rows {
+		variants[variant.TweetID] = append(variants[variant.TweetID], variant)
+	}
+
+	return variants, nil
+}
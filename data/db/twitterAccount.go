@@ -5,19 +5,54 @@ import (
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
 	"github.com/sironfoot/go-twitter-bot/lib/sqlboiler"
 )
 
-// TwitterAccount maps to twitter_accounts table
+const (
+	// DuplicatePolicyOff doesn't check new or edited Tweets for duplicate content
+	DuplicatePolicyOff = "off"
+	// DuplicatePolicyWarn saves Tweets with duplicate content but returns a warning
+	DuplicatePolicyWarn = "warn"
+	// DuplicatePolicyReject refuses to save Tweets with duplicate content
+	DuplicatePolicyReject = "reject"
+)
+
+// DuplicatePolicies is a list of allowed TwitterAccount DuplicatePolicy values
+var DuplicatePolicies = []string{
+	DuplicatePolicyOff,
+	DuplicatePolicyWarn,
+	DuplicatePolicyReject,
+}
+
+// TwitterAccount maps to twitter_accounts table. UserID is the User that added it, and OrganisationID
+// the Organisation that owns it, whose members share it, or NULL for a personal TwitterAccount. An
+// Organisation's TwitterAccount outlives the User that added it, leaving UserID NULL.
 type TwitterAccount struct {
-	ID                string    `db:"id"`
-	UserID            string    `db:"user_id"`
-	Username          string    `db:"username"`
-	DateCreated       time.Time `db:"date_created"`
-	ConsumerKey       string    `db:"consumer_key"`
-	ConsumerSecret    string    `db:"consumer_secret"`
-	AccessToken       string    `db:"access_token"`
-	AccessTokenSecret string    `db:"access_token_secret"`
+	ID                    string         `db:"id"`
+	UserID                sql.NullString `db:"user_id"`
+	OrganisationID        sql.NullString `db:"organisation_id"`
+	Username              string         `db:"username"`
+	DateCreated           time.Time      `db:"date_created"`
+	ConsumerKey           string         `db:"consumer_key"`
+	ConsumerSecret        string         `db:"consumer_secret"`
+	AccessToken           string         `db:"access_token"`
+	AccessTokenSecret     string         `db:"access_token_secret"`
+	MentionsSinceID       sql.NullString `db:"mentions_since_id"`
+	TimeZone              string         `db:"time_zone"`
+	EvergreenSlots        pq.StringArray `db:"evergreen_slots"`
+	EvergreenNoRepeatDays int            `db:"evergreen_no_repeat_days"`
+	DuplicatePolicy       string         `db:"duplicate_policy"`
+	DuplicateWindowDays   int            `db:"duplicate_window_days"`
+}
+
+// Location returns the time.Location for the TwitterAccount's TimeZone, or UTC if it isn't set
+func (account *TwitterAccount) Location() (*time.Location, error) {
+	if account.TimeZone == "" {
+		return time.UTC, nil
+	}
+
+	return time.LoadLocation(account.TimeZone)
 }
 
 // IsTransient determines if TwitterAccount record has been saved to the database,
@@ -54,6 +89,21 @@ func (account *TwitterAccount) Delete() error {
 	return TwitterAccountDelete(account)
 }
 
+// TwitterAccountSetMentionsSinceID records the ID of the newest mention replied to, so only newer mentions are fetched.
+// Only the one column is updated, so settings changed while the mentions are being replied to aren't overwritten.
+var TwitterAccountSetMentionsSinceID = func(account *TwitterAccount, mentionID string) error {
+	_, err := dbx.Exec(`UPDATE twitter_accounts SET mentions_since_id = $1 WHERE id = $2`, mentionID, account.ID)
+	if err == nil {
+		account.MentionsSinceID = sql.NullString{String: mentionID, Valid: true}
+	}
+	return err
+}
+
+// SetMentionsSinceID records the ID of the newest mention replied to, see TwitterAccountSetMentionsSinceID
+func (account *TwitterAccount) SetMentionsSinceID(mentionID string) error {
+	return TwitterAccountSetMentionsSinceID(account, mentionID)
+}
+
 // TwitterAccountFromID returns a TwitterAccount record with given ID
 var TwitterAccountFromID = func(id string) (TwitterAccountList, error) {
 	var account TwitterAccountList
@@ -94,19 +144,35 @@ type TwitterAccountList struct {
 	NumTweets int `db:"num_tweets"`
 }
 
-// TwitterAccountQuery is a search query for searching TwitterAccounts, used by db.TwitterAccountsAll
+// TwitterAccountQuery is a search query for searching TwitterAccounts, used by db.TwitterAccountsAll.
+// AccessibleByUserID limits it to TwitterAccounts the User owns, has been granted a role on or that
+// belong to one of the User's Organisations.
 type TwitterAccountQuery struct {
 	PagingInfo
 	ContainsUsername         string
 	UserID                   string
+	OrganisationID           string
+	AccessibleByUserID       string
 	HasTweetsToBePostedSince time.Time
 }
 
+// accessibleByUser is a condition for personal TwitterAccounts a User owns or has a role on, and TwitterAccounts
+// that belong to one of their Organisations, taking the User's ID three times
+const accessibleByUser = `((ta.organisation_id IS NULL AND (ta.user_id = ? OR EXISTS (
+	SELECT 1 FROM twitter_account_roles r WHERE r.twitter_account_id = ta.id AND r.user_id = ?))) OR EXISTS (
+	SELECT 1 FROM organisation_members m WHERE m.organisation_id = ta.organisation_id AND m.user_id = ?))`
+
 // TwitterAccountsAll returns all TwitterAccount records from the database
 var TwitterAccountsAll = func(query TwitterAccountQuery) ([]TwitterAccountList, int, error) {
 	var accounts []TwitterAccountList
 	recordCount := 0
 
+	// an ID that isn't a UUID can't match anything, and Postgres refuses to compare it with a UUID column
+	if (query.UserID != "" && !isUUID.MatchString(query.UserID)) ||
+		(query.OrganisationID != "" && !isUUID.MatchString(query.OrganisationID)) {
+		return accounts, recordCount, nil
+	}
+
 	cmd := sq.
 		Select(sqlboiler.GetFullColumnList(&TwitterAccount{}, "ta")...).Column("COUNT(t.id) AS num_tweets").
 		From("twitter_accounts ta").
@@ -117,13 +183,21 @@ var TwitterAccountsAll = func(query TwitterAccountQuery) ([]TwitterAccountList,
 	}
 
 	if !query.HasTweetsToBePostedSince.IsZero() {
-		cmd = cmd.Where("t.is_posted = ? AND t.post_on > ?", false, query.HasTweetsToBePostedSince)
+		cmd = cmd.Where("t.is_posted = ? AND t.is_draft = ? AND t.post_on > ?", false, false, query.HasTweetsToBePostedSince)
 	}
 
 	if query.UserID != "" {
 		cmd = cmd.Where("ta.user_id = ?", query.UserID)
 	}
 
+	if query.OrganisationID != "" {
+		cmd = cmd.Where("ta.organisation_id = ?", query.OrganisationID)
+	}
+
+	if query.AccessibleByUserID != "" {
+		cmd = cmd.Where(accessibleByUser, query.AccessibleByUserID, query.AccessibleByUserID, query.AccessibleByUserID)
+	}
+
 	cmd = cmd.
 		GroupBy("ta.id").
 		OrderBy(query.BuildOrderBy()).
@@ -166,12 +240,20 @@ var TwitterAccountsAll = func(query TwitterAccountQuery) ([]TwitterAccountList,
 		}
 
 		if !query.HasTweetsToBePostedSince.IsZero() {
-			countCmd = countCmd.Where("t.is_posted = ? AND t.post_on > ?", false, query.HasTweetsToBePostedSince)
+			countCmd = countCmd.Where("t.is_posted = ? AND t.is_draft = ? AND t.post_on > ?", false, false, query.HasTweetsToBePostedSince)
 		}
+	}
 
-		if query.UserID != "" {
-			countCmd = countCmd.Where("ta.user_id = ?", query.UserID)
-		}
+	if query.UserID != "" {
+		countCmd = countCmd.Where("ta.user_id = ?", query.UserID)
+	}
+
+	if query.OrganisationID != "" {
+		countCmd = countCmd.Where("ta.organisation_id = ?", query.OrganisationID)
+	}
+
+	if query.AccessibleByUserID != "" {
+		countCmd = countCmd.Where(accessibleByUser, query.AccessibleByUserID, query.AccessibleByUserID, query.AccessibleByUserID)
 	}
 
 	countSQL, countArgs, err := countCmd.PlaceholderFormat(sq.Dollar).ToSql()
@@ -220,7 +302,7 @@ var TwitterAccountGetTweets = func(account *TwitterAccount, query TweetsQuery) (
 			WHERE twitter_account_id = $1 `
 
 	if !query.ToBePostedSince.IsZero() {
-		cmd += `AND is_posted = false AND post_on > $5 `
+		cmd += `AND is_posted = false AND is_draft = false AND post_on > $5 `
 		queryParams = append(queryParams, query.ToBePostedSince)
 	}
 
@@ -252,7 +334,7 @@ var TwitterAccountGetTweets = func(account *TwitterAccount, query TweetsQuery) (
 	countParams = append(countParams, account.ID)
 
 	if !query.ToBePostedSince.IsZero() {
-		countCmd += ` AND is_posted = false AND post_on > $2`
+		countCmd += ` AND is_posted = false AND is_draft = false AND post_on > $2`
 		countParams = append(countParams, query.ToBePostedSince)
 	}
 
@@ -0,0 +1,164 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/sironfoot/go-twitter-bot/lib/sqlboiler"
+)
+
+const (
+	// TwitterAccountRoleOwner can do everything with a TwitterAccount, including managing its settings,
+	// webhooks and who has access. Admins, and the User that created a personal TwitterAccount, are always owners.
+	TwitterAccountRoleOwner = "owner"
+	// TwitterAccountRoleEditor can create, update and delete a TwitterAccount's tweets, reply rules, feeds and evergreen tweets
+	TwitterAccountRoleEditor = "editor"
+	// TwitterAccountRoleReviewer can approve a TwitterAccount's draft tweets
+	TwitterAccountRoleReviewer = "reviewer"
+	// TwitterAccountRoleViewer can only view a TwitterAccount
+	TwitterAccountRoleViewer = "viewer"
+)
+
+// TwitterAccountRoles is a list of the roles that can be granted, from most to least access
+var TwitterAccountRoles = []string{
+	TwitterAccountRoleOwner,
+	TwitterAccountRoleEditor,
+	TwitterAccountRoleReviewer,
+	TwitterAccountRoleViewer,
+}
+
+// HigherTwitterAccountRole returns whichever of two TwitterAccountRoles has more access, an empty string is no role
+func HigherTwitterAccountRole(first, second string) string {
+	for _, role := range TwitterAccountRoles {
+		if role == first || role == second {
+			return role
+		}
+	}
+	return ""
+}
+
+// TwitterAccountRole maps to twitter_account_roles table, a Role on a TwitterAccount granted to a User
+type TwitterAccountRole struct {
+	ID               string    `db:"id"`
+	TwitterAccountID string    `db:"twitter_account_id"`
+	UserID           string    `db:"user_id"`
+	Role             string    `db:"role"`
+	DateCreated      time.Time `db:"date_created"`
+}
+
+// TwitterAccountRoleWithUser is a TwitterAccountRole with the name and email address of its User, for listing
+type TwitterAccountRoleWithUser struct {
+	TwitterAccountRole
+	UserName  string `db:"user_name"`
+	UserEmail string `db:"user_email"`
+}
+
+// IsTransient determines if TwitterAccountRole record has been saved to the database,
+// true means TwitterAccountRole struct has NOT been saved, false means it has.
+func (role *TwitterAccountRole) IsTransient() bool {
+	return len(role.ID) == 0
+}
+
+// MetaData returns meta data information about the TwitterAccountRole entity
+func (role *TwitterAccountRole) MetaData() sqlboiler.EntityMetaData {
+	return sqlboiler.EntityMetaData{
+		TableName:      "twitter_account_roles",
+		PrimaryKeyName: "id",
+	}
+}
+
+// TwitterAccountRoleSave saves the TwitterAccountRole struct to the database.
+var TwitterAccountRoleSave = func(role *TwitterAccountRole) error {
+	return sqlboiler.EntitySave(role, dbx)
+}
+
+// Save saves the TwitterAccountRole struct to the database.
+func (role *TwitterAccountRole) Save() error {
+	return TwitterAccountRoleSave(role)
+}
+
+// TwitterAccountRoleDelete deletes the TwitterAccountRole from the database
+var TwitterAccountRoleDelete = func(role *TwitterAccountRole) error {
+	return sqlboiler.EntityDelete(role, dbx)
+}
+
+// Delete deletes the TwitterAccountRole from the database
+func (role *TwitterAccountRole) Delete() error {
+	return TwitterAccountRoleDelete(role)
+}
+
+// TwitterAccountGetRoleForUser returns the TwitterAccountRole granted to a User on the TwitterAccount
+var TwitterAccountGetRoleForUser = func(account *TwitterAccount, userID string) (TwitterAccountRole, error) {
+	var role TwitterAccountRole
+
+	if !isUUID.MatchString(userID) {
+		return role, ErrEntityNotFound
+	}
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&role, "") + `
+			FROM twitter_account_roles
+			WHERE twitter_account_id = $1 AND user_id = $2`
+
+	err := dbx.Get(&role, cmd, account.ID, userID)
+	if err == sql.ErrNoRows {
+		return role, ErrEntityNotFound
+	}
+	return role, err
+}
+
+// GetRoleForUser returns the TwitterAccountRole granted to a User on the TwitterAccount
+func (account *TwitterAccount) GetRoleForUser(userID string) (TwitterAccountRole, error) {
+	return TwitterAccountGetRoleForUser(account, userID)
+}
+
+// TwitterAccountGetRoles returns the TwitterAccountRoles granted on the TwitterAccount, with their Users' details
+var TwitterAccountGetRoles = func(account *TwitterAccount) ([]TwitterAccountRoleWithUser, error) {
+	var roles []TwitterAccountRoleWithUser
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&TwitterAccountRole{}, "r") + `, u.name AS user_name, u.email AS user_email
+			FROM twitter_account_roles r
+			INNER JOIN users u ON r.user_id = u.id
+			WHERE r.twitter_account_id = $1
+			ORDER BY r.date_created`
+
+	err := dbx.Select(&roles, cmd, account.ID)
+	return roles, err
+}
+
+// GetRoles returns the TwitterAccountRoles granted on the TwitterAccount, with their Users' details
+func (account *TwitterAccount) GetRoles() ([]TwitterAccountRoleWithUser, error) {
+	return TwitterAccountGetRoles(account)
+}
+
+// UserGetTwitterAccountRoles returns the User's roles on TwitterAccounts, by TwitterAccount ID. These are the
+// TwitterAccountRoles granted to them on personal TwitterAccounts and their roles in the Organisations that own
+// TwitterAccounts, granted roles don't apply to an Organisation's TwitterAccounts.
+var UserGetTwitterAccountRoles = func(user *User) (map[string]string, error) {
+	var roles []TwitterAccountRole
+
+	cmd := `SELECT r.twitter_account_id, r.role
+			FROM twitter_account_roles r
+			INNER JOIN twitter_accounts ta ON r.twitter_account_id = ta.id
+			WHERE r.user_id = $1 AND ta.organisation_id IS NULL
+			UNION ALL
+			SELECT ta.id AS twitter_account_id, m.role
+			FROM twitter_accounts ta
+			INNER JOIN organisation_members m ON ta.organisation_id = m.organisation_id
+			WHERE m.user_id = $1`
+
+	err := dbx.Select(&roles, cmd, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	rolesByAccount := make(map[string]string)
+	for _, role := range roles {
+		rolesByAccount[role.TwitterAccountID] = HigherTwitterAccountRole(rolesByAccount[role.TwitterAccountID], role.Role)
+	}
+	return rolesByAccount, nil
+}
+
+// GetTwitterAccountRoles returns the TwitterAccountRoles granted to the User, by TwitterAccount ID
+func (user *User) GetTwitterAccountRoles() (map[string]string, error) {
+	return UserGetTwitterAccountRoles(user)
+}
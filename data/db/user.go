@@ -8,16 +8,21 @@ import (
 	"github.com/sironfoot/go-twitter-bot/lib/sqlboiler"
 )
 
-// User maps to users table
+// User maps to users table. TwoFactorSecret is set when enrolling in two-factor authentication,
+// but only used once TwoFactorEnabled is set by confirming a code.
 type User struct {
-	ID             string         `db:"id"`
-	Name           string         `db:"name"`
-	Email          string         `db:"email"`
-	HashedPassword string         `db:"hashed_password"`
-	AuthToken      sql.NullString `db:"auth_token"`
-	IsAdmin        bool           `db:"is_admin"`
-	IsService      bool           `db:"is_service"`
-	DateCreated    time.Time      `db:"date_created"`
+	ID                string         `db:"id"`
+	Name              string         `db:"name"`
+	Email             string         `db:"email"`
+	HashedPassword    string         `db:"hashed_password"`
+	AuthToken         sql.NullString `db:"auth_token"`
+	IsAdmin           bool           `db:"is_admin"`
+	IsService         bool           `db:"is_service"`
+	IsVerified        bool           `db:"is_verified"`
+	TwoFactorSecret   sql.NullString `db:"two_factor_secret"`
+	TwoFactorEnabled  bool           `db:"two_factor_enabled"`
+	TwoFactorLastStep int64          `db:"two_factor_last_step"`
+	DateCreated       time.Time      `db:"date_created"`
 }
 
 // IsTransient determines if User record has been saved to the database,
@@ -46,7 +51,24 @@ func (user *User) Save() error {
 
 // UserDelete deletes the User from the database
 var UserDelete = func(user *User) error {
-	return sqlboiler.EntityDelete(user, dbx)
+	tx, err := dbx.Beginx()
+	if err != nil {
+		return err
+	}
+
+	// the User's personal TwitterAccounts go with them, those belonging to an Organisation are kept
+	_, err = tx.Exec(`DELETE FROM twitter_accounts WHERE user_id = $1 AND organisation_id IS NULL`, user.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err = sqlboiler.EntityDelete(user, tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // Delete deletes the User from the database
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sironfoot/go-twitter-bot/lib/sqlboiler"
+)
+
+const (
+	// UserTokenPurposeVerifyEmail is for tokens emailed to verify a new User's email address
+	UserTokenPurposeVerifyEmail = "verify_email"
+	// UserTokenPurposeResetPassword is for tokens emailed to reset a forgotten password
+	UserTokenPurposeResetPassword = "reset_password"
+	// UserTokenPurposeTwoFactorLogin is for tokens given after a correct password, to send with a two-factor code
+	UserTokenPurposeTwoFactorLogin = "two_factor_login"
+)
+
+// UserToken maps to user_tokens table, a single use token emailed to a User for a Purpose, such as
+// verifying their email address. Only a hash of the token is stored.
+type UserToken struct {
+	ID          string      `db:"id"`
+	UserID      string      `db:"user_id"`
+	Purpose     string      `db:"purpose"`
+	TokenHash   string      `db:"token_hash"`
+	DateCreated time.Time   `db:"date_created"`
+	ExpiresOn   time.Time   `db:"expires_on"`
+	DateUsed    pq.NullTime `db:"date_used"`
+}
+
+// IsTransient determines if UserToken record has been saved to the database,
+// true means UserToken struct has NOT been saved, false means it has.
+func (userToken *UserToken) IsTransient() bool {
+	return len(userToken.ID) == 0
+}
+
+// MetaData returns meta data information about the UserToken entity
+func (userToken *UserToken) MetaData() sqlboiler.EntityMetaData {
+	return sqlboiler.EntityMetaData{
+		TableName:      "user_tokens",
+		PrimaryKeyName: "id",
+	}
+}
+
+// UserTokenSave saves the UserToken struct to the database.
+var UserTokenSave = func(userToken *UserToken) error {
+	return sqlboiler.EntitySave(userToken, dbx)
+}
+
+// Save saves the UserToken struct to the database.
+func (userToken *UserToken) Save() error {
+	return UserTokenSave(userToken)
+}
+
+// UserTokenFromHash gets an unused, unexpired UserToken for 'purpose' by the hash of the token
+var UserTokenFromHash = func(purpose, tokenHash string) (UserToken, error) {
+	var userToken UserToken
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&userToken, "") + `
+			FROM user_tokens
+			WHERE purpose = $1 AND token_hash = $2 AND date_used IS NULL AND expires_on > $3`
+
+	err := dbx.Get(&userToken, cmd, purpose, tokenHash, time.Now().UTC())
+	if err == sql.ErrNoRows {
+		return userToken, ErrEntityNotFound
+	}
+	return userToken, err
+}
+
+// UserTokenUse marks the UserToken as used, returning false if it had already been used
+var UserTokenUse = func(userToken *UserToken) (bool, error) {
+	now := time.Now().UTC()
+
+	result, err := dbx.Exec(`UPDATE user_tokens SET date_used = $1 WHERE id = $2 AND date_used IS NULL`, now, userToken.ID)
+	if err != nil {
+		return false, err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil || updated == 0 {
+		return false, err
+	}
+
+	userToken.DateUsed = pq.NullTime{Time: now, Valid: true}
+	return true, nil
+}
+
+// Use marks the UserToken as used, returning false if it had already been used
+func (userToken *UserToken) Use() (bool, error) {
+	return UserTokenUse(userToken)
+}
+
+// UserDeleteTokens deletes the User's UserTokens for 'purpose', so only a newly sent token can be used
+var UserDeleteTokens = func(user *User, purpose string) error {
+	_, err := dbx.Exec(`DELETE FROM user_tokens WHERE user_id = $1 AND purpose = $2`, user.ID, purpose)
+	return err
+}
+
+// DeleteTokens deletes the User's UserTokens for 'purpose', so only a newly sent token can be used
+func (user *User) DeleteTokens(purpose string) error {
+	return UserDeleteTokens(user, purpose)
+}
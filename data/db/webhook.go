@@ -0,0 +1,238 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sironfoot/go-twitter-bot/lib/sqlboiler"
+)
+
+// Webhook maps to webhooks table, a URL that is sent signed JSON payloads for a TwitterAccount's events
+type Webhook struct {
+	ID          string         `db:"id"`
+	AccountID   string         `db:"twitter_account_id"`
+	URL         string         `db:"url"`
+	Secret      string         `db:"secret"`
+	Events      pq.StringArray `db:"events"`
+	IsEnabled   bool           `db:"is_enabled"`
+	DateCreated time.Time      `db:"date_created"`
+}
+
+// IsTransient determines if Webhook record has been saved to the database,
+// true means Webhook struct has NOT been saved, false means it has.
+func (webhook *Webhook) IsTransient() bool {
+	return len(webhook.ID) == 0
+}
+
+// MetaData returns meta data information about the Webhook entity
+func (webhook *Webhook) MetaData() sqlboiler.EntityMetaData {
+	return sqlboiler.EntityMetaData{
+		TableName:      "webhooks",
+		PrimaryKeyName: "id",
+	}
+}
+
+// WebhookSave saves the Webhook struct to the database.
+var WebhookSave = func(webhook *Webhook) error {
+	return sqlboiler.EntitySave(webhook, dbx)
+}
+
+// Save saves the Webhook struct to the database.
+func (webhook *Webhook) Save() error {
+	return WebhookSave(webhook)
+}
+
+// WebhookDelete deletes the Webhook from the database
+var WebhookDelete = func(webhook *Webhook) error {
+	return sqlboiler.EntityDelete(webhook, dbx)
+}
+
+// Delete deletes the Webhook from the database
+func (webhook *Webhook) Delete() error {
+	return WebhookDelete(webhook)
+}
+
+// WebhookFromID gets a Webhook by its ID
+var WebhookFromID = func(id string) (Webhook, error) {
+	var webhook Webhook
+
+	if !isUUID.MatchString(id) {
+		return webhook, ErrEntityNotFound
+	}
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&webhook, "") + `
+			FROM webhooks
+			WHERE id = $1`
+
+	err := dbx.Get(&webhook, cmd, id)
+	if err == sql.ErrNoRows {
+		return webhook, ErrEntityNotFound
+	}
+	return webhook, err
+}
+
+// TwitterAccountGetWebhooks loads Webhook child entities for TwitterAccount
+var TwitterAccountGetWebhooks = func(account *TwitterAccount) ([]Webhook, error) {
+	var webhooks []Webhook
+
+	if account.IsTransient() {
+		return webhooks, nil
+	}
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&Webhook{}, "") + `
+			FROM webhooks
+			WHERE twitter_account_id = $1
+			ORDER BY date_created ASC`
+
+	err := dbx.Select(&webhooks, cmd, account.ID)
+	return webhooks, err
+}
+
+// GetWebhooks loads Webhook child entities for TwitterAccount
+func (account *TwitterAccount) GetWebhooks() ([]Webhook, error) {
+	return TwitterAccountGetWebhooks(account)
+}
+
+// TwitterAccountGetWebhookFromID gets a TwitterAccount's Webhook by its ID
+var TwitterAccountGetWebhookFromID = func(account *TwitterAccount, webhookID string) (Webhook, error) {
+	var webhook Webhook
+
+	if !isUUID.MatchString(webhookID) {
+		return webhook, ErrEntityNotFound
+	}
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&webhook, "") + `
+			FROM webhooks
+			WHERE twitter_account_id = $1 AND id = $2`
+
+	err := dbx.Get(&webhook, cmd, account.ID, webhookID)
+	if err == sql.ErrNoRows {
+		return webhook, ErrEntityNotFound
+	}
+	return webhook, err
+}
+
+// GetWebhookFromID gets this TwitterAccount's Webhook by ID
+func (account *TwitterAccount) GetWebhookFromID(id string) (Webhook, error) {
+	return TwitterAccountGetWebhookFromID(account, id)
+}
+
+// TwitterAccountGetWebhooksForEvent loads the TwitterAccount's enabled Webhooks subscribed to 'event'
+var TwitterAccountGetWebhooksForEvent = func(account *TwitterAccount, event string) ([]Webhook, error) {
+	var webhooks []Webhook
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&Webhook{}, "") + `
+			FROM webhooks
+			WHERE twitter_account_id = $1 AND is_enabled = true AND $2 = ANY(events)`
+
+	err := dbx.Select(&webhooks, cmd, account.ID, event)
+	return webhooks, err
+}
+
+// GetWebhooksForEvent loads this TwitterAccount's enabled Webhooks subscribed to 'event'
+func (account *TwitterAccount) GetWebhooksForEvent(event string) ([]Webhook, error) {
+	return TwitterAccountGetWebhooksForEvent(account, event)
+}
+
+// WebhookDelivery maps to webhook_deliveries table, a payload to send to a Webhook
+// along with a log of attempts to deliver it
+type WebhookDelivery struct {
+	ID          string         `db:"id"`
+	WebhookID   string         `db:"webhook_id"`
+	Event       string         `db:"event"`
+	Payload     string         `db:"payload"`
+	Attempts    int            `db:"attempts"`
+	IsDelivered bool           `db:"is_delivered"`
+	StatusCode  sql.NullInt64  `db:"status_code"`
+	LastError   sql.NullString `db:"last_error"`
+	LastAttempt pq.NullTime    `db:"last_attempt"`
+	NextAttempt pq.NullTime    `db:"next_attempt"`
+	DateCreated time.Time      `db:"date_created"`
+}
+
+// IsTransient determines if WebhookDelivery record has been saved to the database,
+// true means WebhookDelivery struct has NOT been saved, false means it has.
+func (delivery *WebhookDelivery) IsTransient() bool {
+	return len(delivery.ID) == 0
+}
+
+// MetaData returns meta data information about the WebhookDelivery entity
+func (delivery *WebhookDelivery) MetaData() sqlboiler.EntityMetaData {
+	return sqlboiler.EntityMetaData{
+		TableName:      "webhook_deliveries",
+		PrimaryKeyName: "id",
+	}
+}
+
+// WebhookDeliverySave saves the WebhookDelivery struct to the database.
+var WebhookDeliverySave = func(delivery *WebhookDelivery) error {
+	return sqlboiler.EntitySave(delivery, dbx)
+}
+
+// Save saves the WebhookDelivery struct to the database.
+func (delivery *WebhookDelivery) Save() error {
+	return WebhookDeliverySave(delivery)
+}
+
+// WebhookGetDeliveries loads the most recent 'limit' WebhookDelivery records for Webhook, newest first
+var WebhookGetDeliveries = func(webhook *Webhook, limit int) ([]WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+
+	if webhook.IsTransient() {
+		return deliveries, nil
+	}
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&WebhookDelivery{}, "") + `
+			FROM webhook_deliveries
+			WHERE webhook_id = $1
+			ORDER BY date_created DESC
+			LIMIT $2`
+
+	err := dbx.Select(&deliveries, cmd, webhook.ID, limit)
+	return deliveries, err
+}
+
+// GetDeliveries loads the most recent 'limit' WebhookDelivery records for this Webhook, newest first
+func (webhook *Webhook) GetDeliveries(limit int) ([]WebhookDelivery, error) {
+	return WebhookGetDeliveries(webhook, limit)
+}
+
+// WebhookGetDeliveryFromID gets a Webhook's WebhookDelivery by its ID
+var WebhookGetDeliveryFromID = func(webhook *Webhook, deliveryID string) (WebhookDelivery, error) {
+	var delivery WebhookDelivery
+
+	if !isUUID.MatchString(deliveryID) {
+		return delivery, ErrEntityNotFound
+	}
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&delivery, "") + `
+			FROM webhook_deliveries
+			WHERE webhook_id = $1 AND id = $2`
+
+	err := dbx.Get(&delivery, cmd, webhook.ID, deliveryID)
+	if err == sql.ErrNoRows {
+		return delivery, ErrEntityNotFound
+	}
+	return delivery, err
+}
+
+// GetDeliveryFromID gets this Webhook's WebhookDelivery by ID
+func (webhook *Webhook) GetDeliveryFromID(id string) (WebhookDelivery, error) {
+	return WebhookGetDeliveryFromID(webhook, id)
+}
+
+// WebhookDeliveriesDue returns all undelivered WebhookDelivery records of enabled Webhooks whose NextAttempt has passed
+// at 'now', oldest first
+var WebhookDeliveriesDue = func(now time.Time) ([]WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+
+	cmd := `SELECT ` + sqlboiler.GetFullColumnListString(&WebhookDelivery{}, "d") + `
+			FROM webhook_deliveries d
+			INNER JOIN webhooks w ON w.id = d.webhook_id
+			WHERE d.is_delivered = false AND d.next_attempt <= $1 AND w.is_enabled = true
+			ORDER BY d.next_attempt ASC`
+
+	err := dbx.Select(&deliveries, cmd, now)
+	return deliveries, err
+}
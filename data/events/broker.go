@@ -0,0 +1,73 @@
+package events
+
+import "sync"
+
+// Broker fans published Events out to subscribers, keeping the most recent
+// events so a subscriber can catch up on what it missed
+type Broker struct {
+	lock        sync.RWMutex
+	recent      []Event
+	maxRecent   int
+	subscribers map[chan Event]bool
+}
+
+// NewBroker returns a Broker that keeps the last 'maxRecent' events for resuming
+func NewBroker(maxRecent int) *Broker {
+	return &Broker{
+		maxRecent:   maxRecent,
+		subscribers: make(map[chan Event]bool),
+	}
+}
+
+// Publish sends event to all subscribers. Subscribers that aren't keeping up miss the event
+// rather than holding up the publisher, they can catch up with Since.
+func (broker *Broker) Publish(event Event) {
+	broker.lock.Lock()
+	defer broker.lock.Unlock()
+
+	broker.recent = append(broker.recent, event)
+	if len(broker.recent) > broker.maxRecent {
+		broker.recent = broker.recent[len(broker.recent)-broker.maxRecent:]
+	}
+
+	for subscriber := range broker.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel that receives every event published from now on,
+// call Unsubscribe with the channel when finished
+func (broker *Broker) Subscribe() chan Event {
+	broker.lock.Lock()
+	defer broker.lock.Unlock()
+
+	subscriber := make(chan Event, 100)
+	broker.subscribers[subscriber] = true
+	return subscriber
+}
+
+// Unsubscribe stops sending events to a channel returned by Subscribe
+func (broker *Broker) Unsubscribe(subscriber chan Event) {
+	broker.lock.Lock()
+	defer broker.lock.Unlock()
+
+	delete(broker.subscribers, subscriber)
+}
+
+// Since returns the recent events with an ID greater than lastID, oldest first
+func (broker *Broker) Since(lastID int64) []Event {
+	broker.lock.RLock()
+	defer broker.lock.RUnlock()
+
+	var events []Event
+	for _, event := range broker.recent {
+		if event.ID > lastID {
+			events = append(events, event)
+		}
+	}
+
+	return events
+}
@@ -0,0 +1,124 @@
+package events
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/sironfoot/go-twitter-bot/data/db"
+)
+
+const (
+	// TweetCreated is published when a Tweet is added to a TwitterAccount's schedule
+	TweetCreated = "tweet.created"
+	// TweetUpdated is published when a scheduled Tweet is edited
+	TweetUpdated = "tweet.updated"
+	// TweetDeleted is published when a Tweet is removed from the schedule
+	TweetDeleted = "tweet.deleted"
+	// TweetPosted is published when a Tweet is posted to Twitter
+	TweetPosted = "tweet.posted"
+	// TweetFailed is published each time posting a Tweet to Twitter fails
+	TweetFailed = "tweet.failed"
+)
+
+// Event is a change to a TwitterAccount's schedule, IDs increase over time so
+// a client can resume a stream from the last ID it received
+type Event struct {
+	ID        int64     `json:"id"`
+	Type      string    `json:"type"`
+	AccountID string    `json:"twitterAccountId"`
+	UserID    string    `json:"-"`
+	Tweet     TweetData `json:"tweet"`
+}
+
+// TweetData is the Tweet an Event is about
+type TweetData struct {
+	ID        string    `json:"id"`
+	Text      string    `json:"text"`
+	PostOn    time.Time `json:"postOn"`
+	IsPosted  bool      `json:"isPosted"`
+	IsDraft   bool      `json:"isDraft"`
+	StatusID  string    `json:"statusId,omitempty"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"lastError,omitempty"`
+}
+
+// DefaultBroker is the in-process Broker that Publish sends events to and streams subscribe to
+var DefaultBroker = NewBroker(1000)
+
+var (
+	lastID     int64
+	lastIDLock sync.Mutex
+)
+
+// nextID returns a new Event ID based on the current time, so IDs from different
+// server instances sharing events through Postgres are still roughly in order
+func nextID() int64 {
+	lastIDLock.Lock()
+	defer lastIDLock.Unlock()
+
+	id := time.Now().UnixNano() / int64(time.Microsecond)
+	if id <= lastID {
+		id = lastID + 1
+	}
+
+	lastID = id
+	return id
+}
+
+// publish sends an Event on to subscribers, replaced by UsePostgres to send events through Postgres NOTIFY instead
+var publish = func(event Event) error {
+	DefaultBroker.Publish(event)
+	return nil
+}
+
+// Publish publishes an event about a Tweet belonging to account. Events are for live updates only,
+// so a failure to publish is logged rather than returned.
+func Publish(eventType string, account *db.TwitterAccount, tweet *db.Tweet) {
+	event := Event{
+		ID:        nextID(),
+		Type:      eventType,
+		AccountID: account.ID,
+		Tweet: TweetData{
+			ID:        tweet.ID,
+			Text:      tweet.Tweet,
+			PostOn:    tweet.PostOn,
+			IsPosted:  tweet.IsPosted,
+			IsDraft:   tweet.IsDraft,
+			StatusID:  tweet.StatusID.String,
+			Attempts:  tweet.Attempts,
+			LastError: tweet.LastError.String,
+		},
+	}
+
+	// an Organisation's TwitterAccount is only seen by its members, not the User that added it
+	if !account.OrganisationID.Valid {
+		event.UserID = account.UserID.String
+	}
+
+	if err := publish(event); err != nil {
+		log.Printf("events: %s: %s\n", eventType, err)
+	}
+}
+
+// notification is how an Event is encoded for Postgres NOTIFY, which (unlike the
+// JSON sent to clients) has to include the UserID for filtering
+type notification struct {
+	Event
+	UserID string `json:"userId"`
+}
+
+func encodeNotification(event Event) (string, error) {
+	data, err := json.Marshal(notification{Event: event, UserID: event.UserID})
+	return string(data), err
+}
+
+func decodeNotification(payload string) (Event, error) {
+	var n notification
+	err := json.Unmarshal([]byte(payload), &n)
+
+	event := n.Event
+	event.UserID = n.UserID
+	return event, err
+}
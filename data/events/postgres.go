@@ -0,0 +1,54 @@
+package events
+
+import (
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sironfoot/go-twitter-bot/data/db"
+)
+
+// NotifyChannel is the Postgres LISTEN/NOTIFY channel events are shared on
+const NotifyChannel = "gobot_events"
+
+// UsePostgres shares events between server instances using the same database. Published events are sent
+// with Postgres NOTIFY and every instance, including this one, LISTENs and passes them on to its DefaultBroker.
+func UsePostgres(connectionString string) error {
+	listener := pq.NewListener(connectionString, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("events: postgres listener: %s\n", err)
+		}
+	})
+
+	if err := listener.Listen(NotifyChannel); err != nil {
+		return err
+	}
+
+	publish = func(event Event) error {
+		payload, err := encodeNotification(event)
+		if err != nil {
+			return err
+		}
+
+		return db.Notify(NotifyChannel, payload)
+	}
+
+	go func() {
+		for n := range listener.Notify {
+			// nil is sent when the connection is re-established, events sent meanwhile are lost
+			if n == nil {
+				continue
+			}
+
+			event, err := decodeNotification(n.Extra)
+			if err != nil {
+				log.Printf("events: bad notification: %s\n", err)
+				continue
+			}
+
+			DefaultBroker.Publish(event)
+		}
+	}()
+
+	return nil
+}
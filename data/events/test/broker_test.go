@@ -0,0 +1,64 @@
+package events_test
+
+import (
+	"testing"
+
+	"github.com/sironfoot/go-twitter-bot/data/events"
+)
+
+func TestBrokerPublishSubscribe(t *testing.T) {
+	broker := events.NewBroker(10)
+
+	subscriber := broker.Subscribe()
+	broker.Publish(events.Event{ID: 1, Type: events.TweetCreated})
+
+	select {
+	case event := <-subscriber:
+		if event.ID != 1 || event.Type != events.TweetCreated {
+			t.Errorf("unexpected event %+v", event)
+		}
+	default:
+		t.Fatal("expected subscriber to receive the published event")
+	}
+
+	broker.Unsubscribe(subscriber)
+	broker.Publish(events.Event{ID: 2, Type: events.TweetDeleted})
+
+	select {
+	case event := <-subscriber:
+		t.Errorf("expected no events after unsubscribing, got %+v", event)
+	default:
+	}
+}
+
+func TestBrokerSince(t *testing.T) {
+	broker := events.NewBroker(3)
+
+	for id := int64(1); id <= 5; id++ {
+		broker.Publish(events.Event{ID: id})
+	}
+
+	var testCases = []struct {
+		lastID   int64
+		expected []int64
+	}{
+		{0, []int64{3, 4, 5}},
+		{3, []int64{4, 5}},
+		{5, nil},
+	}
+
+	for _, testCase := range testCases {
+		since := broker.Since(testCase.lastID)
+
+		if len(since) != len(testCase.expected) {
+			t.Errorf("Since(%d): expected %d events, got %d", testCase.lastID, len(testCase.expected), len(since))
+			continue
+		}
+
+		for i, event := range since {
+			if event.ID != testCase.expected[i] {
+				t.Errorf("Since(%d): expected event %d to have ID %d, got %d", testCase.lastID, i, testCase.expected[i], event.ID)
+			}
+		}
+	}
+}
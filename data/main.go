@@ -1,18 +1,20 @@
 package main
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
-	"encoding/base64"
 	"encoding/json"
 	"flag"
-	"fmt"
 	"log"
 	"net/http"
-	"strings"
+	"time"
 
 	"github.com/sironfoot/go-twitter-bot/data/api"
 	"github.com/sironfoot/go-twitter-bot/data/db"
+	"github.com/sironfoot/go-twitter-bot/data/events"
+	"github.com/sironfoot/go-twitter-bot/data/workers"
+	"github.com/sironfoot/go-twitter-bot/lib/authtoken"
+	"github.com/sironfoot/go-twitter-bot/lib/feed"
+	"github.com/sironfoot/go-twitter-bot/lib/mailer"
+	"github.com/sironfoot/go-twitter-bot/lib/webhook"
 	"github.com/sironfoot/transfig"
 
 	"goji.io"
@@ -28,6 +30,12 @@ func main() {
 		log.Fatal(err)
 	}
 
+	for _, key := range configuration.AppSettings.TokenKeys {
+		if len(key.Secret) < authtoken.MinSecretLength {
+			log.Fatalf("appSettings.tokenKeys: the secret for key '%s' must be at least %d characters", key.ID, authtoken.MinSecretLength)
+		}
+	}
+
 	addr := flag.String("addr", configuration.AppSettings.ServerAddress, "Address to run server on")
 	dbConn := flag.String("db", configuration.Database.ConnectionString, "Database connection string")
 	flag.Parse()
@@ -37,6 +45,21 @@ func main() {
 		log.Fatal(err)
 	}
 
+	webhook.AllowPrivateAddresses = configuration.Webhooks.AllowPrivateAddresses
+	feed.AllowPrivateAddresses = configuration.Feeds.AllowPrivateAddresses
+
+	mail, err := mailer.New(configuration.Mail)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if configuration.Events.PostgresNotify {
+		err = events.UsePostgres(*dbConn)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	router := goji.NewMux()
 
 	// 1. Error handling
@@ -79,6 +102,7 @@ func main() {
 			}
 
 			appContext.Settings = configuration
+			appContext.Mailer = mail
 			ctx = context.WithValue(ctx, "appContext", &appContext)
 
 			next.ServeHTTPC(ctx, res, req)
@@ -92,51 +116,26 @@ func main() {
 				next.ServeHTTPC(ctx, res, req)
 			}()
 
-			accessToken := req.Header.Get("accessToken")
-			if len(accessToken) == 0 {
-				return
-			}
-
-			encryptedToken, err := base64.StdEncoding.DecodeString(accessToken)
-			if err != nil {
-				// not a valid Base64 string, force user to log in again
-				return
-			}
-
 			appContext := ctx.Value("appContext").(*api.AppContext)
-			block, err := aes.NewCipher([]byte(appContext.Settings.AppSettings.EncryptionKey))
+
+			user, apiKey, err := api.AuthenticateAPIKey(req)
 			if err != nil {
 				panic(err)
 			}
 
-			if len(encryptedToken) < aes.BlockSize {
-				panic(fmt.Errorf("encryptedToken too short"))
-			}
-
-			iv := encryptedToken[:aes.BlockSize]
-			encryptedToken = encryptedToken[aes.BlockSize:]
-
-			cfb := cipher.NewCFBDecrypter(block, iv)
-			cfb.XORKeyStream(encryptedToken, encryptedToken)
-
-			token := string(encryptedToken)
-			tokenParts := strings.Split(token, "_")
-			if len(tokenParts) != 2 {
-				// not a valid format (UserID_AuthToken), force user to log in again
+			if apiKey != nil {
+				appContext.AuthUser = user
+				appContext.APIKey = apiKey
 				return
 			}
 
-			userID := tokenParts[0]
-			authToken := tokenParts[1]
-
-			user, err := db.UserFromID(userID)
+			user, session, err := api.Authenticate(appContext.Settings.AppSettings, req)
 			if err != nil {
 				panic(err)
 			}
 
-			if user.AuthToken.Valid && user.AuthToken.String == authToken {
-				appContext.AuthUser = &user
-			}
+			appContext.AuthUser = user
+			appContext.Session = session
 		})
 	})
 
@@ -180,7 +179,7 @@ func main() {
 		})
 	})
 
-	var mustBeLoggedIn = func(next goji.Handler) goji.Handler {
+	var mustBeAuthenticated = func(next goji.Handler) goji.Handler {
 		return goji.HandlerFunc(func(ctx context.Context, res http.ResponseWriter, req *http.Request) {
 			appContext := ctx.Value("appContext").(*api.AppContext)
 
@@ -195,12 +194,55 @@ func main() {
 					panic(jsonErr)
 				}
 				res.Write(data)
+			} else if appContext.APIKey != nil && !api.APIKeyAllows(appContext.APIKey, req) {
+				res.WriteHeader(http.StatusForbidden)
+
+				response := api.MessageResponse{
+					Message: "This API key doesn't have the scope for this request.",
+				}
+				data, jsonErr := json.MarshalIndent(response, "", "    ")
+				if jsonErr != nil {
+					panic(jsonErr)
+				}
+				res.Write(data)
 			} else {
 				next.ServeHTTPC(ctx, res, req)
 			}
 		})
 	}
 
+	// admins who have to turn on two-factor authentication can only use the endpoints for setting it up
+	var mustBeLoggedIn = func(next goji.Handler) goji.Handler {
+		return mustBeAuthenticated(goji.HandlerFunc(func(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+			appContext := ctx.Value("appContext").(*api.AppContext)
+
+			// API keys are for service users, who don't log in with a password
+			required := false
+			if appContext.APIKey == nil {
+				var err error
+				required, err = api.TwoFactorSetupRequired(appContext.AuthUser)
+				if err != nil {
+					panic(err)
+				}
+			}
+
+			if required {
+				res.WriteHeader(http.StatusForbidden)
+
+				response := api.MessageResponse{
+					Message: "Two-factor authentication is required for administrators. Please turn it on with POST: /account/two-factor",
+				}
+				data, jsonErr := json.MarshalIndent(response, "", "    ")
+				if jsonErr != nil {
+					panic(jsonErr)
+				}
+				res.Write(data)
+			} else {
+				next.ServeHTTPC(ctx, res, req)
+			}
+		}))
+	}
+
 	router.HandleFuncC(pat.Get("/"), func(ctx context.Context, res http.ResponseWriter, req *http.Request) {
 		appContext := ctx.Value("appContext").(*api.AppContext)
 
@@ -209,14 +251,37 @@ func main() {
 		}
 	})
 
+	// Live event stream
+	router.HandleC(pat.Get("/events"), mustBeLoggedIn(goji.HandlerFunc(api.EventsStream)))
+
 	// Account
 	account := goji.SubMux()
 	account.UseC(notFoundHandler)
 	router.HandleC(pat.New("/account/*"), account)
 
 	account.HandleFuncC(pat.Put("/login"), api.AccountLogin)
+	account.HandleFuncC(pat.Put("/login/two-factor"), api.AccountLoginTwoFactor)
 	account.HandleFuncC(pat.Put("/logout"), api.AccountLogout)
+	account.HandleFuncC(pat.Put("/refresh"), api.AccountRefresh)
 	account.HandleFuncC(pat.Post("/signup"), api.AccountSignup)
+	account.HandleFuncC(pat.Get("/verify"), api.AccountVerify)
+	account.HandleFuncC(pat.Put("/verify/resend"), api.AccountVerifyResend)
+	account.HandleFuncC(pat.Post("/forgot-password"), api.AccountForgotPassword)
+	account.HandleFuncC(pat.Post("/reset-password"), api.AccountResetPassword)
+
+	account.HandleC(pat.Get("/sessions"), mustBeLoggedIn(goji.HandlerFunc(api.AccountSessionsAll)))
+	account.HandleC(pat.Delete("/sessions"), mustBeLoggedIn(goji.HandlerFunc(api.AccountSessionsDelete)))
+	account.HandleC(pat.Delete("/sessions/:sessionID"), mustBeLoggedIn(goji.HandlerFunc(api.AccountSessionDelete)))
+
+	account.HandleC(pat.Get("/two-factor"), mustBeAuthenticated(goji.HandlerFunc(api.AccountTwoFactorGet)))
+	account.HandleC(pat.Post("/two-factor"), mustBeAuthenticated(goji.HandlerFunc(api.AccountTwoFactorEnrol)))
+	account.HandleC(pat.Put("/two-factor/confirm"), mustBeAuthenticated(goji.HandlerFunc(api.AccountTwoFactorConfirm)))
+	account.HandleC(pat.Put("/two-factor/disable"), mustBeLoggedIn(goji.HandlerFunc(api.AccountTwoFactorDisable)))
+	account.HandleC(pat.Post("/two-factor/recovery-codes"), mustBeLoggedIn(goji.HandlerFunc(api.AccountRecoveryCodesCreate)))
+
+	account.HandleC(pat.Get("/invitations"), mustBeLoggedIn(goji.HandlerFunc(api.AccountInvitationsAll)))
+	account.HandleC(pat.Put("/invitations/:invitationID/accept"), mustBeLoggedIn(goji.HandlerFunc(api.AccountInvitationAccept)))
+	account.HandleC(pat.Put("/invitations/:invitationID/decline"), mustBeLoggedIn(goji.HandlerFunc(api.AccountInvitationDecline)))
 
 	// Users
 	users := goji.SubMux()
@@ -230,6 +295,38 @@ func main() {
 	users.HandleFuncC(pat.Get("/:userID"), api.UserGet)
 	users.HandleFuncC(pat.Put("/:userID"), api.UserUpdate)
 	users.HandleFuncC(pat.Delete("/:userID"), api.UserDelete)
+	users.HandleFuncC(pat.Get("/:userID/sessions"), api.UserSessionsAll)
+	users.HandleFuncC(pat.Delete("/:userID/sessions"), api.UserSessionsDelete)
+	users.HandleFuncC(pat.Put("/:userID/unlock"), api.UserUnlock)
+	users.HandleFuncC(pat.Delete("/:userID/two-factor"), api.UserTwoFactorReset)
+	users.HandleFuncC(pat.Get("/:userID/apiKeys"), api.UserAPIKeysAll)
+	users.HandleFuncC(pat.Post("/:userID/apiKeys"), api.UserAPIKeyCreate)
+	users.HandleFuncC(pat.Delete("/:userID/apiKeys/:apiKeyID"), api.UserAPIKeyDelete)
+
+	// Organisations
+	organisations := goji.SubMux()
+	organisations.UseC(notFoundHandler)
+	organisations.UseC(mustBeLoggedIn)
+	router.HandleC(pat.New("/organisations/*"), organisations)
+	router.HandleC(pat.New("/organisations"), organisations)
+
+	organisations.HandleFuncC(pat.Get(""), api.OrganisationsAll)
+	organisations.HandleFuncC(pat.Post(""), api.OrganisationCreate)
+	organisations.HandleFuncC(pat.Get("/:organisationID"), api.OrganisationGet)
+	organisations.HandleFuncC(pat.Put("/:organisationID"), api.OrganisationUpdate)
+	organisations.HandleFuncC(pat.Delete("/:organisationID"), api.OrganisationDelete)
+	organisations.HandleFuncC(pat.Put("/:organisationID/members/:userID"), api.OrganisationMemberUpdate)
+	organisations.HandleFuncC(pat.Delete("/:organisationID/members/:userID"), api.OrganisationMemberDelete)
+	organisations.HandleFuncC(pat.Get("/:organisationID/invitations"), api.OrganisationInvitationsAll)
+	organisations.HandleFuncC(pat.Post("/:organisationID/invitations"), api.OrganisationInvitationCreate)
+	organisations.HandleFuncC(pat.Delete("/:organisationID/invitations/:invitationID"), api.OrganisationInvitationDelete)
+
+	// Audit
+	router.HandleC(pat.Get("/audit"), mustBeLoggedIn(goji.HandlerFunc(api.AuditEntriesAll)))
+
+	// Settings
+	router.HandleC(pat.Get("/settings"), mustBeLoggedIn(goji.HandlerFunc(api.SettingsGet)))
+	router.HandleC(pat.Put("/settings"), mustBeLoggedIn(goji.HandlerFunc(api.SettingsUpdate)))
 
 	// TwitterAccounts
 	twitterAccounts := goji.SubMux()
@@ -241,11 +338,92 @@ func main() {
 	twitterAccounts.HandleFuncC(pat.Get(""), api.TwitterAccountsAll)
 	twitterAccounts.HandleFuncC(pat.Get("/:twitterAccountID"), api.TwitterAccountGet)
 
+	twitterAccounts.HandleFuncC(pat.Get("/:twitterAccountID/roles"), api.TwitterAccountRolesAll)
+	twitterAccounts.HandleFuncC(pat.Put("/:twitterAccountID/roles/:userID"), api.TwitterAccountRoleUpdate)
+	twitterAccounts.HandleFuncC(pat.Delete("/:twitterAccountID/roles/:userID"), api.TwitterAccountRoleDelete)
+	twitterAccounts.HandleFuncC(pat.Put("/:twitterAccountID/organisation"), api.TwitterAccountOrganisationUpdate)
+
 	twitterAccounts.HandleFuncC(pat.Get("/:twitterAccountID/tweets"), api.TwitterAccountGetWithTweets)
 	twitterAccounts.HandleFuncC(pat.Post("/:twitterAccountID/tweets"), api.TwitterAccountTweetCreate)
+	twitterAccounts.HandleFuncC(pat.Post("/:twitterAccountID/tweets/import"), api.TwitterAccountTweetsImport)
+	twitterAccounts.HandleFuncC(pat.Get("/:twitterAccountID/tweets/export"), api.TwitterAccountTweetsExport)
 	twitterAccounts.HandleFuncC(pat.Put("/:twitterAccountID/tweets/:tweetID"), api.TwitterAccountTweetUpdate)
+	twitterAccounts.HandleFuncC(pat.Put("/:twitterAccountID/tweets/:tweetID/approve"), api.TwitterAccountTweetApprove)
 	twitterAccounts.HandleFuncC(pat.Delete("/:twitterAccountID/tweets/:tweetID"), api.TwitterAccountTweetDelete)
 
+	twitterAccounts.HandleFuncC(pat.Get("/:twitterAccountID/replyRules"), api.TwitterAccountReplyRulesAll)
+	twitterAccounts.HandleFuncC(pat.Post("/:twitterAccountID/replyRules"), api.TwitterAccountReplyRuleCreate)
+	twitterAccounts.HandleFuncC(pat.Put("/:twitterAccountID/replyRules/:replyRuleID"), api.TwitterAccountReplyRuleUpdate)
+	twitterAccounts.HandleFuncC(pat.Delete("/:twitterAccountID/replyRules/:replyRuleID"), api.TwitterAccountReplyRuleDelete)
+
+	twitterAccounts.HandleFuncC(pat.Get("/:twitterAccountID/feeds"), api.TwitterAccountFeedsAll)
+	twitterAccounts.HandleFuncC(pat.Post("/:twitterAccountID/feeds"), api.TwitterAccountFeedCreate)
+	twitterAccounts.HandleFuncC(pat.Put("/:twitterAccountID/feeds/:feedID"), api.TwitterAccountFeedUpdate)
+	twitterAccounts.HandleFuncC(pat.Delete("/:twitterAccountID/feeds/:feedID"), api.TwitterAccountFeedDelete)
+
+	twitterAccounts.HandleFuncC(pat.Get("/:twitterAccountID/templateSettings"), api.TwitterAccountTemplateSettingsGet)
+	twitterAccounts.HandleFuncC(pat.Put("/:twitterAccountID/templateSettings"), api.TwitterAccountTemplateSettingsUpdate)
+
+	twitterAccounts.HandleFuncC(pat.Get("/:twitterAccountID/duplicateSettings"), api.TwitterAccountDuplicateSettingsGet)
+	twitterAccounts.HandleFuncC(pat.Put("/:twitterAccountID/duplicateSettings"), api.TwitterAccountDuplicateSettingsUpdate)
+
+	twitterAccounts.HandleFuncC(pat.Get("/:twitterAccountID/evergreen"), api.TwitterAccountEvergreenAll)
+	twitterAccounts.HandleFuncC(pat.Post("/:twitterAccountID/evergreen"), api.TwitterAccountEvergreenCreate)
+	twitterAccounts.HandleFuncC(pat.Put("/:twitterAccountID/evergreen/:evergreenID"), api.TwitterAccountEvergreenUpdate)
+	twitterAccounts.HandleFuncC(pat.Delete("/:twitterAccountID/evergreen/:evergreenID"), api.TwitterAccountEvergreenDelete)
+	twitterAccounts.HandleFuncC(pat.Get("/:twitterAccountID/evergreen/:evergreenID/history"), api.TwitterAccountEvergreenHistory)
+
+	twitterAccounts.HandleFuncC(pat.Get("/:twitterAccountID/webhooks"), api.TwitterAccountWebhooksAll)
+	twitterAccounts.HandleFuncC(pat.Post("/:twitterAccountID/webhooks"), api.TwitterAccountWebhookCreate)
+	twitterAccounts.HandleFuncC(pat.Put("/:twitterAccountID/webhooks/:webhookID"), api.TwitterAccountWebhookUpdate)
+	twitterAccounts.HandleFuncC(pat.Delete("/:twitterAccountID/webhooks/:webhookID"), api.TwitterAccountWebhookDelete)
+	twitterAccounts.HandleFuncC(pat.Get("/:twitterAccountID/webhooks/:webhookID/deliveries"), api.TwitterAccountWebhookDeliveries)
+	twitterAccounts.HandleFuncC(pat.Post("/:twitterAccountID/webhooks/:webhookID/deliveries/:deliveryID/redeliver"), api.TwitterAccountWebhookRedeliver)
+
+	twitterAccounts.HandleFuncC(pat.Get("/:twitterAccountID/evergreenSettings"), api.TwitterAccountEvergreenSettingsGet)
+	twitterAccounts.HandleFuncC(pat.Put("/:twitterAccountID/evergreenSettings"), api.TwitterAccountEvergreenSettingsUpdate)
+
+	// Background workers
+	stopWorkers := make(chan bool)
+	defer close(stopWorkers)
+
+	if configuration.Workers.PosterPollSeconds > 0 {
+		posterWorker := workers.PosterWorker{
+			Interval: time.Duration(configuration.Workers.PosterPollSeconds) * time.Second,
+		}
+		go posterWorker.Run(stopWorkers)
+	}
+
+	if configuration.Workers.MentionsPollSeconds > 0 {
+		mentionsWorker := workers.MentionsWorker{
+			Interval:          time.Duration(configuration.Workers.MentionsPollSeconds) * time.Second,
+			MaxRepliesPerHour: configuration.Workers.MaxRepliesPerHour,
+		}
+		go mentionsWorker.Run(stopWorkers)
+	}
+
+	if configuration.Workers.FeedsPollSeconds > 0 {
+		feedsWorker := workers.FeedsWorker{
+			Interval: time.Duration(configuration.Workers.FeedsPollSeconds) * time.Second,
+		}
+		go feedsWorker.Run(stopWorkers)
+	}
+
+	if configuration.Workers.EvergreenPollSeconds > 0 {
+		evergreenWorker := workers.EvergreenWorker{
+			Interval:  time.Duration(configuration.Workers.EvergreenPollSeconds) * time.Second,
+			Lookahead: time.Duration(configuration.Workers.EvergreenLookaheadHours) * time.Hour,
+		}
+		go evergreenWorker.Run(stopWorkers)
+	}
+
+	if configuration.Workers.WebhooksPollSeconds > 0 {
+		webhooksWorker := workers.WebhooksWorker{
+			Interval: time.Duration(configuration.Workers.WebhooksPollSeconds) * time.Second,
+		}
+		go webhooksWorker.Run(stopWorkers)
+	}
+
 	server := http.Server{
 		Addr:    *addr,
 		Handler: router,
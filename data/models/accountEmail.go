@@ -0,0 +1,23 @@
+package models
+
+import "strings"
+
+// AccountEmail represents a model for account requests that only need an email address,
+// such as resending a verification email, complete with validation
+type AccountEmail struct {
+	Email string `json:"email"`
+}
+
+// Sanitise sanitises fields for the model, such as trimming whitespace
+func (accountEmail *AccountEmail) Sanitise() {
+	accountEmail.Email = strings.TrimSpace(accountEmail.Email)
+}
+
+// Validate provides validation logic for the email address
+func (accountEmail *AccountEmail) Validate() ([]ValidationError, error) {
+	var validationErrors []ValidationError
+	validationErrors = validateRequired(validationErrors, accountEmail.Email, "email")
+	validationErrors = validateEmail(validationErrors, accountEmail.Email, "email")
+
+	return validationErrors, nil
+}
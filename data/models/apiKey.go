@@ -0,0 +1,58 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/sironfoot/go-twitter-bot/data/db"
+)
+
+// APIKey represents a model for creating an API key for a service User
+// using REST API endpoints, complete with validation
+type APIKey struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresOn *time.Time `json:"expiresOn"`
+}
+
+// Sanitise sanitises fields for the model, such as trimming whitespace
+func (apiKey *APIKey) Sanitise() {
+	apiKey.Name = strings.TrimSpace(apiKey.Name)
+
+	var scopes []string
+	for _, scope := range apiKey.Scopes {
+		if scope = strings.ToLower(strings.TrimSpace(scope)); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	apiKey.Scopes = scopes
+}
+
+// Validate provides validation logic for creating an API key
+func (apiKey *APIKey) Validate() ([]ValidationError, error) {
+	var validationErrors []ValidationError
+	validationErrors = validateRequired(validationErrors, apiKey.Name, "name")
+	validationErrors = validateMaxLength(validationErrors, apiKey.Name, 100, "name")
+
+	if len(apiKey.Scopes) == 0 {
+		validationErrors = append(validationErrors, ValidationError{
+			FieldName: "scopes",
+			Type:      ValidationTypeRequired,
+			Message:   "'scopes' is required.",
+		})
+	}
+
+	for _, scope := range apiKey.Scopes {
+		validationErrors = validateOneOf(validationErrors, scope, db.APIKeyScopes, "scopes")
+	}
+
+	if apiKey.ExpiresOn != nil && !apiKey.ExpiresOn.After(time.Now()) {
+		validationErrors = append(validationErrors, ValidationError{
+			FieldName: "expiresOn",
+			Type:      ValidationTypeInvalid,
+			Message:   "'expiresOn' must be in the future.",
+		})
+	}
+
+	return validationErrors, nil
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/sironfoot/go-twitter-bot/data/db"
+)
+
+// DuplicateSettings represents a model for updating how a TwitterAccount handles duplicate Tweets, posted
+// to the update duplicate settings REST API endpoint, complete with validation
+type DuplicateSettings struct {
+	Policy     string `json:"policy"`
+	WindowDays int    `json:"windowDays"`
+}
+
+// Sanitise sanitises fields for the model, such as trimming whitespace
+func (settings *DuplicateSettings) Sanitise() {
+	settings.Policy = strings.TrimSpace(settings.Policy)
+}
+
+// Validate provides validation logic for updating DuplicateSettings
+func (settings *DuplicateSettings) Validate() ([]ValidationError, error) {
+	var validationErrors []ValidationError
+
+	validationErrors = validateRequired(validationErrors, settings.Policy, "policy")
+	if settings.Policy != "" {
+		validationErrors = validateOneOf(validationErrors, settings.Policy, db.DuplicatePolicies, "policy")
+	}
+
+	if settings.WindowDays < 0 {
+		validationErrors = append(validationErrors, ValidationError{
+			FieldName: "windowDays",
+			Type:      ValidationTypeInvalid,
+			Message:   "'windowDays' cannot be negative.",
+		})
+	}
+
+	return validationErrors, nil
+}
+
+// ValidateCreate provides validation logic for DuplicateSettings, which are never created only updated
+func (settings *DuplicateSettings) ValidateCreate() ([]ValidationError, error) {
+	return settings.Validate()
+}
+
+// ValidateUpdate provides validation logic for updating DuplicateSettings,
+// 'id' is the database primary key ID of the TwitterAccount being updated.
+func (settings *DuplicateSettings) ValidateUpdate(id string) ([]ValidationError, error) {
+	return settings.Validate()
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// EvergreenSlotFormat is the time.Parse layout for evergreen slots, a time of day in the TwitterAccount's time zone
+const EvergreenSlotFormat = "15:04"
+
+// EvergreenItem represents a model for creating/updating a reusable post in a TwitterAccount's
+// evergreen library, posted to the create/update evergreen REST API endpoints, complete with validation
+type EvergreenItem struct {
+	Text          string `json:"text"`
+	Category      string `json:"category"`
+	MinRepostDays int    `json:"minRepostDays"`
+	IsPaused      bool   `json:"isPaused"`
+}
+
+// Sanitise sanitises fields for the model, such as trimming whitespace
+func (item *EvergreenItem) Sanitise() {
+	item.Text = strings.TrimSpace(item.Text)
+	item.Category = strings.TrimSpace(item.Category)
+}
+
+// Validate provides validation logic for creating or updating an EvergreenItem
+func (item *EvergreenItem) Validate() ([]ValidationError, error) {
+	var validationErrors []ValidationError
+
+	validationErrors = validateRequired(validationErrors, item.Text, "text")
+	validationErrors = validateTweetText(validationErrors, item.Text, time.Now().UTC(), "text")
+	validationErrors = validateMaxLength(validationErrors, item.Category, 50, "category")
+
+	if item.MinRepostDays < 0 {
+		validationErrors = append(validationErrors, ValidationError{
+			FieldName: "minRepostDays",
+			Type:      ValidationTypeInvalid,
+			Message:   "'minRepostDays' cannot be negative.",
+		})
+	}
+
+	return validationErrors, nil
+}
+
+// ValidateCreate provides validation logic for creating a new EvergreenItem only
+func (item *EvergreenItem) ValidateCreate() ([]ValidationError, error) {
+	return item.Validate()
+}
+
+// ValidateUpdate provides validation logic for updating an existing EvergreenItem only,
+// 'id' is the database primary key ID of the current EvergreenItem being updated.
+func (item *EvergreenItem) ValidateUpdate(id string) ([]ValidationError, error) {
+	return item.Validate()
+}
+
+// EvergreenSettings represents a model for updating when a TwitterAccount's evergreen library
+// fills its schedule, posted to the update evergreen settings REST API endpoint, complete with validation
+type EvergreenSettings struct {
+	Slots        []string `json:"slots"`
+	NoRepeatDays int      `json:"noRepeatDays"`
+}
+
+// Sanitise sanitises fields for the model, such as trimming whitespace
+func (settings *EvergreenSettings) Sanitise() {
+	if settings.Slots == nil {
+		settings.Slots = make([]string, 0)
+	}
+
+	for i := range settings.Slots {
+		settings.Slots[i] = strings.TrimSpace(settings.Slots[i])
+	}
+}
+
+// Validate provides validation logic for updating EvergreenSettings
+func (settings *EvergreenSettings) Validate() ([]ValidationError, error) {
+	var validationErrors []ValidationError
+
+	seen := make(map[string]bool)
+
+	for _, slot := range settings.Slots {
+		if _, err := time.Parse(EvergreenSlotFormat, slot); err != nil {
+			validationErrors = append(validationErrors, ValidationError{
+				FieldName: "slots",
+				Type:      ValidationTypeInvalid,
+				Message:   "'" + slot + "' is not a valid slot, use a 24 hour time such as 09:30.",
+			})
+		} else if seen[slot] {
+			validationErrors = append(validationErrors, ValidationError{
+				FieldName: "slots",
+				Type:      ValidationTypeInvalid,
+				Message:   "slot '" + slot + "' is listed more than once.",
+			})
+		}
+
+		seen[slot] = true
+	}
+
+	if settings.NoRepeatDays < 0 {
+		validationErrors = append(validationErrors, ValidationError{
+			FieldName: "noRepeatDays",
+			Type:      ValidationTypeInvalid,
+			Message:   "'noRepeatDays' cannot be negative.",
+		})
+	}
+
+	return validationErrors, nil
+}
+
+// ValidateCreate provides validation logic for EvergreenSettings, which are never created only updated
+func (settings *EvergreenSettings) ValidateCreate() ([]ValidationError, error) {
+	return settings.Validate()
+}
+
+// ValidateUpdate provides validation logic for updating EvergreenSettings,
+// 'id' is the database primary key ID of the TwitterAccount being updated.
+func (settings *EvergreenSettings) ValidateUpdate(id string) ([]ValidationError, error) {
+	return settings.Validate()
+}
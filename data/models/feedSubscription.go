@@ -0,0 +1,92 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/sironfoot/go-twitter-bot/lib/feed"
+	"github.com/sironfoot/go-twitter-bot/lib/tweettext"
+)
+
+// MinFeedPollIntervalMinutes is the shortest allowed time between polls of a feed
+const MinFeedPollIntervalMinutes = 5
+
+// FeedSubscription represents a model for creating/updating an RSS/Atom feed subscription
+// posted to the create/update feed REST API endpoints, complete with validation
+type FeedSubscription struct {
+	URL                 string `json:"url"`
+	PollIntervalMinutes int    `json:"pollIntervalMinutes"`
+	Template            string `json:"template"`
+	CreateAsDraft       bool   `json:"createAsDraft"`
+}
+
+// Sanitise sanitises fields for the model, such as trimming whitespace
+func (subscription *FeedSubscription) Sanitise() {
+	subscription.URL = strings.TrimSpace(subscription.URL)
+	subscription.Template = strings.TrimSpace(subscription.Template)
+}
+
+// Validate provides validation logic for creating or updating a FeedSubscription
+func (subscription *FeedSubscription) Validate() ([]ValidationError, error) {
+	var validationErrors []ValidationError
+
+	validationErrors = validateRequired(validationErrors, subscription.URL, "url")
+	if subscription.URL != "" {
+		feedURL, err := url.Parse(subscription.URL)
+		if err != nil || (feedURL.Scheme != "http" && feedURL.Scheme != "https") || feedURL.Host == "" {
+			validationErrors = append(validationErrors, ValidationError{
+				FieldName: "url",
+				Type:      ValidationTypeInvalid,
+				Message:   "'url' must be an absolute http or https URL.",
+			})
+		} else if feed.CheckHost(feedURL.Hostname()) == feed.ErrPrivateAddress {
+			validationErrors = append(validationErrors, ValidationError{
+				FieldName: "url",
+				Type:      ValidationTypeInvalid,
+				Message:   "'url' can't be a loopback, private or link-local address.",
+			})
+		}
+	}
+
+	if subscription.PollIntervalMinutes < MinFeedPollIntervalMinutes {
+		validationErrors = append(validationErrors, ValidationError{
+			FieldName: "pollIntervalMinutes",
+			Type:      ValidationTypeInvalid,
+			Message:   fmt.Sprintf("'pollIntervalMinutes' cannot be less than %d.", MinFeedPollIntervalMinutes),
+		})
+	}
+
+	validationErrors = validateRequired(validationErrors, subscription.Template, "template")
+	if subscription.Template != "" {
+		sampleFeed := feed.Feed{Title: "Sample Blog"}
+
+		text, err := feed.RenderTemplate(subscription.Template, sampleFeed, feed.SampleItem)
+		if err != nil {
+			validationErrors = append(validationErrors, ValidationError{
+				FieldName: "template",
+				Type:      ValidationTypeInvalid,
+				Message:   "'template' is not a valid template: " + err.Error(),
+			})
+		} else if tweettext.WeightedLength(text) > TweetMaxLength {
+			validationErrors = append(validationErrors, ValidationError{
+				FieldName: "template",
+				Type:      ValidationTypeMaxLength,
+				Message:   fmt.Sprintf("'template' renders to more than %d characters for a sample post.", TweetMaxLength),
+			})
+		}
+	}
+
+	return validationErrors, nil
+}
+
+// ValidateCreate provides validation logic for creating a new FeedSubscription only
+func (subscription *FeedSubscription) ValidateCreate() ([]ValidationError, error) {
+	return subscription.Validate()
+}
+
+// ValidateUpdate provides validation logic for updating an existing FeedSubscription only,
+// 'id' is the database primary key ID of the current FeedSubscription being updated.
+func (subscription *FeedSubscription) ValidateUpdate(id string) ([]ValidationError, error) {
+	return subscription.Validate()
+}
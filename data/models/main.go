@@ -3,21 +3,23 @@ package models
 import (
 	"fmt"
 	"regexp"
+	"strings"
 	"unicode/utf8"
 )
 
 // Model is an interface for all model types
 type Model interface {
-	TrimFields()
+	Sanitise()
 	ValidateCreate() ([]ValidationError, error)
 	ValidateUpdate(id string) ([]ValidationError, error)
 }
 
 // ValidationError contains information on a single validate error when validating a model
 type ValidationError struct {
-	FieldName string `json:"fieldName"`
-	Type      string `json:"code"`
-	Message   string `json:"message"`
+	FieldName      string   `json:"fieldName"`
+	Type           string   `json:"code"`
+	Message        string   `json:"message"`
+	ConflictingIDs []string `json:"conflictingIds,omitempty"`
 }
 
 const (
@@ -43,6 +45,14 @@ const (
 	// ValidationTypeNotFound represents fields where a corresponding
 	// record cannot be found based on the field value provided.
 	ValidationTypeNotFound = "not_found"
+
+	// ValidationTypeDuplicate represents fields that are the same as an
+	// existing record once normalised, such as Tweet text
+	ValidationTypeDuplicate = "duplicate"
+
+	// ValidationTypeSimilar represents fields that are very similar to
+	// an existing record, though not an exact duplicate
+	ValidationTypeSimilar = "similar"
 )
 
 var isEmail = regexp.MustCompile(`(?i)^.+@.+\.[a-z]+$`)
@@ -94,3 +104,17 @@ func validateMinLength(validationErrors []ValidationError, fieldValue string, mi
 
 	return validationErrors
 }
+
+func validateOneOf(validationErrors []ValidationError, fieldValue string, allowedValues []string, fieldName string) []ValidationError {
+	for _, allowedValue := range allowedValues {
+		if fieldValue == allowedValue {
+			return validationErrors
+		}
+	}
+
+	return append(validationErrors, ValidationError{
+		FieldName: fieldName,
+		Type:      ValidationTypeInvalid,
+		Message:   fmt.Sprintf("'%s' must be one of: %s.", fieldName, strings.Join(allowedValues, ", ")),
+	})
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/sironfoot/go-twitter-bot/data/db"
+)
+
+// Organisation represents a model for creating and updating Organisations
+// using REST API endpoints, complete with validation
+type Organisation struct {
+	Name string `json:"name"`
+}
+
+// Sanitise sanitises fields for the model, such as trimming whitespace
+func (organisation *Organisation) Sanitise() {
+	organisation.Name = strings.TrimSpace(organisation.Name)
+}
+
+// Validate provides validation logic for creating and updating an Organisation
+func (organisation *Organisation) Validate() ([]ValidationError, error) {
+	var validationErrors []ValidationError
+	validationErrors = validateRequired(validationErrors, organisation.Name, "name")
+	validationErrors = validateMaxLength(validationErrors, organisation.Name, 100, "name")
+
+	return validationErrors, nil
+}
+
+// OrganisationInvitation represents a model for inviting someone to join an Organisation by
+// email address using REST API endpoints, complete with validation
+type OrganisationInvitation struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+// Sanitise sanitises fields for the model, such as trimming whitespace
+func (invitation *OrganisationInvitation) Sanitise() {
+	invitation.Email = strings.TrimSpace(invitation.Email)
+	invitation.Role = strings.ToLower(strings.TrimSpace(invitation.Role))
+}
+
+// Validate provides validation logic for inviting someone to an Organisation
+func (invitation *OrganisationInvitation) Validate() ([]ValidationError, error) {
+	var validationErrors []ValidationError
+	validationErrors = validateRequired(validationErrors, invitation.Email, "email")
+	validationErrors = validateEmail(validationErrors, invitation.Email, "email")
+	validationErrors = validateRequired(validationErrors, invitation.Role, "role")
+	if invitation.Role != "" {
+		validationErrors = validateOneOf(validationErrors, invitation.Role, db.TwitterAccountRoles, "role")
+	}
+
+	return validationErrors, nil
+}
+
+// TwitterAccountOrganisation represents a model for moving a TwitterAccount to an Organisation
+// using REST API endpoints, a null or empty OrganisationID makes it a personal TwitterAccount again
+type TwitterAccountOrganisation struct {
+	OrganisationID *string `json:"organisationId"`
+}
+
+// Sanitise sanitises fields for the model, such as trimming whitespace
+func (accountOrganisation *TwitterAccountOrganisation) Sanitise() {
+	if accountOrganisation.OrganisationID != nil {
+		organisationID := strings.TrimSpace(*accountOrganisation.OrganisationID)
+		if organisationID == "" {
+			accountOrganisation.OrganisationID = nil
+		} else {
+			accountOrganisation.OrganisationID = &organisationID
+		}
+	}
+}
+
+// Validate provides validation logic for moving a TwitterAccount to an Organisation
+func (accountOrganisation *TwitterAccountOrganisation) Validate() ([]ValidationError, error) {
+	return nil, nil
+}
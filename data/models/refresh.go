@@ -0,0 +1,22 @@
+package models
+
+import "strings"
+
+// Refresh represents a model for exchanging a refresh token for a new access token
+// using REST API endpoints, complete with validation
+type Refresh struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// Sanitise sanitises fields for the model, such as trimming whitespace
+func (refresh *Refresh) Sanitise() {
+	refresh.RefreshToken = strings.TrimSpace(refresh.RefreshToken)
+}
+
+// Validate provides validation logic for a refresh request
+func (refresh *Refresh) Validate() ([]ValidationError, error) {
+	var validationErrors []ValidationError
+	validationErrors = validateRequired(validationErrors, refresh.RefreshToken, "refreshToken")
+
+	return validationErrors, nil
+}
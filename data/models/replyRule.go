@@ -0,0 +1,87 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/sironfoot/go-twitter-bot/data/db"
+	"github.com/sironfoot/go-twitter-bot/lib/tweettext"
+)
+
+// ReplyRule represents a model for creating/updating an auto-reply rule posted
+// to the create/update reply rule REST API endpoints, complete with validation
+type ReplyRule struct {
+	MatchType       string `json:"matchType"`
+	Pattern         string `json:"pattern"`
+	ReplyTemplate   string `json:"replyTemplate"`
+	CooldownMinutes int    `json:"cooldownMinutes"`
+	IsEnabled       bool   `json:"isEnabled"`
+}
+
+// Sanitise sanitises fields for the model, such as trimming whitespace
+func (rule *ReplyRule) Sanitise() {
+	rule.MatchType = strings.TrimSpace(rule.MatchType)
+	rule.ReplyTemplate = strings.TrimSpace(rule.ReplyTemplate)
+
+	if rule.MatchType != db.ReplyRuleMatchRegex {
+		rule.Pattern = strings.TrimSpace(rule.Pattern)
+	}
+
+	if rule.MatchType == db.ReplyRuleMatchFromUser {
+		rule.Pattern = strings.TrimPrefix(rule.Pattern, "@")
+	}
+}
+
+// Validate provides validation logic for creating or updating a ReplyRule
+func (rule *ReplyRule) Validate() ([]ValidationError, error) {
+	var validationErrors []ValidationError
+
+	validationErrors = validateRequired(validationErrors, rule.MatchType, "matchType")
+	if rule.MatchType != "" {
+		validationErrors = validateOneOf(validationErrors, rule.MatchType, db.ReplyRuleMatchTypes, "matchType")
+	}
+
+	validationErrors = validateRequired(validationErrors, rule.Pattern, "pattern")
+	if rule.MatchType == db.ReplyRuleMatchRegex && rule.Pattern != "" {
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			validationErrors = append(validationErrors, ValidationError{
+				FieldName: "pattern",
+				Type:      ValidationTypeInvalid,
+				Message:   "'pattern' is not a valid regular expression: " + err.Error(),
+			})
+		}
+	}
+
+	validationErrors = validateRequired(validationErrors, rule.ReplyTemplate, "replyTemplate")
+	validationErrors = validateMaxLength(validationErrors, rule.ReplyTemplate, 1000, "replyTemplate")
+	if rule.ReplyTemplate != "" {
+		if _, err := tweettext.RenderReply(rule.ReplyTemplate, tweettext.SampleReplyData); err != nil {
+			validationErrors = append(validationErrors, ValidationError{
+				FieldName: "replyTemplate",
+				Type:      ValidationTypeInvalid,
+				Message:   "'replyTemplate' is not a valid template: " + err.Error(),
+			})
+		}
+	}
+
+	if rule.CooldownMinutes < 0 {
+		validationErrors = append(validationErrors, ValidationError{
+			FieldName: "cooldownMinutes",
+			Type:      ValidationTypeInvalid,
+			Message:   "'cooldownMinutes' cannot be negative.",
+		})
+	}
+
+	return validationErrors, nil
+}
+
+// ValidateCreate provides validation logic for creating a new ReplyRule only
+func (rule *ReplyRule) ValidateCreate() ([]ValidationError, error) {
+	return rule.Validate()
+}
+
+// ValidateUpdate provides validation logic for updating an existing ReplyRule only,
+// 'id' is the database primary key ID of the current ReplyRule being updated.
+func (rule *ReplyRule) ValidateUpdate(id string) ([]ValidationError, error) {
+	return rule.Validate()
+}
@@ -0,0 +1,25 @@
+package models
+
+import "strings"
+
+// ResetPassword represents a model for resetting a forgotten password with the token
+// from a password reset email, complete with validation
+type ResetPassword struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// Sanitise sanitises fields for the model, such as trimming whitespace
+func (resetPassword *ResetPassword) Sanitise() {
+	resetPassword.Token = strings.TrimSpace(resetPassword.Token)
+}
+
+// Validate provides validation logic for resetting a password, with the same password rules as creating a User
+func (resetPassword *ResetPassword) Validate() ([]ValidationError, error) {
+	var validationErrors []ValidationError
+	validationErrors = validateRequired(validationErrors, resetPassword.Token, "token")
+	validationErrors = validateRequired(validationErrors, resetPassword.Password, "password")
+	validationErrors = validateMinLength(validationErrors, resetPassword.Password, 8, "password")
+
+	return validationErrors, nil
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+var isTemplateName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// TemplateSettings represents a model for updating the values available to a TwitterAccount's
+// Tweet templates, posted to the update template settings REST API endpoint, complete with validation
+type TemplateSettings struct {
+	TimeZone  string            `json:"timeZone"`
+	Variables map[string]string `json:"variables"`
+	Counters  map[string]int    `json:"counters"`
+}
+
+// Sanitise sanitises fields for the model, such as trimming whitespace
+func (settings *TemplateSettings) Sanitise() {
+	settings.TimeZone = strings.TrimSpace(settings.TimeZone)
+
+	if settings.Variables == nil {
+		settings.Variables = make(map[string]string)
+	}
+
+	if settings.Counters == nil {
+		settings.Counters = make(map[string]int)
+	}
+}
+
+// Validate provides validation logic for updating TemplateSettings
+func (settings *TemplateSettings) Validate() ([]ValidationError, error) {
+	var validationErrors []ValidationError
+
+	validationErrors = validateRequired(validationErrors, settings.TimeZone, "timeZone")
+	if settings.TimeZone != "" {
+		if _, err := time.LoadLocation(settings.TimeZone); err != nil {
+			validationErrors = append(validationErrors, ValidationError{
+				FieldName: "timeZone",
+				Type:      ValidationTypeInvalid,
+				Message:   "'timeZone' is not a valid IANA time zone name, e.g. Europe/London.",
+			})
+		}
+	}
+
+	for name, value := range settings.Variables {
+		if !isTemplateName.MatchString(name) {
+			validationErrors = append(validationErrors, ValidationError{
+				FieldName: "variables",
+				Type:      ValidationTypeInvalid,
+				Message:   "'" + name + "' is not a valid variable name, use letters, numbers and underscores only.",
+			})
+		}
+
+		validationErrors = validateMaxLength(validationErrors, value, TweetMaxLength, "variables")
+	}
+
+	for name, value := range settings.Counters {
+		if !isTemplateName.MatchString(name) {
+			validationErrors = append(validationErrors, ValidationError{
+				FieldName: "counters",
+				Type:      ValidationTypeInvalid,
+				Message:   "'" + name + "' is not a valid counter name, use letters, numbers and underscores only.",
+			})
+		}
+
+		if value < 0 {
+			validationErrors = append(validationErrors, ValidationError{
+				FieldName: "counters",
+				Type:      ValidationTypeInvalid,
+				Message:   "counter '" + name + "' cannot be negative.",
+			})
+		}
+	}
+
+	return validationErrors, nil
+}
+
+// ValidateCreate provides validation logic for TemplateSettings, which are never created only updated
+func (settings *TemplateSettings) ValidateCreate() ([]ValidationError, error) {
+	return settings.Validate()
+}
+
+// ValidateUpdate provides validation logic for updating TemplateSettings,
+// 'id' is the database primary key ID of the TwitterAccount being updated.
+func (settings *TemplateSettings) ValidateUpdate(id string) ([]ValidationError, error) {
+	return settings.Validate()
+}
@@ -0,0 +1,55 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sironfoot/go-twitter-bot/data/models"
+)
+
+func TestAPIKeyValidate(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(24 * time.Hour)
+
+	var testCases = []struct {
+		description    string
+		model          models.APIKey
+		expectedErrors []expectedError
+	}{
+		{"no errors", models.APIKey{Name: "Scheduler", Scopes: []string{" Read ", "write"}, ExpiresOn: &future}, []expectedError{}},
+		{"name required", models.APIKey{Name: " ", Scopes: []string{"read"}}, []expectedError{
+			{"name", models.ValidationTypeRequired},
+		}},
+		{"scopes required", models.APIKey{Name: "Scheduler", Scopes: []string{" "}}, []expectedError{
+			{"scopes", models.ValidationTypeRequired},
+		}},
+		{"unknown scope", models.APIKey{Name: "Scheduler", Scopes: []string{"read", "admin"}}, []expectedError{
+			{"scopes", models.ValidationTypeInvalid},
+		}},
+		{"expired", models.APIKey{Name: "Scheduler", Scopes: []string{"read"}, ExpiresOn: &past}, []expectedError{
+			{"expiresOn", models.ValidationTypeInvalid},
+		}},
+	}
+
+	for _, testCase := range testCases {
+		testCase.model.Sanitise()
+		validationErrors, err := testCase.model.Validate()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(validationErrors) != len(testCase.expectedErrors) {
+			t.Errorf("test case '%s': expected %d validation error(s) but got %d: %s",
+				testCase.description, len(testCase.expectedErrors), len(validationErrors), validationErrors)
+			continue
+		}
+
+		for i, validationError := range validationErrors {
+			expected := testCase.expectedErrors[i]
+			if validationError.FieldName != expected.fieldName || validationError.Type != expected.typeName {
+				t.Errorf("test case '%s': expected validation error '%s(%s)', got '%s(%s)'",
+					testCase.description, expected.fieldName, expected.typeName, validationError.FieldName, validationError.Type)
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package models_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sironfoot/go-twitter-bot/data/models"
+)
+
+func TestEvergreenItemValidate(t *testing.T) {
+	testCases := []testCase{
+		{
+			description: "no errors",
+			model: &models.EvergreenItem{
+				Text:          "Have you read our getting started guide? https://example.com/guide",
+				Category:      "guides",
+				MinRepostDays: 14,
+			},
+			expectedErrors: []expectedError{},
+		},
+		{
+			description: "text required",
+			model: &models.EvergreenItem{
+				Text: "   ",
+			},
+			expectedErrors: []expectedError{
+				{"text", models.ValidationTypeRequired},
+			},
+		},
+		{
+			description: "category too long and negative repost interval",
+			model: &models.EvergreenItem{
+				Text:          "Evergreen",
+				Category:      strings.Repeat("a", 51),
+				MinRepostDays: -1,
+			},
+			expectedErrors: []expectedError{
+				{"category", models.ValidationTypeMaxLength},
+				{"minRepostDays", models.ValidationTypeInvalid},
+			},
+		},
+	}
+
+	runValidationTest(t, testCases, func(item models.Model, id string) ([]models.ValidationError, error) {
+		item.Sanitise()
+		return item.ValidateCreate()
+	})
+}
+
+func TestEvergreenSettingsValidate(t *testing.T) {
+	testCases := []testCase{
+		{
+			description: "no errors",
+			model: &models.EvergreenSettings{
+				Slots:        []string{"09:00", " 17:30 "},
+				NoRepeatDays: 30,
+			},
+			expectedErrors: []expectedError{},
+		},
+		{
+			description:    "no slots",
+			model:          &models.EvergreenSettings{},
+			expectedErrors: []expectedError{},
+		},
+		{
+			description: "invalid and duplicate slots",
+			model: &models.EvergreenSettings{
+				Slots:        []string{"9am", "09:00", "09:00"},
+				NoRepeatDays: -1,
+			},
+			expectedErrors: []expectedError{
+				{"slots", models.ValidationTypeInvalid},
+				{"slots", models.ValidationTypeInvalid},
+				{"noRepeatDays", models.ValidationTypeInvalid},
+			},
+		},
+	}
+
+	runValidationTest(t, testCases, func(settings models.Model, id string) ([]models.ValidationError, error) {
+		settings.Sanitise()
+		return settings.ValidateUpdate(id)
+	})
+}
@@ -0,0 +1,81 @@
+package models_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sironfoot/go-twitter-bot/data/models"
+)
+
+func TestFeedSubscriptionValidate(t *testing.T) {
+	testCases := []testCase{
+		{
+			description: "no errors",
+			model: &models.FeedSubscription{
+				URL:                 "https://example.com/feed.xml",
+				PollIntervalMinutes: 60,
+				Template:            "New post: {{.Title}} {{.Link}}",
+			},
+			expectedErrors: []expectedError{},
+		},
+		{
+			description: "url required, template required",
+			model: &models.FeedSubscription{
+				PollIntervalMinutes: 60,
+			},
+			expectedErrors: []expectedError{
+				{"url", models.ValidationTypeRequired},
+				{"template", models.ValidationTypeRequired},
+			},
+		},
+		{
+			description: "url not http, poll interval too short",
+			model: &models.FeedSubscription{
+				URL:                 "ftp://example.com/feed.xml",
+				PollIntervalMinutes: 1,
+				Template:            "{{.Title}}",
+			},
+			expectedErrors: []expectedError{
+				{"url", models.ValidationTypeInvalid},
+				{"pollIntervalMinutes", models.ValidationTypeInvalid},
+			},
+		},
+		{
+			description: "url is a cloud metadata address",
+			model: &models.FeedSubscription{
+				URL:                 "http://169.254.169.254/latest/meta-data/",
+				PollIntervalMinutes: 60,
+				Template:            "{{.Title}}",
+			},
+			expectedErrors: []expectedError{
+				{"url", models.ValidationTypeInvalid},
+			},
+		},
+		{
+			description: "template has unknown field",
+			model: &models.FeedSubscription{
+				URL:                 "https://example.com/feed.xml",
+				PollIntervalMinutes: 60,
+				Template:            "{{.Author}}",
+			},
+			expectedErrors: []expectedError{
+				{"template", models.ValidationTypeInvalid},
+			},
+		},
+		{
+			description: "template renders too long",
+			model: &models.FeedSubscription{
+				URL:                 "https://example.com/feed.xml",
+				PollIntervalMinutes: 60,
+				Template:            strings.Repeat("a", 250) + " {{.Title}} {{.Link}}",
+			},
+			expectedErrors: []expectedError{
+				{"template", models.ValidationTypeMaxLength},
+			},
+		},
+	}
+
+	runValidationTest(t, testCases, func(subscription models.Model, id string) ([]models.ValidationError, error) {
+		return subscription.ValidateCreate()
+	})
+}
@@ -0,0 +1,103 @@
+package models_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sironfoot/go-twitter-bot/data/models"
+)
+
+func TestOrganisationValidate(t *testing.T) {
+	var testCases = []struct {
+		description    string
+		model          models.Organisation
+		expectedErrors []expectedError
+	}{
+		{"no errors", models.Organisation{Name: " Marketing "}, []expectedError{}},
+		{"name required", models.Organisation{Name: "  "}, []expectedError{
+			{"name", models.ValidationTypeRequired},
+		}},
+		{"name too long", models.Organisation{Name: strings.Repeat("a", 101)}, []expectedError{
+			{"name", models.ValidationTypeMaxLength},
+		}},
+	}
+
+	for _, testCase := range testCases {
+		testCase.model.Sanitise()
+		validationErrors, err := testCase.model.Validate()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(validationErrors) != len(testCase.expectedErrors) {
+			t.Errorf("test case '%s': expected %d validation error(s) but got %d: %s",
+				testCase.description, len(testCase.expectedErrors), len(validationErrors), validationErrors)
+			continue
+		}
+
+		for i, validationError := range validationErrors {
+			expected := testCase.expectedErrors[i]
+			if validationError.FieldName != expected.fieldName || validationError.Type != expected.typeName {
+				t.Errorf("test case '%s': expected validation error '%s(%s)', got '%s(%s)'",
+					testCase.description, expected.fieldName, expected.typeName, validationError.FieldName, validationError.Type)
+			}
+		}
+	}
+}
+
+func TestOrganisationInvitationValidate(t *testing.T) {
+	var testCases = []struct {
+		description    string
+		model          models.OrganisationInvitation
+		expectedErrors []expectedError
+	}{
+		{"no errors", models.OrganisationInvitation{Email: " someone@example.com ", Role: " Viewer "}, []expectedError{}},
+		{"invalid email", models.OrganisationInvitation{Email: "someone", Role: "viewer"}, []expectedError{
+			{"email", models.ValidationTypeInvalid},
+		}},
+		{"role required", models.OrganisationInvitation{Email: "someone@example.com", Role: ""}, []expectedError{
+			{"role", models.ValidationTypeRequired},
+		}},
+		{"unknown role", models.OrganisationInvitation{Email: "someone@example.com", Role: "admin"}, []expectedError{
+			{"role", models.ValidationTypeInvalid},
+		}},
+	}
+
+	for _, testCase := range testCases {
+		testCase.model.Sanitise()
+		validationErrors, err := testCase.model.Validate()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(validationErrors) != len(testCase.expectedErrors) {
+			t.Errorf("test case '%s': expected %d validation error(s) but got %d: %s",
+				testCase.description, len(testCase.expectedErrors), len(validationErrors), validationErrors)
+			continue
+		}
+
+		for i, validationError := range validationErrors {
+			expected := testCase.expectedErrors[i]
+			if validationError.FieldName != expected.fieldName || validationError.Type != expected.typeName {
+				t.Errorf("test case '%s': expected validation error '%s(%s)', got '%s(%s)'",
+					testCase.description, expected.fieldName, expected.typeName, validationError.FieldName, validationError.Type)
+			}
+		}
+	}
+}
+
+func TestTwitterAccountOrganisationSanitise(t *testing.T) {
+	empty, id := " ", " 8f7a3c1e-0000-0000-0000-000000000000 "
+
+	model := models.TwitterAccountOrganisation{OrganisationID: &empty}
+	model.Sanitise()
+	if model.OrganisationID != nil {
+		t.Errorf("expected an empty organisationId to be sanitised to null, got '%s'", *model.OrganisationID)
+	}
+
+	model = models.TwitterAccountOrganisation{OrganisationID: &id}
+	model.Sanitise()
+	if model.OrganisationID == nil || *model.OrganisationID != strings.TrimSpace(id) {
+		t.Errorf("expected organisationId to be trimmed, got %v", model.OrganisationID)
+	}
+}
@@ -0,0 +1,93 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/sironfoot/go-twitter-bot/data/db"
+	"github.com/sironfoot/go-twitter-bot/data/models"
+)
+
+func TestReplyRuleValidate(t *testing.T) {
+	testCases := []testCase{
+		{
+			description: "no errors",
+			model: &models.ReplyRule{
+				MatchType:     db.ReplyRuleMatchKeyword,
+				Pattern:       "pricing",
+				ReplyTemplate: "Hi {{.Username}}, our prices are at example.com/pricing",
+			},
+			expectedErrors: []expectedError{},
+		},
+		{
+			description: "match type required",
+			model: &models.ReplyRule{
+				Pattern:       "pricing",
+				ReplyTemplate: "Hi",
+			},
+			expectedErrors: []expectedError{
+				{"matchType", models.ValidationTypeRequired},
+			},
+		},
+		{
+			description: "match type invalid",
+			model: &models.ReplyRule{
+				MatchType:     "nonsense",
+				Pattern:       "pricing",
+				ReplyTemplate: "Hi",
+			},
+			expectedErrors: []expectedError{
+				{"matchType", models.ValidationTypeInvalid},
+			},
+		},
+		{
+			description: "regex doesn't compile",
+			model: &models.ReplyRule{
+				MatchType:     db.ReplyRuleMatchRegex,
+				Pattern:       "(price",
+				ReplyTemplate: "Hi",
+			},
+			expectedErrors: []expectedError{
+				{"pattern", models.ValidationTypeInvalid},
+			},
+		},
+		{
+			description: "reply template doesn't parse",
+			model: &models.ReplyRule{
+				MatchType:     db.ReplyRuleMatchFromUser,
+				Pattern:       "@someone",
+				ReplyTemplate: "Hi {{.Username",
+			},
+			expectedErrors: []expectedError{
+				{"replyTemplate", models.ValidationTypeInvalid},
+			},
+		},
+		{
+			description: "reply template uses a field that doesn't exist",
+			model: &models.ReplyRule{
+				MatchType:     db.ReplyRuleMatchKeyword,
+				Pattern:       "pricing",
+				ReplyTemplate: "Hi {{.Foo}}",
+			},
+			expectedErrors: []expectedError{
+				{"replyTemplate", models.ValidationTypeInvalid},
+			},
+		},
+		{
+			description: "pattern and template required, negative cooldown",
+			model: &models.ReplyRule{
+				MatchType:       db.ReplyRuleMatchKeyword,
+				CooldownMinutes: -1,
+			},
+			expectedErrors: []expectedError{
+				{"pattern", models.ValidationTypeRequired},
+				{"replyTemplate", models.ValidationTypeRequired},
+				{"cooldownMinutes", models.ValidationTypeInvalid},
+			},
+		},
+	}
+
+	runValidationTest(t, testCases, func(rule models.Model, id string) ([]models.ValidationError, error) {
+		rule.Sanitise()
+		return rule.ValidateCreate()
+	})
+}
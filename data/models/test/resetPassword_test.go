@@ -0,0 +1,45 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/sironfoot/go-twitter-bot/data/models"
+)
+
+func TestResetPasswordValidate(t *testing.T) {
+	var testCases = []struct {
+		description    string
+		model          models.ResetPassword
+		expectedErrors []expectedError
+	}{
+		{"no errors", models.ResetPassword{Token: "abc", Password: "Password1"}, []expectedError{}},
+		{"token required", models.ResetPassword{Token: "   ", Password: "Password1"}, []expectedError{
+			{"token", models.ValidationTypeRequired},
+		}},
+		{"password too short", models.ResetPassword{Token: "abc", Password: "short"}, []expectedError{
+			{"password", models.ValidationTypeMinLength},
+		}},
+	}
+
+	for _, testCase := range testCases {
+		testCase.model.Sanitise()
+		validationErrors, err := testCase.model.Validate()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(validationErrors) != len(testCase.expectedErrors) {
+			t.Errorf("test case '%s': expected %d validation error(s) but got %d: %s",
+				testCase.description, len(testCase.expectedErrors), len(validationErrors), validationErrors)
+			continue
+		}
+
+		for i, validationError := range validationErrors {
+			expected := testCase.expectedErrors[i]
+			if validationError.FieldName != expected.fieldName || validationError.Type != expected.typeName {
+				t.Errorf("test case '%s': expected validation error '%s(%s)', got '%s(%s)'",
+					testCase.description, expected.fieldName, expected.typeName, validationError.FieldName, validationError.Type)
+			}
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package models_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sironfoot/go-twitter-bot/data/db"
+	"github.com/sironfoot/go-twitter-bot/data/models"
+	"github.com/sironfoot/go-twitter-bot/lib/tweetcsv"
+)
+
+func TestTweetValidate(t *testing.T) {
+	testCases := []testCase{
+		{
+			description: "no errors",
+			model: &models.Tweet{
+				Text:   "Some people, when confronted with a problem, think \"I know, I'll use regular expressions.\"",
+				PostOn: time.Now().UTC(),
+			},
+			expectedErrors: []expectedError{},
+		},
+		{
+			description: "text required",
+			model: &models.Tweet{
+				PostOn: time.Now().UTC(),
+			},
+			expectedErrors: []expectedError{
+				{"text", models.ValidationTypeRequired},
+			},
+		},
+		{
+			description: "template is valid",
+			model: &models.Tweet{
+				Text:   `{{daysUntil "2016-04-01"}} days until {{var "product"}} launches! Episode {{next "episode"}}`,
+				PostOn: time.Now().UTC(),
+			},
+			expectedErrors: []expectedError{},
+		},
+		{
+			description: "template has a syntax error",
+			model: &models.Tweet{
+				Text:   `{{daysUntil "2016-04-01"}`,
+				PostOn: time.Now().UTC(),
+			},
+			expectedErrors: []expectedError{
+				{"text", models.ValidationTypeInvalid},
+			},
+		},
+		{
+			description: "weighted length too long",
+			model: &models.Tweet{
+				Text:   strings.Repeat("こ", 141),
+				PostOn: time.Now().UTC(),
+			},
+			expectedErrors: []expectedError{
+				{"text", models.ValidationTypeMaxLength},
+			},
+		},
+		{
+			description: "template renders too long",
+			model: &models.Tweet{
+				Text:   strings.Repeat("a", 270) + ` {{var "a_long_variable_name"}}`,
+				PostOn: time.Now().UTC(),
+			},
+			expectedErrors: []expectedError{
+				{"text", models.ValidationTypeMaxLength},
+			},
+		},
+		{
+			description: "variants are valid",
+			model: &models.Tweet{
+				Text:             "Variants",
+				PostOn:           time.Now().UTC(),
+				VariantSelection: "weighted",
+				Variants: []models.TweetVariant{
+					{Text: "Variant A", Weight: 3},
+					{Text: "Variant B"},
+				},
+			},
+			expectedErrors: []expectedError{},
+		},
+		{
+			description: "unknown variant selection",
+			model: &models.Tweet{
+				Text:             "Variants",
+				PostOn:           time.Now().UTC(),
+				VariantSelection: "best",
+			},
+			expectedErrors: []expectedError{
+				{"variantSelection", models.ValidationTypeInvalid},
+			},
+		},
+		{
+			description: "variant text required and weight not negative",
+			model: &models.Tweet{
+				Text:   "Variants",
+				PostOn: time.Now().UTC(),
+				Variants: []models.TweetVariant{
+					{Text: "   "},
+					{Text: "Variant B", Weight: -1},
+				},
+			},
+			expectedErrors: []expectedError{
+				{"variants", models.ValidationTypeRequired},
+				{"variants", models.ValidationTypeInvalid},
+			},
+		},
+		{
+			description: "tags cannot contain commas",
+			model: &models.Tweet{
+				Text:   "Tags",
+				PostOn: time.Now().UTC(),
+				Tags:   []string{"launch", " ", "promo, sale"},
+			},
+			expectedErrors: []expectedError{
+				{"tags", models.ValidationTypeInvalid},
+			},
+		},
+	}
+
+	runValidationTest(t, testCases, func(tweet models.Model, id string) ([]models.ValidationError, error) {
+		tweet.Sanitise()
+		return tweet.ValidateCreate()
+	})
+}
+
+func TestTweetValidateImport(t *testing.T) {
+	testCases := []struct {
+		description    string
+		row            tweetcsv.Row
+		expectedErrors []expectedError
+	}{
+		{
+			"no errors",
+			tweetcsv.Row{Text: "Hello", PostOn: time.Now().UTC(), Account: "@My_Bot"},
+			[]expectedError{},
+		},
+		{
+			"postOn required",
+			tweetcsv.Row{Text: "Hello"},
+			[]expectedError{{"postOn", models.ValidationTypeRequired}},
+		},
+		{
+			"postOn could not be read",
+			tweetcsv.Row{Text: "Hello", Errors: []tweetcsv.FieldError{{Column: "postOn", Message: "bad"}}},
+			[]expectedError{{"postOn", models.ValidationTypeInvalid}},
+		},
+		{
+			"different account",
+			tweetcsv.Row{Text: "Hello", PostOn: time.Now().UTC(), Account: "another_bot"},
+			[]expectedError{{"account", models.ValidationTypeInvalid}},
+		},
+		{
+			"tweet is invalid",
+			tweetcsv.Row{PostOn: time.Now().UTC()},
+			[]expectedError{{"text", models.ValidationTypeRequired}},
+		},
+	}
+
+	for _, testCase := range testCases {
+		tweet := models.Tweet{
+			Text:   testCase.row.Text,
+			PostOn: testCase.row.PostOn,
+		}
+
+		validationErrors, err := tweet.ValidateImport(testCase.row, "my_bot")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(validationErrors) != len(testCase.expectedErrors) {
+			t.Errorf("test case '%s': expected %d validation error(s) but got %d: %s",
+				testCase.description, len(testCase.expectedErrors), len(validationErrors), validationErrors)
+			continue
+		}
+
+		for i, expected := range testCase.expectedErrors {
+			if validationErrors[i].FieldName != expected.fieldName || validationErrors[i].Type != expected.typeName {
+				t.Errorf("test case '%s': expected error %s/%s, got %s/%s", testCase.description,
+					expected.fieldName, expected.typeName, validationErrors[i].FieldName, validationErrors[i].Type)
+			}
+		}
+	}
+}
+
+func TestTweetValidateDuplicates(t *testing.T) {
+	getRecentTweets := db.TwitterAccountGetRecentTweets
+	getVariants := db.TweetsGetVariants
+	defer func() {
+		db.TwitterAccountGetRecentTweets = getRecentTweets
+		db.TweetsGetVariants = getVariants
+	}()
+
+	db.TwitterAccountGetRecentTweets = func(account *db.TwitterAccount, since time.Time) ([]db.Tweet, error) {
+		return []db.Tweet{
+			{ID: "posted", Tweet: "Check out our new blog post about writing bots in Go!", IsPosted: true},
+			{ID: "pending", Tweet: "Check out our new blog post about writing bots in Go", IsPosted: false},
+			{ID: "unrelated", Tweet: "Happy Friday everyone, have a great weekend"},
+		}, nil
+	}
+
+	db.TweetsGetVariants = func(tweets []db.Tweet) (map[string][]db.TweetVariant, error) {
+		return map[string][]db.TweetVariant{
+			"unrelated": {{ID: "variant", TweetID: "unrelated", Tweet: "Join us at the Go meetup in London tonight at 7pm"}},
+		}, nil
+	}
+
+	account := &db.TwitterAccount{ID: "account", DuplicateWindowDays: 30}
+
+	testCases := []struct {
+		description    string
+		text           string
+		id             string
+		expectedType   string
+		expectedIDs    []string
+		expectedErrors int
+	}{
+		{"no duplicates", "Our office is closed for the holidays", "", "", nil, 0},
+		{"duplicate of another tweet's variant", "join us at the Go meetup in London tonight at 7pm!", "", models.ValidationTypeDuplicate, []string{"unrelated"}, 1},
+		{"exact duplicate after normalisation", "check out our NEW blog post about writing bots in go", "", models.ValidationTypeDuplicate, []string{"posted", "pending"}, 1},
+		{"ignores itself when updating", "Check out our new blog post about writing bots in Go", "pending", models.ValidationTypeDuplicate, []string{"posted"}, 1},
+		{"near repeat", "Check out our new blog post about writing bots in Rust", "", models.ValidationTypeSimilar, []string{"posted", "pending"}, 1},
+	}
+
+	for _, testCase := range testCases {
+		tweet := models.Tweet{Text: testCase.text}
+
+		validationErrors, err := tweet.ValidateDuplicates(account, testCase.id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(validationErrors) != testCase.expectedErrors {
+			t.Errorf("test case '%s': expected %d validation error(s), got %d: %v",
+				testCase.description, testCase.expectedErrors, len(validationErrors), validationErrors)
+			continue
+		}
+
+		if testCase.expectedErrors == 0 {
+			continue
+		}
+
+		validationError := validationErrors[0]
+		if validationError.Type != testCase.expectedType {
+			t.Errorf("test case '%s': expected '%s', got '%s'", testCase.description, testCase.expectedType, validationError.Type)
+		}
+
+		if strings.Join(validationError.ConflictingIDs, ",") != strings.Join(testCase.expectedIDs, ",") {
+			t.Errorf("test case '%s': expected conflicting IDs %v, got %v", testCase.description, testCase.expectedIDs, validationError.ConflictingIDs)
+		}
+	}
+
+	// a variant of the Tweet being checked can be a duplicate too
+	tweet := models.Tweet{
+		Text:     "Our office is closed for the holidays",
+		Variants: []models.TweetVariant{{Text: "Happy Friday everyone, have a great weekend!"}},
+	}
+
+	validationErrors, err := tweet.ValidateDuplicates(account, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(validationErrors) != 1 || validationErrors[0].Type != models.ValidationTypeDuplicate || strings.Join(validationErrors[0].ConflictingIDs, ",") != "unrelated" {
+		t.Errorf("expected a variant to be a duplicate of 'unrelated', got %v", validationErrors)
+	}
+}
@@ -0,0 +1,45 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/sironfoot/go-twitter-bot/data/models"
+)
+
+func TestTwitterAccountRoleValidate(t *testing.T) {
+	var testCases = []struct {
+		description    string
+		model          models.TwitterAccountRole
+		expectedErrors []expectedError
+	}{
+		{"no errors", models.TwitterAccountRole{Role: " Editor "}, []expectedError{}},
+		{"role required", models.TwitterAccountRole{Role: ""}, []expectedError{
+			{"role", models.ValidationTypeRequired},
+		}},
+		{"unknown role", models.TwitterAccountRole{Role: "admin"}, []expectedError{
+			{"role", models.ValidationTypeInvalid},
+		}},
+	}
+
+	for _, testCase := range testCases {
+		testCase.model.Sanitise()
+		validationErrors, err := testCase.model.Validate()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(validationErrors) != len(testCase.expectedErrors) {
+			t.Errorf("test case '%s': expected %d validation error(s) but got %d: %s",
+				testCase.description, len(testCase.expectedErrors), len(validationErrors), validationErrors)
+			continue
+		}
+
+		for i, validationError := range validationErrors {
+			expected := testCase.expectedErrors[i]
+			if validationError.FieldName != expected.fieldName || validationError.Type != expected.typeName {
+				t.Errorf("test case '%s': expected validation error '%s(%s)', got '%s(%s)'",
+					testCase.description, expected.fieldName, expected.typeName, validationError.FieldName, validationError.Type)
+			}
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/sironfoot/go-twitter-bot/data/models"
+)
+
+func TestTwoFactorLoginValidate(t *testing.T) {
+	var testCases = []struct {
+		description    string
+		model          models.TwoFactorLogin
+		expectedErrors []expectedError
+	}{
+		{"no errors", models.TwoFactorLogin{Token: "abc", Code: "123456"}, []expectedError{}},
+		{"token required", models.TwoFactorLogin{Token: "  ", Code: "123456"}, []expectedError{
+			{"token", models.ValidationTypeRequired},
+		}},
+		{"code required", models.TwoFactorLogin{Token: "abc", Code: " "}, []expectedError{
+			{"code", models.ValidationTypeRequired},
+		}},
+	}
+
+	for _, testCase := range testCases {
+		testCase.model.Sanitise()
+		validationErrors, err := testCase.model.Validate()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(validationErrors) != len(testCase.expectedErrors) {
+			t.Errorf("test case '%s': expected %d validation error(s) but got %d: %s",
+				testCase.description, len(testCase.expectedErrors), len(validationErrors), validationErrors)
+			continue
+		}
+
+		for i, validationError := range validationErrors {
+			expected := testCase.expectedErrors[i]
+			if validationError.FieldName != expected.fieldName || validationError.Type != expected.typeName {
+				t.Errorf("test case '%s': expected validation error '%s(%s)', got '%s(%s)'",
+					testCase.description, expected.fieldName, expected.typeName, validationError.FieldName, validationError.Type)
+			}
+		}
+	}
+}
@@ -1,21 +1,64 @@
 package models
 
 import (
+	"fmt"
 	"strings"
 	"time"
+
+	"github.com/sironfoot/go-twitter-bot/data/db"
+	"github.com/sironfoot/go-twitter-bot/lib/tweettext"
 )
 
+// TweetMaxLength is the maximum weighted length (see tweettext.WeightedLength) allowed in a Tweet's text
+const TweetMaxLength = tweettext.MaxWeightedLength
+
+// DuplicateSimilarityThreshold is the tweettext.Similarity at or above which two Tweets are considered near-repeats
+const DuplicateSimilarityThreshold = 0.7
+
 // Tweet represents a model for creating/updating a tweet posted to
 // the create/update tweet REST API endpoints, complete with validation
 type Tweet struct {
-	Text     string    `json:"text"`
-	PostOn   time.Time `json:"postOn"`
-	IsPosted bool      `json:"isPosted"`
+	Text             string         `json:"text"`
+	PostOn           time.Time      `json:"postOn"`
+	IsPosted         bool           `json:"isPosted"`
+	IsDraft          bool           `json:"isDraft"`
+	VariantSelection string         `json:"variantSelection"`
+	Variants         []TweetVariant `json:"variants"`
+	Tags             []string       `json:"tags"`
+}
+
+// TweetVariant represents an alternative text for a Tweet, when a Tweet has
+// variants one of them is chosen to be posted instead of the Tweet's Text
+type TweetVariant struct {
+	ID     string `json:"id"`
+	Text   string `json:"text"`
+	Weight int    `json:"weight"`
 }
 
 // Sanitise sanitises fields for the model, such as trimming whitespace
 func (tweet *Tweet) Sanitise() {
 	tweet.Text = strings.TrimSpace(tweet.Text)
+	tweet.VariantSelection = strings.TrimSpace(tweet.VariantSelection)
+
+	if tweet.VariantSelection == "" {
+		tweet.VariantSelection = tweettext.SelectRandom
+	}
+
+	var tags []string
+	for _, tag := range tweet.Tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	tweet.Tags = tags
+
+	for i := range tweet.Variants {
+		tweet.Variants[i].Text = strings.TrimSpace(tweet.Variants[i].Text)
+
+		if tweet.Variants[i].Weight == 0 {
+			tweet.Variants[i].Weight = 1
+		}
+	}
 }
 
 // Validate provides validation logic for creating or updating a Tweet
@@ -23,11 +66,65 @@ func (tweet *Tweet) Validate() ([]ValidationError, error) {
 	var validationErrors []ValidationError
 
 	validationErrors = validateRequired(validationErrors, tweet.Text, "text")
-	validationErrors = validateMaxLength(validationErrors, tweet.Text, 140, "text")
+	validationErrors = validateTweetText(validationErrors, tweet.Text, tweet.PostOn, "text")
+
+	validationErrors = validateOneOf(validationErrors, tweet.VariantSelection, tweettext.VariantSelections, "variantSelection")
+
+	for _, variant := range tweet.Variants {
+		validationErrors = validateRequired(validationErrors, variant.Text, "variants")
+		validationErrors = validateTweetText(validationErrors, variant.Text, tweet.PostOn, "variants")
+
+		if variant.Weight < 0 {
+			validationErrors = append(validationErrors, ValidationError{
+				FieldName: "variants",
+				Type:      ValidationTypeInvalid,
+				Message:   "variant 'weight' cannot be negative.",
+			})
+		}
+	}
+
+	for _, tag := range tweet.Tags {
+		// tags are comma separated when imported and exported as CSV
+		if strings.Contains(tag, ",") {
+			validationErrors = append(validationErrors, ValidationError{
+				FieldName: "tags",
+				Type:      ValidationTypeInvalid,
+				Message:   "'tags' cannot contain commas.",
+			})
+			break
+		}
+	}
 
 	return validationErrors, nil
 }
 
+// validateTweetText renders text as a template against sample data (see tweettext.Render),
+// checking it's a valid template and that the result isn't longer than TweetMaxLength
+func validateTweetText(validationErrors []ValidationError, text string, postOn time.Time, fieldName string) []ValidationError {
+	if postOn.IsZero() {
+		postOn = time.Now().UTC()
+	}
+
+	result, err := tweettext.Render(text, tweettext.SampleData("sample_account", postOn))
+	if err != nil {
+		return append(validationErrors, ValidationError{
+			FieldName: fieldName,
+			Type:      ValidationTypeInvalid,
+			Message:   fmt.Sprintf("'%s' is not a valid template: %s", fieldName, err),
+		})
+	}
+
+	if tweettext.WeightedLength(result.Text) > TweetMaxLength {
+		validationErrors = append(validationErrors, ValidationError{
+			FieldName: fieldName,
+			Type:      ValidationTypeMaxLength,
+			Message:   fmt.Sprintf("'%s' cannot be greater than %d characters.", fieldName, TweetMaxLength),
+		})
+	}
+
+	return validationErrors
+}
+
 // ValidateCreate provides validation logic for creating a new Tweet only
 func (tweet *Tweet) ValidateCreate() ([]ValidationError, error) {
 	validationErrors, err := tweet.Validate()
@@ -48,3 +145,97 @@ func (tweet *Tweet) ValidateUpdate(id string) ([]ValidationError, error) {
 
 	return validationErrors, nil
 }
+
+// ValidateDuplicates checks the Tweet's text and its variants against the TwitterAccount's pending Tweets and those posted
+// within its DuplicateWindowDays, including their variants, for exact duplicates once normalised and for near-repeats.
+// 'id' is the database primary key ID of the Tweet being updated so it isn't compared with itself, or empty when creating.
+func (tweet *Tweet) ValidateDuplicates(account *db.TwitterAccount, id string) ([]ValidationError, error) {
+	var validationErrors []ValidationError
+
+	since := time.Now().UTC().AddDate(0, 0, -account.DuplicateWindowDays)
+	recentTweets, err := account.GetRecentTweets(since)
+	if err != nil {
+		return nil, err
+	}
+
+	recentVariants, err := db.TweetsGetVariants(recentTweets)
+	if err != nil {
+		return nil, err
+	}
+
+	texts := tweet.texts()
+
+	var duplicateIDs, similarIDs []string
+	for _, recentTweet := range recentTweets {
+		if recentTweet.ID == id {
+			continue
+		}
+
+		recentTexts := []string{recentTweet.Tweet}
+		for _, variant := range recentVariants[recentTweet.ID] {
+			recentTexts = append(recentTexts, variant.Tweet)
+		}
+
+		if isDuplicate, isSimilar := compareTexts(texts, recentTexts); isDuplicate {
+			duplicateIDs = append(duplicateIDs, recentTweet.ID)
+		} else if isSimilar {
+			similarIDs = append(similarIDs, recentTweet.ID)
+		}
+	}
+
+	if len(duplicateIDs) > 0 {
+		validationErrors = append(validationErrors, ValidationError{
+			FieldName:      "text",
+			Type:           ValidationTypeDuplicate,
+			Message:        "'text' is the same as a recent or scheduled Tweet.",
+			ConflictingIDs: duplicateIDs,
+		})
+	}
+
+	if len(similarIDs) > 0 {
+		validationErrors = append(validationErrors, ValidationError{
+			FieldName:      "text",
+			Type:           ValidationTypeSimilar,
+			Message:        "'text' is very similar to a recent or scheduled Tweet.",
+			ConflictingIDs: similarIDs,
+		})
+	}
+
+	return validationErrors, nil
+}
+
+// texts returns the Tweet's text and the text of each of its variants, any of which could be posted
+func (tweet *Tweet) texts() []string {
+	var texts []string
+	if tweet.Text != "" {
+		texts = append(texts, tweet.Text)
+	}
+
+	for _, variant := range tweet.Variants {
+		if variant.Text != "" {
+			texts = append(texts, variant.Text)
+		}
+	}
+
+	return texts
+}
+
+// compareTexts determines if any of 'texts' is the same as any of 'otherTexts' once normalised, or failing that
+// if any are near-repeats
+func compareTexts(texts, otherTexts []string) (isDuplicate, isSimilar bool) {
+	for _, text := range texts {
+		normalised := tweettext.Normalise(text)
+
+		for _, otherText := range otherTexts {
+			if tweettext.Normalise(otherText) == normalised {
+				return true, false
+			}
+
+			if tweettext.Similarity(otherText, text) >= DuplicateSimilarityThreshold {
+				isSimilar = true
+			}
+		}
+	}
+
+	return false, isSimilar
+}
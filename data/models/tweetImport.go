@@ -0,0 +1,54 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/sironfoot/go-twitter-bot/lib/tweetcsv"
+)
+
+// RowValidation holds the validation errors for one row of an imported file,
+// 'Row' being its line number in the file
+type RowValidation struct {
+	Row    int               `json:"row"`
+	Errors []ValidationError `json:"errors"`
+}
+
+// ValidateImport sanitises and validates a Tweet read from a row of an imported CSV file (see tweetcsv.Read),
+// including problems reading the row and that the row's account, if given, is 'username'
+func (tweet *Tweet) ValidateImport(row tweetcsv.Row, username string) ([]ValidationError, error) {
+	var validationErrors []ValidationError
+
+	for _, fieldError := range row.Errors {
+		validationErrors = append(validationErrors, ValidationError{
+			FieldName: fieldError.Column,
+			Type:      ValidationTypeInvalid,
+			Message:   fieldError.Message,
+		})
+	}
+
+	if tweet.PostOn.IsZero() && len(row.Errors) == 0 {
+		validationErrors = append(validationErrors, ValidationError{
+			FieldName: "postOn",
+			Type:      ValidationTypeRequired,
+			Message:   "'postOn' field is required.",
+		})
+	}
+
+	account := strings.TrimPrefix(row.Account, "@")
+	if account != "" && !strings.EqualFold(account, strings.TrimPrefix(username, "@")) {
+		validationErrors = append(validationErrors, ValidationError{
+			FieldName: "account",
+			Type:      ValidationTypeInvalid,
+			Message:   "'account' must be blank or the account being imported to.",
+		})
+	}
+
+	tweet.Sanitise()
+
+	createErrors, err := tweet.ValidateCreate()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(validationErrors, createErrors...), nil
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/sironfoot/go-twitter-bot/data/db"
+)
+
+// TwitterAccountRole represents a model for granting a User a role on a TwitterAccount
+// using REST API endpoints, complete with validation
+type TwitterAccountRole struct {
+	Role string `json:"role"`
+}
+
+// Sanitise sanitises fields for the model, such as trimming whitespace
+func (role *TwitterAccountRole) Sanitise() {
+	role.Role = strings.ToLower(strings.TrimSpace(role.Role))
+}
+
+// Validate provides validation logic for granting a role
+func (role *TwitterAccountRole) Validate() ([]ValidationError, error) {
+	var validationErrors []ValidationError
+	validationErrors = validateRequired(validationErrors, role.Role, "role")
+	if role.Role != "" {
+		validationErrors = validateOneOf(validationErrors, role.Role, db.TwitterAccountRoles, "role")
+	}
+
+	return validationErrors, nil
+}
@@ -0,0 +1,44 @@
+package models
+
+import "strings"
+
+// TwoFactorCode represents a model for confirming, or turning off, two-factor authentication with
+// a code from an authenticator app or a recovery code, complete with validation
+type TwoFactorCode struct {
+	Code string `json:"code"`
+}
+
+// Sanitise sanitises fields for the model, such as trimming whitespace
+func (twoFactorCode *TwoFactorCode) Sanitise() {
+	twoFactorCode.Code = strings.TrimSpace(twoFactorCode.Code)
+}
+
+// Validate provides validation logic for a two-factor code
+func (twoFactorCode *TwoFactorCode) Validate() ([]ValidationError, error) {
+	var validationErrors []ValidationError
+	validationErrors = validateRequired(validationErrors, twoFactorCode.Code, "code")
+
+	return validationErrors, nil
+}
+
+// TwoFactorLogin represents a model for the second step of logging in with two-factor authentication,
+// the token from the first step and a code, complete with validation
+type TwoFactorLogin struct {
+	Token string `json:"token"`
+	Code  string `json:"code"`
+}
+
+// Sanitise sanitises fields for the model, such as trimming whitespace
+func (twoFactorLogin *TwoFactorLogin) Sanitise() {
+	twoFactorLogin.Token = strings.TrimSpace(twoFactorLogin.Token)
+	twoFactorLogin.Code = strings.TrimSpace(twoFactorLogin.Code)
+}
+
+// Validate provides validation logic for the second step of logging in
+func (twoFactorLogin *TwoFactorLogin) Validate() ([]ValidationError, error) {
+	var validationErrors []ValidationError
+	validationErrors = validateRequired(validationErrors, twoFactorLogin.Token, "token")
+	validationErrors = validateRequired(validationErrors, twoFactorLogin.Code, "code")
+
+	return validationErrors, nil
+}
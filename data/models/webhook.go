@@ -0,0 +1,101 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/sironfoot/go-twitter-bot/lib/webhook"
+)
+
+// MinWebhookSecretLength is the shortest secret allowed for signing webhook payloads
+const MinWebhookSecretLength = 16
+
+// Webhook represents a model for creating/updating a webhook subscription posted
+// to the create/update webhook REST API endpoints, complete with validation
+type Webhook struct {
+	URL       string   `json:"url"`
+	Secret    string   `json:"secret"`
+	Events    []string `json:"events"`
+	IsEnabled bool     `json:"isEnabled"`
+}
+
+// Sanitise sanitises fields for the model, such as trimming whitespace
+func (hook *Webhook) Sanitise() {
+	hook.URL = strings.TrimSpace(hook.URL)
+
+	for i := range hook.Events {
+		hook.Events[i] = strings.TrimSpace(hook.Events[i])
+	}
+}
+
+// Validate provides validation logic for creating or updating a Webhook
+func (hook *Webhook) Validate() ([]ValidationError, error) {
+	var validationErrors []ValidationError
+
+	validationErrors = validateRequired(validationErrors, hook.URL, "url")
+	if hook.URL != "" {
+		hookURL, err := url.Parse(hook.URL)
+		if err != nil || (hookURL.Scheme != "http" && hookURL.Scheme != "https") || hookURL.Host == "" {
+			validationErrors = append(validationErrors, ValidationError{
+				FieldName: "url",
+				Type:      ValidationTypeInvalid,
+				Message:   "'url' must be an absolute http or https URL.",
+			})
+		} else if webhook.CheckHost(hookURL.Hostname()) == webhook.ErrPrivateAddress {
+			validationErrors = append(validationErrors, ValidationError{
+				FieldName: "url",
+				Type:      ValidationTypeInvalid,
+				Message:   "'url' can't be a loopback, private or link-local address.",
+			})
+		}
+	}
+
+	if len(hook.Events) == 0 {
+		validationErrors = append(validationErrors, ValidationError{
+			FieldName: "events",
+			Type:      ValidationTypeRequired,
+			Message:   "'events' field is required.",
+		})
+	}
+
+	for _, event := range hook.Events {
+		validationErrors = validateOneOf(validationErrors, event, webhook.Events, "events")
+	}
+
+	return validationErrors, nil
+}
+
+func (hook *Webhook) validateSecret(validationErrors []ValidationError) []ValidationError {
+	if hook.Secret != "" && len(hook.Secret) < MinWebhookSecretLength {
+		validationErrors = append(validationErrors, ValidationError{
+			FieldName: "secret",
+			Type:      ValidationTypeMinLength,
+			Message:   fmt.Sprintf("'secret' must be at least %d characters.", MinWebhookSecretLength),
+		})
+	}
+
+	return validationErrors
+}
+
+// ValidateCreate provides validation logic for creating a new Webhook only
+func (hook *Webhook) ValidateCreate() ([]ValidationError, error) {
+	validationErrors, err := hook.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	validationErrors = validateRequired(validationErrors, hook.Secret, "secret")
+	return hook.validateSecret(validationErrors), nil
+}
+
+// ValidateUpdate provides validation logic for updating an existing Webhook only, an empty
+// Secret keeps the current one. 'id' is the database primary key ID of the current Webhook being updated.
+func (hook *Webhook) ValidateUpdate(id string) ([]ValidationError, error) {
+	validationErrors, err := hook.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return hook.validateSecret(validationErrors), nil
+}
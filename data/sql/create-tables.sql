@@ -7,23 +7,48 @@ CREATE TABLE users
     auth_token              TEXT        NULL,
     is_admin                BOOL        NOT NULL        DEFAULT false,
     is_service              BOOL        NOT NULL        DEFAULT false,
+    is_verified             BOOL        NOT NULL        DEFAULT false,
+    two_factor_secret       TEXT        NULL,
+    two_factor_enabled      BOOL        NOT NULL        DEFAULT false,
+    two_factor_last_step    BIGINT      NOT NULL        DEFAULT 0,
+    date_created            TIMESTAMP   NOT NULL
+);
+
+CREATE TABLE organisations
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    name                    TEXT        NOT NULL,
     date_created            TIMESTAMP   NOT NULL
 );
 
 CREATE TABLE twitter_accounts
 (
     id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
-    user_id                 UUID        NOT NULL,
+    user_id                 UUID        NULL,
+    organisation_id         UUID        NULL,
     username                TEXT        NOT NULL        UNIQUE,
     date_created            TIMESTAMP   NOT NULL,
     consumer_key            TEXT        NOT NULL,
     consumer_secret         TEXT        NOT NULL,
     access_token            TEXT        NOT NULL,
     access_token_secret     TEXT        NOT NULL,
+    mentions_since_id       TEXT        NULL,
+    time_zone               TEXT        NOT NULL        DEFAULT 'UTC',
+    evergreen_slots         TEXT[]      NULL,
+    evergreen_no_repeat_days INT        NOT NULL        DEFAULT 30,
+    duplicate_policy        TEXT        NOT NULL        DEFAULT 'warn',
+    duplicate_window_days   INT         NOT NULL        DEFAULT 30,
+
+    CHECK (user_id IS NOT NULL OR organisation_id IS NOT NULL),
 
     FOREIGN KEY (user_id)
     REFERENCES users(id)
-        ON DELETE CASCADE
+        ON DELETE SET NULL
+        ON UPDATE NO ACTION,
+
+    FOREIGN KEY (organisation_id)
+    REFERENCES organisations(id)
+        ON DELETE SET NULL
         ON UPDATE NO ACTION
 );
 
@@ -34,6 +59,15 @@ CREATE TABLE tweets
     tweet                   TEXT        NOT NULL,
     post_on                 TIMESTAMP   NOT NULL,
     is_posted               BOOL        NOT NULL        DEFAULT false,
+    is_draft                BOOL        NOT NULL        DEFAULT false,
+    variant_selection       TEXT        NOT NULL        DEFAULT 'random',
+    posted_variant_id       UUID        NULL,
+    status_id               TEXT        NULL,
+    attempts                INT         NOT NULL        DEFAULT 0,
+    last_error              TEXT        NULL,
+    retry_after             TIMESTAMP   NULL,
+    claimed_until           TIMESTAMP   NULL,
+    tags                    TEXT[]      NULL,
     date_created            TIMESTAMP   NOT NULL,
 
     FOREIGN KEY (twitter_account_id)
@@ -41,3 +75,382 @@ CREATE TABLE tweets
         ON DELETE CASCADE
         ON UPDATE NO ACTION
 );
+
+CREATE TABLE tweet_variants
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    tweet_id                UUID        NOT NULL,
+    tweet                   TEXT        NOT NULL,
+    weight                  INT         NOT NULL        DEFAULT 1,
+    times_posted            INT         NOT NULL        DEFAULT 0,
+    date_created            TIMESTAMP   NOT NULL,
+
+    FOREIGN KEY (tweet_id)
+    REFERENCES tweets(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION
+);
+
+CREATE TABLE reply_rules
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    twitter_account_id      UUID        NOT NULL,
+    match_type              TEXT        NOT NULL,
+    pattern                 TEXT        NOT NULL,
+    reply_template          TEXT        NOT NULL,
+    cooldown_minutes        INT         NOT NULL        DEFAULT 0,
+    is_enabled              BOOL        NOT NULL        DEFAULT true,
+    date_created            TIMESTAMP   NOT NULL,
+
+    FOREIGN KEY (twitter_account_id)
+    REFERENCES twitter_accounts(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION
+);
+
+CREATE TABLE replies
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    twitter_account_id      UUID        NOT NULL,
+    reply_rule_id           UUID        NULL,
+    mention_id              TEXT        NOT NULL,
+    mention_username        TEXT        NOT NULL,
+    reply_id                TEXT        NOT NULL,
+    date_created            TIMESTAMP   NOT NULL,
+
+    FOREIGN KEY (twitter_account_id)
+    REFERENCES twitter_accounts(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION,
+
+    FOREIGN KEY (reply_rule_id)
+    REFERENCES reply_rules(id)
+        ON DELETE SET NULL
+        ON UPDATE NO ACTION
+);
+
+
+CREATE TABLE feed_subscriptions
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    twitter_account_id      UUID        NOT NULL,
+    url                     TEXT        NOT NULL,
+    poll_interval_minutes   INT         NOT NULL        DEFAULT 60,
+    template                TEXT        NOT NULL,
+    create_as_draft         BOOL        NOT NULL        DEFAULT true,
+    last_polled             TIMESTAMP   NULL,
+    is_seeded               BOOL        NOT NULL        DEFAULT false,
+    date_created            TIMESTAMP   NOT NULL,
+
+    FOREIGN KEY (twitter_account_id)
+    REFERENCES twitter_accounts(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION
+);
+
+CREATE TABLE feed_items
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    feed_subscription_id    UUID        NOT NULL,
+    guid                    TEXT        NOT NULL,
+    tweet_id                UUID        NULL,
+    date_created            TIMESTAMP   NOT NULL,
+
+    UNIQUE (feed_subscription_id, guid),
+
+    FOREIGN KEY (feed_subscription_id)
+    REFERENCES feed_subscriptions(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION,
+
+    FOREIGN KEY (tweet_id)
+    REFERENCES tweets(id)
+        ON DELETE SET NULL
+        ON UPDATE NO ACTION
+);
+
+CREATE TABLE template_variables
+(
+    twitter_account_id      UUID        NOT NULL,
+    name                    TEXT        NOT NULL,
+    value                   TEXT        NOT NULL,
+
+    PRIMARY KEY (twitter_account_id, name),
+
+    FOREIGN KEY (twitter_account_id)
+    REFERENCES twitter_accounts(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION
+);
+
+CREATE TABLE template_counters
+(
+    twitter_account_id      UUID        NOT NULL,
+    name                    TEXT        NOT NULL,
+    value                   INT         NOT NULL        DEFAULT 0,
+
+    PRIMARY KEY (twitter_account_id, name),
+
+    FOREIGN KEY (twitter_account_id)
+    REFERENCES twitter_accounts(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION
+);
+
+CREATE TABLE evergreen_items
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    twitter_account_id      UUID        NOT NULL,
+    tweet                   TEXT        NOT NULL,
+    category                TEXT        NOT NULL        DEFAULT '',
+    min_repost_days         INT         NOT NULL        DEFAULT 0,
+    is_paused               BOOL        NOT NULL        DEFAULT false,
+    last_used               TIMESTAMP   NULL,
+    times_used              INT         NOT NULL        DEFAULT 0,
+    date_created            TIMESTAMP   NOT NULL,
+
+    FOREIGN KEY (twitter_account_id)
+    REFERENCES twitter_accounts(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION
+);
+
+CREATE TABLE evergreen_item_uses
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    evergreen_item_id       UUID        NOT NULL,
+    tweet_id                UUID        NULL,
+    used_for                TIMESTAMP   NOT NULL,
+    date_created            TIMESTAMP   NOT NULL,
+
+    FOREIGN KEY (evergreen_item_id)
+    REFERENCES evergreen_items(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION,
+
+    FOREIGN KEY (tweet_id)
+    REFERENCES tweets(id)
+        ON DELETE SET NULL
+        ON UPDATE NO ACTION
+);
+
+CREATE TABLE webhooks
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    twitter_account_id      UUID        NOT NULL,
+    url                     TEXT        NOT NULL,
+    secret                  TEXT        NOT NULL,
+    events                  TEXT[]      NOT NULL,
+    is_enabled              BOOL        NOT NULL        DEFAULT true,
+    date_created            TIMESTAMP   NOT NULL,
+
+    FOREIGN KEY (twitter_account_id)
+    REFERENCES twitter_accounts(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION
+);
+
+CREATE TABLE webhook_deliveries
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    webhook_id              UUID        NOT NULL,
+    event                   TEXT        NOT NULL,
+    payload                 TEXT        NOT NULL,
+    attempts                INT         NOT NULL        DEFAULT 0,
+    is_delivered            BOOL        NOT NULL        DEFAULT false,
+    status_code             INT         NULL,
+    last_error              TEXT        NULL,
+    last_attempt            TIMESTAMP   NULL,
+    next_attempt            TIMESTAMP   NULL,
+    date_created            TIMESTAMP   NOT NULL,
+
+    FOREIGN KEY (webhook_id)
+    REFERENCES webhooks(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION
+);
+
+CREATE TABLE sessions
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    user_id                 UUID        NOT NULL,
+    user_agent              TEXT        NOT NULL,
+    ip_address              TEXT        NOT NULL,
+    date_created            TIMESTAMP   NOT NULL,
+    last_seen               TIMESTAMP   NOT NULL,
+    expires_on              TIMESTAMP   NOT NULL,
+
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION
+);
+
+CREATE TABLE refresh_tokens
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    session_id              UUID        NOT NULL,
+    token_hash              TEXT        NOT NULL        UNIQUE,
+    date_created            TIMESTAMP   NOT NULL,
+    date_used               TIMESTAMP   NULL,
+
+    FOREIGN KEY (session_id)
+    REFERENCES sessions(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION
+);
+
+CREATE TABLE user_tokens
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    user_id                 UUID        NOT NULL,
+    purpose                 TEXT        NOT NULL,
+    token_hash              TEXT        NOT NULL        UNIQUE,
+    date_created            TIMESTAMP   NOT NULL,
+    expires_on              TIMESTAMP   NOT NULL,
+    date_used               TIMESTAMP   NULL,
+
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION
+);
+
+CREATE TABLE settings
+(
+    id                      INT         PRIMARY KEY     DEFAULT 1       CHECK (id = 1),
+    signup_enabled          BOOL        NOT NULL        DEFAULT true,
+    require_admin_two_factor BOOL       NOT NULL        DEFAULT false
+);
+
+INSERT INTO settings (id) VALUES (1);
+
+CREATE TABLE login_failures
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    email                   TEXT        NOT NULL,
+    ip_address              TEXT        NOT NULL,
+    date_created            TIMESTAMP   NOT NULL
+);
+
+CREATE INDEX login_failures_email_idx ON login_failures (email, date_created);
+CREATE INDEX login_failures_ip_address_idx ON login_failures (ip_address, date_created);
+
+CREATE TABLE audit_entries
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    user_id                 UUID        NULL,
+    actor_id                UUID        NULL,
+    event                   TEXT        NOT NULL,
+    ip_address              TEXT        NOT NULL,
+    detail                  TEXT        NOT NULL,
+    date_created            TIMESTAMP   NOT NULL,
+
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+        ON DELETE SET NULL
+        ON UPDATE NO ACTION,
+
+    FOREIGN KEY (actor_id)
+    REFERENCES users(id)
+        ON DELETE SET NULL
+        ON UPDATE NO ACTION
+);
+
+CREATE TABLE recovery_codes
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    user_id                 UUID        NOT NULL,
+    code_hash               TEXT        NOT NULL,
+    date_created            TIMESTAMP   NOT NULL,
+    date_used               TIMESTAMP   NULL,
+
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION
+);
+
+CREATE TABLE api_keys
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    user_id                 UUID        NOT NULL,
+    name                    TEXT        NOT NULL,
+    prefix                  TEXT        NOT NULL        UNIQUE,
+    key_hash                TEXT        NOT NULL        UNIQUE,
+    scopes                  TEXT[]      NOT NULL,
+    date_created            TIMESTAMP   NOT NULL,
+    last_used               TIMESTAMP   NULL,
+    expires_on              TIMESTAMP   NULL,
+
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION
+);
+
+CREATE TABLE twitter_account_roles
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    twitter_account_id      UUID        NOT NULL,
+    user_id                 UUID        NOT NULL,
+    role                    TEXT        NOT NULL,
+    date_created            TIMESTAMP   NOT NULL,
+
+    UNIQUE (twitter_account_id, user_id),
+
+    FOREIGN KEY (twitter_account_id)
+    REFERENCES twitter_accounts(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION,
+
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION
+);
+
+CREATE TABLE organisation_members
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    organisation_id         UUID        NOT NULL,
+    user_id                 UUID        NOT NULL,
+    role                    TEXT        NOT NULL,
+    date_created            TIMESTAMP   NOT NULL,
+
+    UNIQUE (organisation_id, user_id),
+
+    FOREIGN KEY (organisation_id)
+    REFERENCES organisations(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION,
+
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION
+);
+
+CREATE TABLE organisation_invitations
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    organisation_id         UUID        NOT NULL,
+    email                   TEXT        NOT NULL,
+    role                    TEXT        NOT NULL,
+    token_hash              TEXT        NOT NULL,
+    invited_by              UUID        NULL,
+    date_created            TIMESTAMP   NOT NULL,
+    expires_on              TIMESTAMP   NOT NULL,
+    date_responded          TIMESTAMP   NULL,
+    is_accepted             BOOL        NOT NULL        DEFAULT false,
+
+    FOREIGN KEY (organisation_id)
+    REFERENCES organisations(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION,
+
+    FOREIGN KEY (invited_by)
+    REFERENCES users(id)
+        ON DELETE SET NULL
+        ON UPDATE NO ACTION
+);
@@ -4,8 +4,8 @@ DO $$
     DECLARE user_id UUID;
     DECLARE twitter_account_id UUID;
 BEGIN
-    INSERT INTO users(email, hashed_password, is_admin, date_created)
-    VALUES ('your@email.com', '$2a$10$oF2TzJDQO7VuKQR3y.5bne.vGIOEWGNpE8T1VVLNLLX.QKKj8bifa', true, LOCALTIMESTAMP(0))
+    INSERT INTO users(email, hashed_password, is_admin, is_verified, date_created)
+    VALUES ('your@email.com', '$2a$10$oF2TzJDQO7VuKQR3y.5bne.vGIOEWGNpE8T1VVLNLLX.QKKj8bifa', true, true, LOCALTIMESTAMP(0))
     RETURNING id INTO user_id;
 
     INSERT INTO twitter_accounts(user_id, username, date_created, consumer_key, consumer_secret, access_token, access_token_secret)
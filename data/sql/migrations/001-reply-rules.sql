@@ -0,0 +1,42 @@
+-- Auto-reply rules for mentions (user-026).
+
+ALTER TABLE twitter_accounts
+    ADD COLUMN mentions_since_id       TEXT        NULL;
+
+CREATE TABLE reply_rules
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    twitter_account_id      UUID        NOT NULL,
+    match_type              TEXT        NOT NULL,
+    pattern                 TEXT        NOT NULL,
+    reply_template          TEXT        NOT NULL,
+    cooldown_minutes        INT         NOT NULL        DEFAULT 0,
+    is_enabled              BOOL        NOT NULL        DEFAULT true,
+    date_created            TIMESTAMP   NOT NULL,
+
+    FOREIGN KEY (twitter_account_id)
+    REFERENCES twitter_accounts(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION
+);
+
+CREATE TABLE replies
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    twitter_account_id      UUID        NOT NULL,
+    reply_rule_id           UUID        NULL,
+    mention_id              TEXT        NOT NULL,
+    mention_username        TEXT        NOT NULL,
+    reply_id                TEXT        NOT NULL,
+    date_created            TIMESTAMP   NOT NULL,
+
+    FOREIGN KEY (twitter_account_id)
+    REFERENCES twitter_accounts(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION,
+
+    FOREIGN KEY (reply_rule_id)
+    REFERENCES reply_rules(id)
+        ON DELETE SET NULL
+        ON UPDATE NO ACTION
+);
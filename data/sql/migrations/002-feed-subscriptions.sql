@@ -0,0 +1,43 @@
+-- RSS/Atom feed subscriptions that create tweets, as drafts by default (user-027).
+
+ALTER TABLE tweets
+    ADD COLUMN is_draft                BOOL        NOT NULL        DEFAULT false;
+
+CREATE TABLE feed_subscriptions
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    twitter_account_id      UUID        NOT NULL,
+    url                     TEXT        NOT NULL,
+    poll_interval_minutes   INT         NOT NULL        DEFAULT 60,
+    template                TEXT        NOT NULL,
+    create_as_draft         BOOL        NOT NULL        DEFAULT true,
+    last_polled             TIMESTAMP   NULL,
+    is_seeded               BOOL        NOT NULL        DEFAULT false,
+    date_created            TIMESTAMP   NOT NULL,
+
+    FOREIGN KEY (twitter_account_id)
+    REFERENCES twitter_accounts(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION
+);
+
+CREATE TABLE feed_items
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    feed_subscription_id    UUID        NOT NULL,
+    guid                    TEXT        NOT NULL,
+    tweet_id                UUID        NULL,
+    date_created            TIMESTAMP   NOT NULL,
+
+    UNIQUE (feed_subscription_id, guid),
+
+    FOREIGN KEY (feed_subscription_id)
+    REFERENCES feed_subscriptions(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION,
+
+    FOREIGN KEY (tweet_id)
+    REFERENCES tweets(id)
+        ON DELETE SET NULL
+        ON UPDATE NO ACTION
+);
@@ -0,0 +1,36 @@
+-- Template placeholders in tweets, rendered at post time (user-028). Due tweets are claimed by a
+-- poster worker before posting, so two workers don't post the same tweet.
+
+ALTER TABLE twitter_accounts
+    ADD COLUMN time_zone               TEXT        NOT NULL        DEFAULT 'UTC';
+
+ALTER TABLE tweets
+    ADD COLUMN claimed_until           TIMESTAMP   NULL;
+
+CREATE TABLE template_variables
+(
+    twitter_account_id      UUID        NOT NULL,
+    name                    TEXT        NOT NULL,
+    value                   TEXT        NOT NULL,
+
+    PRIMARY KEY (twitter_account_id, name),
+
+    FOREIGN KEY (twitter_account_id)
+    REFERENCES twitter_accounts(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION
+);
+
+CREATE TABLE template_counters
+(
+    twitter_account_id      UUID        NOT NULL,
+    name                    TEXT        NOT NULL,
+    value                   INT         NOT NULL        DEFAULT 0,
+
+    PRIMARY KEY (twitter_account_id, name),
+
+    FOREIGN KEY (twitter_account_id)
+    REFERENCES twitter_accounts(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION
+);
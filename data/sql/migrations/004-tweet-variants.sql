@@ -0,0 +1,21 @@
+-- Alternative texts for tweets (user-029).
+
+ALTER TABLE tweets
+    ADD COLUMN variant_selection       TEXT        NOT NULL        DEFAULT 'random',
+    ADD COLUMN posted_variant_id       UUID        NULL,
+    ADD COLUMN status_id               TEXT        NULL;
+
+CREATE TABLE tweet_variants
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    tweet_id                UUID        NOT NULL,
+    tweet                   TEXT        NOT NULL,
+    weight                  INT         NOT NULL        DEFAULT 1,
+    times_posted            INT         NOT NULL        DEFAULT 0,
+    date_created            TIMESTAMP   NOT NULL,
+
+    FOREIGN KEY (tweet_id)
+    REFERENCES tweets(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION
+);
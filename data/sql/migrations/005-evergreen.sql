@@ -0,0 +1,42 @@
+-- Evergreen tweets that fill empty schedule slots (user-030).
+
+ALTER TABLE twitter_accounts
+    ADD COLUMN evergreen_slots         TEXT[]      NULL,
+    ADD COLUMN evergreen_no_repeat_days INT        NOT NULL        DEFAULT 30;
+
+CREATE TABLE evergreen_items
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    twitter_account_id      UUID        NOT NULL,
+    tweet                   TEXT        NOT NULL,
+    category                TEXT        NOT NULL        DEFAULT '',
+    min_repost_days         INT         NOT NULL        DEFAULT 0,
+    is_paused               BOOL        NOT NULL        DEFAULT false,
+    last_used               TIMESTAMP   NULL,
+    times_used              INT         NOT NULL        DEFAULT 0,
+    date_created            TIMESTAMP   NOT NULL,
+
+    FOREIGN KEY (twitter_account_id)
+    REFERENCES twitter_accounts(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION
+);
+
+CREATE TABLE evergreen_item_uses
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    evergreen_item_id       UUID        NOT NULL,
+    tweet_id                UUID        NULL,
+    used_for                TIMESTAMP   NOT NULL,
+    date_created            TIMESTAMP   NOT NULL,
+
+    FOREIGN KEY (evergreen_item_id)
+    REFERENCES evergreen_items(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION,
+
+    FOREIGN KEY (tweet_id)
+    REFERENCES tweets(id)
+        ON DELETE SET NULL
+        ON UPDATE NO ACTION
+);
@@ -0,0 +1,5 @@
+-- Duplicate and near-repeat tweet checks (user-031).
+
+ALTER TABLE twitter_accounts
+    ADD COLUMN duplicate_policy        TEXT        NOT NULL        DEFAULT 'warn',
+    ADD COLUMN duplicate_window_days   INT         NOT NULL        DEFAULT 30;
@@ -0,0 +1,42 @@
+-- Webhook notifications, with posting failures recorded on tweets for the tweet.failed event (user-032).
+
+ALTER TABLE tweets
+    ADD COLUMN attempts                INT         NOT NULL        DEFAULT 0,
+    ADD COLUMN last_error              TEXT        NULL,
+    ADD COLUMN retry_after             TIMESTAMP   NULL;
+
+CREATE TABLE webhooks
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    twitter_account_id      UUID        NOT NULL,
+    url                     TEXT        NOT NULL,
+    secret                  TEXT        NOT NULL,
+    events                  TEXT[]      NOT NULL,
+    is_enabled              BOOL        NOT NULL        DEFAULT true,
+    date_created            TIMESTAMP   NOT NULL,
+
+    FOREIGN KEY (twitter_account_id)
+    REFERENCES twitter_accounts(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION
+);
+
+CREATE TABLE webhook_deliveries
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    webhook_id              UUID        NOT NULL,
+    event                   TEXT        NOT NULL,
+    payload                 TEXT        NOT NULL,
+    attempts                INT         NOT NULL        DEFAULT 0,
+    is_delivered            BOOL        NOT NULL        DEFAULT false,
+    status_code             INT         NULL,
+    last_error              TEXT        NULL,
+    last_attempt            TIMESTAMP   NULL,
+    next_attempt            TIMESTAMP   NULL,
+    date_created            TIMESTAMP   NOT NULL,
+
+    FOREIGN KEY (webhook_id)
+    REFERENCES webhooks(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION
+);
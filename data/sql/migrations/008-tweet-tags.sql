@@ -0,0 +1,4 @@
+-- Tags on tweets, for CSV import and export (user-038).
+
+ALTER TABLE tweets
+    ADD COLUMN tags                    TEXT[]      NULL;
@@ -0,0 +1,17 @@
+-- Login sessions (user-042).
+
+CREATE TABLE sessions
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    user_id                 UUID        NOT NULL,
+    user_agent              TEXT        NOT NULL,
+    ip_address              TEXT        NOT NULL,
+    date_created            TIMESTAMP   NOT NULL,
+    last_seen               TIMESTAMP   NOT NULL,
+    expires_on              TIMESTAMP   NOT NULL,
+
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION
+);
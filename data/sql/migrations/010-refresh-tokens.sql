@@ -0,0 +1,15 @@
+-- Rotating refresh tokens (user-043).
+
+CREATE TABLE refresh_tokens
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    session_id              UUID        NOT NULL,
+    token_hash              TEXT        NOT NULL        UNIQUE,
+    date_created            TIMESTAMP   NOT NULL,
+    date_used               TIMESTAMP   NULL,
+
+    FOREIGN KEY (session_id)
+    REFERENCES sessions(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION
+);
@@ -0,0 +1,31 @@
+-- Signup with email verification (user-044). Users from before signups were added were created by admins,
+-- so they're all marked as verified, otherwise none of them could log in.
+
+ALTER TABLE users
+    ADD COLUMN is_verified             BOOL        NOT NULL        DEFAULT false;
+
+UPDATE users SET is_verified = true;
+
+CREATE TABLE user_tokens
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    user_id                 UUID        NOT NULL,
+    purpose                 TEXT        NOT NULL,
+    token_hash              TEXT        NOT NULL        UNIQUE,
+    date_created            TIMESTAMP   NOT NULL,
+    expires_on              TIMESTAMP   NOT NULL,
+    date_used               TIMESTAMP   NULL,
+
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION
+);
+
+CREATE TABLE settings
+(
+    id                      INT         PRIMARY KEY     DEFAULT 1       CHECK (id = 1),
+    signup_enabled          BOOL        NOT NULL        DEFAULT true
+);
+
+INSERT INTO settings (id) VALUES (1);
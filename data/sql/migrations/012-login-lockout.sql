@@ -0,0 +1,33 @@
+-- Login lockout and the audit log (user-046).
+
+CREATE TABLE login_failures
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    email                   TEXT        NOT NULL,
+    ip_address              TEXT        NOT NULL,
+    date_created            TIMESTAMP   NOT NULL
+);
+
+CREATE INDEX login_failures_email_idx ON login_failures (email, date_created);
+CREATE INDEX login_failures_ip_address_idx ON login_failures (ip_address, date_created);
+
+CREATE TABLE audit_entries
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    user_id                 UUID        NULL,
+    actor_id                UUID        NULL,
+    event                   TEXT        NOT NULL,
+    ip_address              TEXT        NOT NULL,
+    detail                  TEXT        NOT NULL,
+    date_created            TIMESTAMP   NOT NULL,
+
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+        ON DELETE SET NULL
+        ON UPDATE NO ACTION,
+
+    FOREIGN KEY (actor_id)
+    REFERENCES users(id)
+        ON DELETE SET NULL
+        ON UPDATE NO ACTION
+);
@@ -0,0 +1,23 @@
+-- Two-factor authentication with recovery codes (user-047).
+
+ALTER TABLE users
+    ADD COLUMN two_factor_secret       TEXT        NULL,
+    ADD COLUMN two_factor_enabled      BOOL        NOT NULL        DEFAULT false,
+    ADD COLUMN two_factor_last_step    BIGINT      NOT NULL        DEFAULT 0;
+
+ALTER TABLE settings
+    ADD COLUMN require_admin_two_factor BOOL       NOT NULL        DEFAULT false;
+
+CREATE TABLE recovery_codes
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    user_id                 UUID        NOT NULL,
+    code_hash               TEXT        NOT NULL,
+    date_created            TIMESTAMP   NOT NULL,
+    date_used               TIMESTAMP   NULL,
+
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION
+);
@@ -0,0 +1,19 @@
+-- API keys for service users (user-048).
+
+CREATE TABLE api_keys
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    user_id                 UUID        NOT NULL,
+    name                    TEXT        NOT NULL,
+    prefix                  TEXT        NOT NULL        UNIQUE,
+    key_hash                TEXT        NOT NULL        UNIQUE,
+    scopes                  TEXT[]      NOT NULL,
+    date_created            TIMESTAMP   NOT NULL,
+    last_used               TIMESTAMP   NULL,
+    expires_on              TIMESTAMP   NULL,
+
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION
+);
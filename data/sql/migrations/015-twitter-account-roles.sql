@@ -0,0 +1,22 @@
+-- Roles granted to users on Twitter accounts (user-049).
+
+CREATE TABLE twitter_account_roles
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    twitter_account_id      UUID        NOT NULL,
+    user_id                 UUID        NOT NULL,
+    role                    TEXT        NOT NULL,
+    date_created            TIMESTAMP   NOT NULL,
+
+    UNIQUE (twitter_account_id, user_id),
+
+    FOREIGN KEY (twitter_account_id)
+    REFERENCES twitter_accounts(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION,
+
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION
+);
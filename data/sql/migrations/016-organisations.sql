@@ -0,0 +1,71 @@
+-- Organisations that own Twitter accounts, with members and email invitations (user-050). An organisation's
+-- accounts are kept when the user that added them is deleted, leaving user_id NULL. That user's personal
+-- accounts are still deleted with them, by the data server, before the user.
+
+CREATE TABLE organisations
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    name                    TEXT        NOT NULL,
+    date_created            TIMESTAMP   NOT NULL
+);
+
+ALTER TABLE twitter_accounts
+    ADD COLUMN organisation_id         UUID        NULL,
+    ADD FOREIGN KEY (organisation_id)
+    REFERENCES organisations(id)
+        ON DELETE SET NULL
+        ON UPDATE NO ACTION;
+
+ALTER TABLE twitter_accounts
+    ALTER COLUMN user_id DROP NOT NULL,
+    DROP CONSTRAINT twitter_accounts_user_id_fkey,
+    ADD FOREIGN KEY (user_id)
+    REFERENCES users(id)
+        ON DELETE SET NULL
+        ON UPDATE NO ACTION,
+    ADD CHECK (user_id IS NOT NULL OR organisation_id IS NOT NULL);
+
+CREATE TABLE organisation_members
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    organisation_id         UUID        NOT NULL,
+    user_id                 UUID        NOT NULL,
+    role                    TEXT        NOT NULL,
+    date_created            TIMESTAMP   NOT NULL,
+
+    UNIQUE (organisation_id, user_id),
+
+    FOREIGN KEY (organisation_id)
+    REFERENCES organisations(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION,
+
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION
+);
+
+CREATE TABLE organisation_invitations
+(
+    id                      UUID        PRIMARY KEY     DEFAULT uuid_generate_v1mc(),
+    organisation_id         UUID        NOT NULL,
+    email                   TEXT        NOT NULL,
+    role                    TEXT        NOT NULL,
+    token_hash              TEXT        NOT NULL,
+    invited_by              UUID        NULL,
+    date_created            TIMESTAMP   NOT NULL,
+    expires_on              TIMESTAMP   NOT NULL,
+    date_responded          TIMESTAMP   NULL,
+    is_accepted             BOOL        NOT NULL        DEFAULT false,
+
+    FOREIGN KEY (organisation_id)
+    REFERENCES organisations(id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION,
+
+    FOREIGN KEY (invited_by)
+    REFERENCES users(id)
+        ON DELETE SET NULL
+        ON UPDATE NO ACTION
+);
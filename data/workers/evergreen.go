@@ -0,0 +1,234 @@
+package workers
+
+import (
+	"database/sql"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sironfoot/go-twitter-bot/data/db"
+	"github.com/sironfoot/go-twitter-bot/data/events"
+	"github.com/sironfoot/go-twitter-bot/data/models"
+	"github.com/sironfoot/go-twitter-bot/lib/tweettext"
+)
+
+// EvergreenSlotWindow is how close to a slot another Tweet must be scheduled for the slot to count as taken
+const EvergreenSlotWindow = 30 * time.Minute
+
+// EvergreenWorker fills empty schedule slots with Tweets drawn from each TwitterAccount's evergreen library
+type EvergreenWorker struct {
+	Interval time.Duration
+	// Lookahead is how far ahead of now slots are filled, so they show up in the schedule before posting
+	Lookahead time.Duration
+}
+
+// Run fills empty slots every Interval until stop is closed
+func (worker *EvergreenWorker) Run(stop <-chan bool) {
+	ticker := time.NewTicker(worker.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := worker.Poll(); err != nil {
+				log.Printf("evergreen worker: %s\n", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Poll makes a single pass filling empty slots for every TwitterAccount with an evergreen library
+func (worker *EvergreenWorker) Poll() error {
+	accounts, err := db.TwitterAccountsWithEvergreenSlots()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	for i := range accounts {
+		account := &accounts[i]
+
+		err = worker.fillSlots(account, now)
+		if err != nil {
+			log.Printf("evergreen worker: @%s: %s\n", account.Username, err)
+		}
+	}
+
+	return nil
+}
+
+func (worker *EvergreenWorker) fillSlots(account *db.TwitterAccount, now time.Time) error {
+	location, err := account.Location()
+	if err != nil {
+		return err
+	}
+
+	slots, err := EvergreenSlotsBetween(account.EvergreenSlots, now, now.Add(worker.Lookahead), location)
+	if err != nil {
+		return err
+	}
+
+	items, err := account.GetEvergreenItems()
+	if err != nil {
+		return err
+	}
+
+	for _, slot := range slots {
+		taken, err := account.HasTweetBetween(slot.Add(-EvergreenSlotWindow), slot.Add(EvergreenSlotWindow))
+		if err != nil {
+			return err
+		}
+
+		if taken {
+			continue
+		}
+
+		item := ChooseEvergreenItem(items, slot, account.EvergreenNoRepeatDays)
+		if item == nil {
+			log.Printf("evergreen worker: @%s: no evergreen item can be used for slot at %s\n", account.Username, slot)
+			continue
+		}
+
+		err = useEvergreenItem(account, item, slot)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func useEvergreenItem(account *db.TwitterAccount, item *db.EvergreenItem, slot time.Time) error {
+	tweet := db.Tweet{
+		AccountID:        account.ID,
+		Tweet:            item.Tweet,
+		PostOn:           slot,
+		VariantSelection: tweettext.SelectRandom,
+		DateCreated:      time.Now().UTC(),
+	}
+
+	err := tweet.Save()
+	if err != nil {
+		return err
+	}
+	events.Publish(events.TweetCreated, account, &tweet)
+
+	item.LastUsed = pq.NullTime{Time: slot, Valid: true}
+	item.TimesUsed++
+
+	err = item.Save()
+	if err != nil {
+		return err
+	}
+
+	use := db.EvergreenItemUse{
+		EvergreenItemID: item.ID,
+		TweetID:         sql.NullString{String: tweet.ID, Valid: true},
+		UsedFor:         slot,
+		DateCreated:     time.Now().UTC(),
+	}
+
+	return use.Save()
+}
+
+// EvergreenSlotsBetween returns the times, in UTC, of each daily slot (formatted as models.EvergreenSlotFormat
+// in 'location') that fall after 'from' and no later than 'to', earliest first
+func EvergreenSlotsBetween(slots []string, from, to time.Time, location *time.Location) ([]time.Time, error) {
+	var times []time.Time
+
+	start := from.In(location)
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, location)
+
+	for day := start; !day.After(to); day = day.AddDate(0, 0, 1) {
+		for _, slot := range slots {
+			timeOfDay, err := time.Parse(models.EvergreenSlotFormat, slot)
+			if err != nil {
+				return nil, err
+			}
+
+			slotTime := time.Date(day.Year(), day.Month(), day.Day(), timeOfDay.Hour(), timeOfDay.Minute(), 0, 0, location)
+			if slotTime.After(from) && !slotTime.After(to) {
+				times = append(times, slotTime.UTC())
+			}
+		}
+	}
+
+	sort.Sort(timeSlice(times))
+	return times, nil
+}
+
+type timeSlice []time.Time
+
+func (s timeSlice) Len() int           { return len(s) }
+func (s timeSlice) Less(i, j int) bool { return s[i].Before(s[j]) }
+func (s timeSlice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// ChooseEvergreenItem picks which EvergreenItem to use for a slot at time 'at'. Paused items, and items
+// used within their MinRepostDays or the account wide noRepeatDays, are never chosen. Categories are
+// rotated in alphabetical order starting after the category of the most recently used item, and within
+// a category items never used come first, then the least recently used. Returns nil if no item can be used.
+func ChooseEvergreenItem(items []db.EvergreenItem, at time.Time, noRepeatDays int) *db.EvergreenItem {
+	var lastUsed *db.EvergreenItem
+	categories := make(map[string][]*db.EvergreenItem)
+
+	for i := range items {
+		item := &items[i]
+
+		if item.LastUsed.Valid && (lastUsed == nil || item.LastUsed.Time.After(lastUsed.LastUsed.Time)) {
+			lastUsed = item
+		}
+
+		if item.IsPaused {
+			continue
+		}
+
+		repostDays := item.MinRepostDays
+		if noRepeatDays > repostDays {
+			repostDays = noRepeatDays
+		}
+
+		if item.LastUsed.Valid && at.Sub(item.LastUsed.Time) < time.Duration(repostDays)*24*time.Hour {
+			continue
+		}
+
+		categories[item.Category] = append(categories[item.Category], item)
+	}
+
+	if len(categories) == 0 {
+		return nil
+	}
+
+	var names []string
+	for name := range categories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// first category alphabetically after the last one used, wrapping around to the start
+	category := names[0]
+	if lastUsed != nil {
+		for _, name := range names {
+			if name > lastUsed.Category {
+				category = name
+				break
+			}
+		}
+	}
+
+	var chosen *db.EvergreenItem
+	for _, item := range categories[category] {
+		if chosen == nil {
+			chosen = item
+		} else if !item.LastUsed.Valid && chosen.LastUsed.Valid {
+			chosen = item
+		} else if item.LastUsed.Valid && chosen.LastUsed.Valid && item.LastUsed.Time.Before(chosen.LastUsed.Time) {
+			chosen = item
+		}
+	}
+
+	return chosen
+}
@@ -0,0 +1,142 @@
+package workers
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/sironfoot/go-twitter-bot/data/db"
+	"github.com/sironfoot/go-twitter-bot/data/events"
+	"github.com/sironfoot/go-twitter-bot/data/models"
+	"github.com/sironfoot/go-twitter-bot/lib/feed"
+	"github.com/sironfoot/go-twitter-bot/lib/tweettext"
+)
+
+// FeedsWorker polls RSS/Atom FeedSubscriptions that are due, and creates a
+// Tweet for each new feed item using the subscription's template
+type FeedsWorker struct {
+	Interval time.Duration
+}
+
+// Run checks for due feeds every Interval until stop is closed
+func (worker *FeedsWorker) Run(stop <-chan bool) {
+	ticker := time.NewTicker(worker.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := worker.Poll(); err != nil {
+				log.Printf("feeds worker: %s\n", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Poll makes a single pass over all FeedSubscriptions that are due to be polled
+func (worker *FeedsWorker) Poll() error {
+	now := time.Now().UTC()
+
+	subscriptions, err := db.FeedSubscriptionsDue(now)
+	if err != nil {
+		return err
+	}
+
+	for i := range subscriptions {
+		subscription := &subscriptions[i]
+
+		err = pollFeed(subscription)
+		if err != nil {
+			// a broken feed shouldn't stop the others, it'll be retried next interval
+			log.Printf("feeds worker: %s: %s\n", subscription.URL, err)
+		}
+
+		// only a successful pass has recorded everything already in the feed
+		if err = subscription.SetPolled(now, err == nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func pollFeed(subscription *db.FeedSubscription) error {
+	f, err := feed.Fetch(subscription.URL)
+	if err != nil {
+		return err
+	}
+
+	// until a poll of this URL has succeeded, record the items already in the feed
+	// without tweeting them, otherwise subscribing would tweet the feed's backlog
+	isFirstPoll := !subscription.IsSeeded
+
+	// feeds list the newest items first, create tweets in the order they were published
+	for i := len(f.Items) - 1; i >= 0; i-- {
+		item := f.Items[i]
+		if item.GUID == "" {
+			continue
+		}
+
+		seen, err := subscription.HasItem(item.GUID)
+		if err != nil {
+			return err
+		}
+
+		if seen {
+			continue
+		}
+
+		feedItem := db.FeedItem{
+			FeedSubscriptionID: subscription.ID,
+			GUID:               item.GUID,
+			DateCreated:        time.Now().UTC(),
+		}
+
+		if !isFirstPoll {
+			tweet, err := createFeedTweet(subscription, f, item)
+			if err != nil {
+				return err
+			}
+
+			account, err := db.TwitterAccountFromID(subscription.AccountID)
+			if err != nil {
+				return err
+			}
+			events.Publish(events.TweetCreated, &account.TwitterAccount, &tweet)
+
+			feedItem.TweetID = sql.NullString{String: tweet.ID, Valid: true}
+		}
+
+		if err = feedItem.Save(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func createFeedTweet(subscription *db.FeedSubscription, f feed.Feed, item feed.Item) (db.Tweet, error) {
+	text, err := feed.RenderTemplate(subscription.Template, f, item)
+	if err != nil {
+		return db.Tweet{}, err
+	}
+
+	tweet := db.Tweet{
+		AccountID:        subscription.AccountID,
+		Tweet:            tweettext.Escape(text),
+		PostOn:           time.Now().UTC(),
+		IsDraft:          subscription.CreateAsDraft,
+		VariantSelection: tweettext.SelectRandom,
+		DateCreated:      time.Now().UTC(),
+	}
+
+	// too long to post as is, leave it as a draft for someone to edit
+	if tweettext.WeightedLength(text) > models.TweetMaxLength {
+		tweet.IsDraft = true
+	}
+
+	err = tweet.Save()
+	return tweet, err
+}
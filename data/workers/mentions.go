@@ -0,0 +1,215 @@
+package workers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sironfoot/go-twitter-bot/data/db"
+	"github.com/sironfoot/go-twitter-bot/lib/tweettext"
+	"github.com/sironfoot/go-twitter-bot/lib/twitter"
+)
+
+// MentionsWorker polls the mentions timeline of every TwitterAccount that has enabled
+// ReplyRules, and replies to mentions that match one of the account's rules
+type MentionsWorker struct {
+	Interval          time.Duration
+	MaxRepliesPerHour int
+}
+
+var errReplyCapReached = errors.New("global reply cap reached")
+
+// Run polls for mentions every Interval until stop is closed
+func (worker *MentionsWorker) Run(stop <-chan bool) {
+	ticker := time.NewTicker(worker.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := worker.Poll(); err != nil {
+				log.Printf("mentions worker: %s\n", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Poll makes a single pass over all TwitterAccounts with enabled ReplyRules
+func (worker *MentionsWorker) Poll() error {
+	accounts, err := db.TwitterAccountsWithReplyRules()
+	if err != nil {
+		return err
+	}
+
+	for i := range accounts {
+		err = worker.pollAccount(&accounts[i])
+		if err == errReplyCapReached {
+			return nil
+		} else if err != nil {
+			// one account's failure (e.g. revoked credentials) shouldn't stop the others
+			log.Printf("mentions worker: @%s: %s\n", accounts[i].Username, err)
+		}
+	}
+
+	return nil
+}
+
+func (worker *MentionsWorker) pollAccount(account *db.TwitterAccount) error {
+	rules, err := account.GetReplyRules()
+	if err != nil {
+		return err
+	}
+
+	client, err := twitter.NewClient(twitter.Credentials{
+		ConsumerKey:       account.ConsumerKey,
+		ConsumerSecret:    account.ConsumerSecret,
+		AccessToken:       account.AccessToken,
+		AccessTokenSecret: account.AccessTokenSecret,
+	})
+	if err != nil {
+		return err
+	}
+
+	mentions, err := client.MentionsTimeline(account.MentionsSinceID.String)
+	if err != nil {
+		return err
+	}
+
+	if len(mentions) == 0 {
+		return nil
+	}
+
+	// first poll for this account, don't reply to the backlog, just set the cursor
+	if !account.MentionsSinceID.Valid {
+		return saveMentionsCursor(account, mentions[0].ID)
+	}
+
+	// mentions are returned newest first, reply in the order they were sent
+	for i := len(mentions) - 1; i >= 0; i-- {
+		mention := mentions[i]
+
+		err = worker.replyTo(client, account, rules, mention)
+		if err != nil {
+			return err
+		}
+
+		err = saveMentionsCursor(account, mention.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (worker *MentionsWorker) replyTo(client twitter.Client, account *db.TwitterAccount, rules []db.ReplyRule, mention twitter.Tweet) error {
+	if strings.EqualFold(mention.User.ScreenName, account.Username) {
+		return nil
+	}
+
+	rule := MatchReplyRule(rules, mention)
+	if rule == nil {
+		return nil
+	}
+
+	lastReply, err := account.LastReplyTo(mention.User.ScreenName)
+	if err != nil && err != db.ErrEntityNotFound {
+		return err
+	}
+
+	cooldown := time.Duration(rule.CooldownMinutes) * time.Minute
+	if err == nil && time.Now().UTC().Sub(lastReply.DateCreated) < cooldown {
+		return nil
+	}
+
+	if worker.MaxRepliesPerHour > 0 {
+		count, err := db.RepliesCountSince(time.Now().UTC().Add(-time.Hour))
+		if err != nil {
+			return err
+		}
+
+		if count >= worker.MaxRepliesPerHour {
+			return errReplyCapReached
+		}
+	}
+
+	text, err := RenderReply(*rule, account.Username, mention)
+	if err != nil {
+		// a broken template would fail for every mention it matches, skip this one so later mentions are still answered
+		log.Printf("mentions worker: @%s: problem rendering reply to %s: %s\n", account.Username, mention.ID, err)
+		return nil
+	}
+
+	posted, err := client.UpdateStatus(text, mention.ID)
+	if twitterErr, ok := err.(*twitter.Error); ok && twitterErr.StatusCode == 403 {
+		// Twitter refused this particular reply (e.g. a duplicate status), skip the mention
+		log.Printf("mentions worker: @%s: reply to %s refused: %s\n", account.Username, mention.ID, err)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	reply := db.Reply{
+		AccountID:       account.ID,
+		ReplyRuleID:     sql.NullString{String: rule.ID, Valid: true},
+		MentionID:       mention.ID,
+		MentionUsername: mention.User.ScreenName,
+		ReplyID:         posted.ID,
+		DateCreated:     time.Now().UTC(),
+	}
+
+	return reply.Save()
+}
+
+func saveMentionsCursor(account *db.TwitterAccount, mentionID string) error {
+	if account.MentionsSinceID.Valid && twitter.CompareIDs(mentionID, account.MentionsSinceID.String) <= 0 {
+		return nil
+	}
+
+	return account.SetMentionsSinceID(mentionID)
+}
+
+// MatchReplyRule returns the first enabled rule that matches the mention, or nil if none match
+func MatchReplyRule(rules []db.ReplyRule, mention twitter.Tweet) *db.ReplyRule {
+	for i := range rules {
+		rule := &rules[i]
+
+		if !rule.IsEnabled {
+			continue
+		}
+
+		switch rule.MatchType {
+		case db.ReplyRuleMatchKeyword:
+			keyword := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(rule.Pattern) + `\b`)
+			if keyword.MatchString(mention.Text) {
+				return rule
+			}
+		case db.ReplyRuleMatchRegex:
+			pattern, err := regexp.Compile(rule.Pattern)
+			if err == nil && pattern.MatchString(mention.Text) {
+				return rule
+			}
+		case db.ReplyRuleMatchFromUser:
+			if strings.EqualFold(strings.TrimPrefix(rule.Pattern, "@"), mention.User.ScreenName) {
+				return rule
+			}
+		}
+	}
+
+	return nil
+}
+
+// RenderReply renders the rule's ReplyTemplate for a mention, the reply is
+// prefixed with the mentioning user's @username if the template doesn't include it
+func RenderReply(rule db.ReplyRule, account string, mention twitter.Tweet) (string, error) {
+	return tweettext.RenderReply(rule.ReplyTemplate, tweettext.ReplyData{
+		Account:  account,
+		Username: mention.User.ScreenName,
+		Text:     mention.Text,
+	})
+}
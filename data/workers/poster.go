@@ -0,0 +1,246 @@
+package workers
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sironfoot/go-twitter-bot/data/db"
+	"github.com/sironfoot/go-twitter-bot/data/events"
+	"github.com/sironfoot/go-twitter-bot/lib/tweettext"
+	"github.com/sironfoot/go-twitter-bot/lib/twitter"
+	"github.com/sironfoot/go-twitter-bot/lib/webhook"
+)
+
+// tweetClaimLifetime is how long a worker has to post the Tweets it's claimed, before
+// they can be claimed by another worker in case it stopped part way through
+const tweetClaimLifetime = 10 * time.Minute
+
+// PosterWorker posts Tweets that are due, rendering each Tweet's text template at post time
+type PosterWorker struct {
+	Interval time.Duration
+
+	// Tweets that were posted but couldn't be saved as posted, they're saved again before posting any more
+	unsaved []*db.Tweet
+}
+
+// Run posts due Tweets every Interval until stop is closed
+func (worker *PosterWorker) Run(stop <-chan bool) {
+	ticker := time.NewTicker(worker.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := worker.Poll(); err != nil {
+				log.Printf("poster worker: %s\n", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Poll makes a single pass posting all Tweets that are due, claiming them first so
+// another worker running at the same time doesn't post them too
+func (worker *PosterWorker) Poll() error {
+	for len(worker.unsaved) > 0 {
+		if err := worker.unsaved[0].Save(); err != nil {
+			return err
+		}
+		worker.unsaved = worker.unsaved[1:]
+	}
+
+	now := time.Now().UTC()
+
+	tweets, err := db.TweetsClaimDue(now, now.Add(tweetClaimLifetime))
+	if err != nil {
+		return err
+	}
+
+	accounts := make(map[string]*db.TwitterAccount)
+	failedAccounts := make(map[string]bool)
+
+	for i := range tweets {
+		tweet := &tweets[i]
+
+		// one failure per account per pass, so a broken account doesn't hold up the others
+		if failedAccounts[tweet.AccountID] {
+			if err = tweet.ReleaseClaim(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		account, ok := accounts[tweet.AccountID]
+		if !ok {
+			accountList, err := db.TwitterAccountFromID(tweet.AccountID)
+			if err != nil {
+				return err
+			}
+
+			account = &accountList.TwitterAccount
+			accounts[tweet.AccountID] = account
+		}
+
+		if tweet.Attempts > 0 {
+			emitEvent(account, tweetEvent(webhook.EventTweetRetried, account, tweet))
+		}
+
+		text, posted, err := postTweet(account, tweet)
+		if posted && err != nil {
+			// Twitter has the tweet so it mustn't be posted again, keep trying to save it as posted while it's still claimed
+			log.Printf("poster worker: @%s: tweet %s was posted as status %s but not saved: %s\n",
+				account.Username, tweet.ID, tweet.StatusID.String, err)
+			worker.unsaved = append(worker.unsaved, tweet)
+		} else if err != nil {
+			failedAccounts[tweet.AccountID] = true
+			log.Printf("poster worker: @%s: tweet %s: %s\n", account.Username, tweet.ID, err)
+
+			if err = recordFailure(account, tweet, err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		payload := tweetEvent(webhook.EventTweetPosted, account, tweet)
+		payload.Tweet.Text = text
+		emitEvent(account, payload)
+		events.Publish(events.TweetPosted, account, tweet)
+	}
+
+	return nil
+}
+
+// recordFailure saves the error from a failed attempt to post a Tweet, schedules a retry and emits the failure events
+func recordFailure(account *db.TwitterAccount, tweet *db.Tweet, postErr error) error {
+	tweet.Attempts++
+	tweet.LastError = sql.NullString{String: postErr.Error(), Valid: true}
+	tweet.RetryAfter = pq.NullTime{Time: time.Now().UTC().Add(twitter.RetryBackoff(tweet.Attempts)), Valid: true}
+	tweet.ClaimedUntil = pq.NullTime{}
+
+	if err := tweet.Save(); err != nil {
+		return err
+	}
+
+	events.Publish(events.TweetFailed, account, tweet)
+
+	payload := tweetEvent(webhook.EventTweetFailed, account, tweet)
+	payload.Error = postErr.Error()
+	emitEvent(account, payload)
+
+	if twitter.IsUnauthorized(postErr) {
+		payload = webhook.NewPayload(webhook.EventCredentialsInvalid, account.Username)
+		payload.Error = postErr.Error()
+		emitEvent(account, payload)
+	}
+
+	return nil
+}
+
+// chooseVariant picks which of a Tweet's variants to post using the Tweet's VariantSelection,
+// returns nil if the Tweet has no variants and its own text should be posted
+func chooseVariant(tweet *db.Tweet, variants []db.TweetVariant) *db.TweetVariant {
+	var choices []tweettext.Variant
+	for _, variant := range variants {
+		choices = append(choices, tweettext.Variant{
+			Weight:      variant.Weight,
+			TimesPosted: variant.TimesPosted,
+		})
+	}
+
+	chosen := tweettext.ChooseVariant(tweet.VariantSelection, choices)
+	if chosen < 0 {
+		return nil
+	}
+
+	return &variants[chosen]
+}
+
+// renderTweet renders text template for the TwitterAccount at time 'now'
+func renderTweet(account *db.TwitterAccount, text string, now time.Time) (tweettext.Result, error) {
+	data := tweettext.Data{
+		Account: account.Username,
+	}
+
+	location, err := account.Location()
+	if err != nil {
+		return tweettext.Result{}, err
+	}
+	data.Now = now.In(location)
+
+	if tweettext.IsTemplate(text) {
+		data.Vars, err = account.GetTemplateVariables()
+		if err != nil {
+			return tweettext.Result{}, err
+		}
+
+		data.Counters, err = account.GetTemplateCounters()
+		if err != nil {
+			return tweettext.Result{}, err
+		}
+	}
+
+	return tweettext.Render(text, data)
+}
+
+// postTweet posts the Tweet to Twitter and records it as posted, returning the rendered text that was posted.
+// 'posted' is true once Twitter has accepted it, even if recording that fails, in which case it mustn't be posted again.
+func postTweet(account *db.TwitterAccount, tweet *db.Tweet) (postedText string, posted bool, err error) {
+	variants, err := tweet.GetVariants()
+	if err != nil {
+		return "", false, err
+	}
+
+	text := tweet.Tweet
+	variant := chooseVariant(tweet, variants)
+	if variant != nil {
+		text = variant.Tweet
+	}
+
+	result, err := renderTweet(account, text, time.Now().UTC())
+	if err != nil {
+		return "", false, err
+	}
+
+	client, err := twitter.NewClient(twitter.Credentials{
+		ConsumerKey:       account.ConsumerKey,
+		ConsumerSecret:    account.ConsumerSecret,
+		AccessToken:       account.AccessToken,
+		AccessTokenSecret: account.AccessTokenSecret,
+	})
+	if err != nil {
+		return "", false, err
+	}
+
+	status, err := client.UpdateStatus(result.Text, "")
+	if err != nil {
+		return "", false, err
+	}
+
+	tweet.IsPosted = true
+	tweet.LastError = sql.NullString{}
+	tweet.RetryAfter = pq.NullTime{}
+	tweet.StatusID = sql.NullString{String: status.ID, Valid: len(status.ID) > 0}
+	if variant != nil {
+		tweet.PostedVariantID = sql.NullString{String: variant.ID, Valid: true}
+	}
+
+	if err = tweet.Save(); err != nil {
+		return result.Text, true, err
+	}
+
+	if variant != nil {
+		variant.TimesPosted++
+		if err = variant.Save(); err != nil {
+			return result.Text, true, err
+		}
+	}
+
+	if tweettext.IsTemplate(text) {
+		return result.Text, true, account.SetTemplateCounters(result.Counters)
+	}
+
+	return result.Text, true, nil
+}
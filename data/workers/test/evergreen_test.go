@@ -0,0 +1,136 @@
+package workers_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sironfoot/go-twitter-bot/data/db"
+	"github.com/sironfoot/go-twitter-bot/data/workers"
+)
+
+func usedDaysAgo(now time.Time, days int) pq.NullTime {
+	return pq.NullTime{Time: now.AddDate(0, 0, -days), Valid: true}
+}
+
+func TestChooseEvergreenItem(t *testing.T) {
+	now := time.Date(2016, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		description    string
+		items          []db.EvergreenItem
+		noRepeatDays   int
+		expectedItemID string
+	}{
+		{
+			description:    "no items",
+			items:          []db.EvergreenItem{},
+			expectedItemID: "",
+		},
+		{
+			description: "never used items come first",
+			items: []db.EvergreenItem{
+				{ID: "used", LastUsed: usedDaysAgo(now, 50)},
+				{ID: "unused"},
+			},
+			expectedItemID: "unused",
+		},
+		{
+			description: "least recently used",
+			items: []db.EvergreenItem{
+				{ID: "recent", LastUsed: usedDaysAgo(now, 40)},
+				{ID: "oldest", LastUsed: usedDaysAgo(now, 60)},
+			},
+			expectedItemID: "oldest",
+		},
+		{
+			description: "paused items are skipped",
+			items: []db.EvergreenItem{
+				{ID: "paused", IsPaused: true},
+				{ID: "active", LastUsed: usedDaysAgo(now, 60)},
+			},
+			expectedItemID: "active",
+		},
+		{
+			description: "never repeat within account wide days",
+			items: []db.EvergreenItem{
+				{ID: "a", LastUsed: usedDaysAgo(now, 10)},
+				{ID: "b", LastUsed: usedDaysAgo(now, 20)},
+			},
+			noRepeatDays:   30,
+			expectedItemID: "",
+		},
+		{
+			description: "item minimum re-post interval",
+			items: []db.EvergreenItem{
+				{ID: "long_interval", MinRepostDays: 90, LastUsed: usedDaysAgo(now, 60)},
+				{ID: "short_interval", MinRepostDays: 7, LastUsed: usedDaysAgo(now, 30)},
+			},
+			expectedItemID: "short_interval",
+		},
+		{
+			description: "categories rotate after the last used category",
+			items: []db.EvergreenItem{
+				{ID: "blog", Category: "blog"},
+				{ID: "product", Category: "product", LastUsed: usedDaysAgo(now, 1)},
+				{ID: "tips", Category: "tips"},
+			},
+			expectedItemID: "tips",
+		},
+		{
+			description: "categories wrap around",
+			items: []db.EvergreenItem{
+				{ID: "blog", Category: "blog", LastUsed: usedDaysAgo(now, 30)},
+				{ID: "tips", Category: "tips", LastUsed: usedDaysAgo(now, 1)},
+			},
+			expectedItemID: "blog",
+		},
+	}
+
+	for _, testCase := range testCases {
+		item := workers.ChooseEvergreenItem(testCase.items, now, testCase.noRepeatDays)
+
+		actualID := ""
+		if item != nil {
+			actualID = item.ID
+		}
+
+		if actualID != testCase.expectedItemID {
+			t.Errorf("test case '%s': expected item '%s', got '%s'", testCase.description, testCase.expectedItemID, actualID)
+		}
+	}
+}
+
+func TestEvergreenSlotsBetween(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+
+	from := time.Date(2016, 5, 1, 14, 0, 0, 0, time.UTC) // 10:00 in New York
+	to := from.Add(24 * time.Hour)
+
+	slots, err := workers.EvergreenSlotsBetween([]string{"17:30", "09:00"}, from, to, newYork)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []time.Time{
+		time.Date(2016, 5, 1, 21, 30, 0, 0, time.UTC),
+		time.Date(2016, 5, 2, 13, 0, 0, 0, time.UTC),
+	}
+
+	if len(slots) != len(expected) {
+		t.Fatalf("expected %d slots, got %d: %v", len(expected), len(slots), slots)
+	}
+
+	for i := range expected {
+		if !slots[i].Equal(expected[i]) {
+			t.Errorf("slot %d: expected %s, got %s", i, expected[i], slots[i])
+		}
+	}
+
+	if _, err = workers.EvergreenSlotsBetween([]string{"9am"}, from, to, newYork); err == nil {
+		t.Error("expected an error for an invalid slot")
+	}
+}
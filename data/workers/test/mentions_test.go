@@ -0,0 +1,78 @@
+package workers_test
+
+import (
+	"testing"
+
+	"github.com/sironfoot/go-twitter-bot/data/db"
+	"github.com/sironfoot/go-twitter-bot/data/workers"
+	"github.com/sironfoot/go-twitter-bot/lib/twitter"
+)
+
+func newMention(username, text string) twitter.Tweet {
+	return twitter.Tweet{
+		ID:   "1000",
+		Text: text,
+		User: twitter.User{ScreenName: username},
+	}
+}
+
+func TestMatchReplyRule(t *testing.T) {
+	rules := []db.ReplyRule{
+		{ID: "disabled", MatchType: db.ReplyRuleMatchKeyword, Pattern: "hello", IsEnabled: false},
+		{ID: "keyword", MatchType: db.ReplyRuleMatchKeyword, Pattern: "price", IsEnabled: true},
+		{ID: "regex", MatchType: db.ReplyRuleMatchRegex, Pattern: `(?i)order #\d+`, IsEnabled: true},
+		{ID: "from_user", MatchType: db.ReplyRuleMatchFromUser, Pattern: "@VIP", IsEnabled: true},
+	}
+
+	testCases := []struct {
+		description    string
+		mention        twitter.Tweet
+		expectedRuleID string
+	}{
+		{"no match", newMention("someone", "@bot hello there"), ""},
+		{"keyword matches whole word, case insensitive", newMention("someone", "@bot what's the PRICE?"), "keyword"},
+		{"keyword doesn't match part of a word", newMention("someone", "@bot priceless"), ""},
+		{"regex", newMention("someone", "@bot where is Order #123"), "regex"},
+		{"from user, case insensitive", newMention("vip", "@bot hi"), "from_user"},
+		{"first matching rule wins", newMention("vip", "@bot price?"), "keyword"},
+	}
+
+	for _, testCase := range testCases {
+		rule := workers.MatchReplyRule(rules, testCase.mention)
+
+		ruleID := ""
+		if rule != nil {
+			ruleID = rule.ID
+		}
+
+		if ruleID != testCase.expectedRuleID {
+			t.Errorf("test case '%s': expected rule '%s' but got '%s'", testCase.description, testCase.expectedRuleID, ruleID)
+		}
+	}
+}
+
+func TestRenderReply(t *testing.T) {
+	mention := newMention("someone", "@mybot what's the price?")
+
+	rule := db.ReplyRule{ReplyTemplate: "Thanks {{.Username}}, @{{.Account}} will DM you"}
+	text, err := workers.RenderReply(rule, "mybot", mention)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "@someone Thanks someone, @mybot will DM you"
+	if text != expected {
+		t.Errorf("expected reply '%s' but got '%s'", expected, text)
+	}
+
+	rule = db.ReplyRule{ReplyTemplate: "@{{.Username}} see example.com"}
+	text, err = workers.RenderReply(rule, "mybot", mention)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = "@someone see example.com"
+	if text != expected {
+		t.Errorf("reply already starting with @username shouldn't be prefixed again, expected '%s' but got '%s'", expected, text)
+	}
+}
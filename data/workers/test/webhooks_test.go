@@ -0,0 +1,66 @@
+package workers_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sironfoot/go-twitter-bot/data/db"
+	"github.com/sironfoot/go-twitter-bot/data/workers"
+	"github.com/sironfoot/go-twitter-bot/lib/webhook"
+)
+
+func TestDeliver(t *testing.T) {
+	deliverySave := db.WebhookDeliverySave
+	deliver := webhook.Deliver
+	defer func() {
+		db.WebhookDeliverySave = deliverySave
+		webhook.Deliver = deliver
+	}()
+
+	saves := 0
+	db.WebhookDeliverySave = func(delivery *db.WebhookDelivery) error {
+		saves++
+		return nil
+	}
+
+	hook := &db.Webhook{URL: "https://example.com/hook", Secret: "a_very_secret_key"}
+	delivery := &db.WebhookDelivery{Event: webhook.EventTweetPosted, Payload: `{}`}
+
+	webhook.Deliver = func(url, secret, event string, body []byte) (int, error) {
+		return 500, errors.New("server error")
+	}
+
+	for attempt := 1; attempt <= webhook.MaxAttempts; attempt++ {
+		if err := workers.Deliver(hook, delivery); err == nil {
+			t.Fatal("expected delivery to fail")
+		}
+
+		if delivery.Attempts != attempt || !delivery.LastError.Valid || delivery.StatusCode.Int64 != 500 {
+			t.Errorf("attempt %d not recorded: %+v", attempt, delivery)
+		}
+
+		if attempt < webhook.MaxAttempts && !delivery.NextAttempt.Valid {
+			t.Errorf("attempt %d: expected a retry to be scheduled", attempt)
+		}
+	}
+
+	if delivery.NextAttempt.Valid {
+		t.Error("expected no retry after the maximum attempts")
+	}
+
+	webhook.Deliver = func(url, secret, event string, body []byte) (int, error) {
+		return 200, nil
+	}
+
+	if err := workers.Deliver(hook, delivery); err != nil {
+		t.Fatal(err)
+	}
+
+	if !delivery.IsDelivered || delivery.LastError.Valid || delivery.NextAttempt.Valid {
+		t.Errorf("successful delivery not recorded: %+v", delivery)
+	}
+
+	if saves != webhook.MaxAttempts+1 {
+		t.Errorf("expected the delivery to be saved after every attempt, saved %d times", saves)
+	}
+}
@@ -0,0 +1,144 @@
+package workers
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sironfoot/go-twitter-bot/data/db"
+	"github.com/sironfoot/go-twitter-bot/lib/webhook"
+)
+
+// WebhooksWorker delivers queued WebhookDelivery records, retrying failures with webhook.Backoff
+type WebhooksWorker struct {
+	Interval time.Duration
+}
+
+// Run delivers due webhooks every Interval until stop is closed
+func (worker *WebhooksWorker) Run(stop <-chan bool) {
+	ticker := time.NewTicker(worker.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := worker.Poll(); err != nil {
+				log.Printf("webhooks worker: %s\n", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Poll makes a single pass delivering all WebhookDelivery records that are due
+func (worker *WebhooksWorker) Poll() error {
+	deliveries, err := db.WebhookDeliveriesDue(time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	for i := range deliveries {
+		delivery := &deliveries[i]
+
+		hook, err := db.WebhookFromID(delivery.WebhookID)
+		if err != nil {
+			return err
+		}
+
+		err = Deliver(&hook, delivery)
+		if err != nil {
+			log.Printf("webhooks worker: delivery %s to %s: %s\n", delivery.ID, hook.URL, err)
+		}
+	}
+
+	return nil
+}
+
+// Deliver makes one attempt to send the WebhookDelivery, recording the outcome and scheduling
+// the next attempt on failure, until webhook.MaxAttempts is reached
+func Deliver(hook *db.Webhook, delivery *db.WebhookDelivery) error {
+	now := time.Now().UTC()
+
+	statusCode, deliverErr := webhook.Deliver(hook.URL, hook.Secret, delivery.Event, []byte(delivery.Payload))
+
+	delivery.Attempts++
+	delivery.LastAttempt = pq.NullTime{Time: now, Valid: true}
+	delivery.StatusCode = sql.NullInt64{Int64: int64(statusCode), Valid: statusCode != 0}
+
+	if deliverErr == nil {
+		delivery.IsDelivered = true
+		delivery.LastError = sql.NullString{}
+		delivery.NextAttempt = pq.NullTime{}
+	} else {
+		delivery.LastError = sql.NullString{String: deliverErr.Error(), Valid: true}
+
+		if delivery.Attempts < webhook.MaxAttempts {
+			delivery.NextAttempt = pq.NullTime{Time: now.Add(webhook.Backoff(delivery.Attempts)), Valid: true}
+		} else {
+			delivery.NextAttempt = pq.NullTime{}
+		}
+	}
+
+	if err := delivery.Save(); err != nil {
+		return err
+	}
+
+	return deliverErr
+}
+
+// EmitEvent queues a WebhookDelivery of payload for each of the TwitterAccount's Webhooks subscribed
+// to the payload's event, they are sent by the WebhooksWorker
+func EmitEvent(account *db.TwitterAccount, payload webhook.Payload) error {
+	hooks, err := account.GetWebhooksForEvent(payload.Event)
+	if err != nil {
+		return err
+	}
+
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	body, err := webhook.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	for _, hook := range hooks {
+		delivery := db.WebhookDelivery{
+			WebhookID:   hook.ID,
+			Event:       payload.Event,
+			Payload:     string(body),
+			NextAttempt: pq.NullTime{Time: time.Now().UTC(), Valid: true},
+			DateCreated: time.Now().UTC(),
+		}
+
+		if err = delivery.Save(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// tweetEvent returns a webhook.Payload for an event about a Tweet
+func tweetEvent(event string, account *db.TwitterAccount, tweet *db.Tweet) webhook.Payload {
+	payload := webhook.NewPayload(event, account.Username)
+	payload.Tweet = &webhook.Tweet{
+		ID:       tweet.ID,
+		Text:     tweet.Tweet,
+		PostOn:   tweet.PostOn,
+		StatusID: tweet.StatusID.String,
+		Attempts: tweet.Attempts,
+	}
+
+	return payload
+}
+
+// emitEvent is EmitEvent for workers, where a failure to queue a webhook is logged rather than stopping work
+func emitEvent(account *db.TwitterAccount, payload webhook.Payload) {
+	if err := EmitEvent(account, payload); err != nil {
+		log.Printf("webhooks: @%s: %s: %s\n", account.Username, payload.Event, err)
+	}
+}
@@ -0,0 +1,162 @@
+package authtoken
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Algorithm is the only signing algorithm used and accepted, HMAC-SHA256
+const Algorithm = "HS256"
+
+// MinSecretLength is the shortest secret a Key can have
+const MinSecretLength = 32
+
+var (
+	// ErrMalformed is returned by Verify when a token isn't in the header.claims.signature format
+	ErrMalformed = errors.New("authtoken: token is malformed")
+	// ErrUnknownKey is returned by Verify when a token was signed with a key that isn't active
+	ErrUnknownKey = errors.New("authtoken: token was signed with an unknown key")
+	// ErrInvalidSignature is returned by Verify when a token's signature doesn't match, i.e. it's been tampered with
+	ErrInvalidSignature = errors.New("authtoken: token signature is invalid")
+	// ErrExpired is returned by Verify when a token's expiry time has passed
+	ErrExpired = errors.New("authtoken: token has expired")
+	// ErrWeakKey is returned by Sign, and by Verify for a token naming the Key, when the Key's secret is shorter than MinSecretLength
+	ErrWeakKey = errors.New("authtoken: key secret is too short")
+)
+
+// Key is a secret used to sign and verify tokens. Tokens record the ID of the Key that signed them,
+// so keys can be rotated by signing with a new Key while the old ones are still accepted by Verify.
+type Key struct {
+	ID     string `json:"id"`
+	Secret string `json:"secret"`
+}
+
+// Claims are the values carried by a token, times are Unix seconds
+type Claims struct {
+	Subject   string `json:"sub"`
+	SessionID string `json:"sid"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+type header struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ"`
+	KeyID     string `json:"kid"`
+}
+
+// NewClaims returns Claims for a subject's session, issued now and expiring after lifetime
+func NewClaims(subject, sessionID string, now time.Time, lifetime time.Duration) Claims {
+	return Claims{
+		Subject:   subject,
+		SessionID: sessionID,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(lifetime).Unix(),
+	}
+}
+
+// Expires returns when the Claims expire
+func (claims Claims) Expires() time.Time {
+	return time.Unix(claims.ExpiresAt, 0).UTC()
+}
+
+// IsSigned determines if token looks like a signed token (see Sign), rather than some other kind of credential
+func IsSigned(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// Sign returns a JWT compatible token of the claims, signed by key
+func Sign(claims Claims, key Key) (string, error) {
+	if len(key.Secret) < MinSecretLength {
+		return "", ErrWeakKey
+	}
+
+	headerJSON, err := json.Marshal(header{Algorithm: Algorithm, Type: "JWT", KeyID: key.ID})
+	if err != nil {
+		return "", err
+	}
+
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := encode(headerJSON) + "." + encode(claimsJSON)
+	return unsigned + "." + encode(signature(key.Secret, unsigned)), nil
+}
+
+// Verify checks token was signed by one of keys and hasn't expired at now, returning its Claims.
+// Keys with a secret shorter than MinSecretLength are never trusted.
+func Verify(token string, keys []Key, now time.Time) (Claims, error) {
+	var claims Claims
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims, ErrMalformed
+	}
+
+	var tokenHeader header
+	if err := decode(parts[0], &tokenHeader); err != nil || tokenHeader.Algorithm != Algorithm {
+		return claims, ErrMalformed
+	}
+
+	var key *Key
+	for i := range keys {
+		if keys[i].ID == tokenHeader.KeyID {
+			key = &keys[i]
+			break
+		}
+	}
+
+	if key == nil {
+		return claims, ErrUnknownKey
+	}
+
+	// a short or empty secret is easily guessed, so anything signed with it could be forged
+	if len(key.Secret) < MinSecretLength {
+		return claims, ErrWeakKey
+	}
+
+	given, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return claims, ErrMalformed
+	}
+
+	if !hmac.Equal(given, signature(key.Secret, parts[0]+"."+parts[1])) {
+		return claims, ErrInvalidSignature
+	}
+
+	if err = decode(parts[1], &claims); err != nil {
+		return claims, ErrMalformed
+	}
+
+	if now.Unix() >= claims.ExpiresAt {
+		return claims, ErrExpired
+	}
+
+	return claims, nil
+}
+
+func signature(secret, unsigned string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return mac.Sum(nil)
+}
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decode(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
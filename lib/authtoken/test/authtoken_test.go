@@ -0,0 +1,89 @@
+package authtoken_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sironfoot/go-twitter-bot/lib/authtoken"
+)
+
+var oldKey = authtoken.Key{ID: "2017-01", Secret: "an_old_secret_that_is_long_enough_1"}
+var newKey = authtoken.Key{ID: "2017-02", Secret: "a_new_secret_that_is_long_enough_22"}
+
+func TestSignAndVerify(t *testing.T) {
+	now := time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	token, err := authtoken.Sign(authtoken.NewClaims("user-id", "session-id", now, time.Hour), newKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !authtoken.IsSigned(token) {
+		t.Errorf("expected '%s' to be recognised as a signed token", token)
+	}
+
+	claims, err := authtoken.Verify(token, []authtoken.Key{oldKey, newKey}, now.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if claims.Subject != "user-id" || claims.SessionID != "session-id" || claims.IssuedAt != now.Unix() || !claims.Expires().Equal(now.Add(time.Hour)) {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+
+	if _, err = authtoken.Verify(token, []authtoken.Key{newKey}, now.Add(time.Hour)); err != authtoken.ErrExpired {
+		t.Errorf("expected ErrExpired, got %v", err)
+	}
+
+	if _, err = authtoken.Verify(token, []authtoken.Key{oldKey}, now); err != authtoken.ErrUnknownKey {
+		t.Errorf("expected ErrUnknownKey once the signing key is retired, got %v", err)
+	}
+
+	wrongSecret := authtoken.Key{ID: newKey.ID, Secret: "not_the_secret_that_signed_it_333"}
+	if _, err = authtoken.Verify(token, []authtoken.Key{wrongSecret}, now); err != authtoken.ErrInvalidSignature {
+		t.Errorf("expected ErrInvalidSignature for a different secret, got %v", err)
+	}
+}
+
+func TestVerifyTampered(t *testing.T) {
+	now := time.Now()
+
+	token, err := authtoken.Sign(authtoken.NewClaims("user-id", "session-id", now, time.Hour), oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := authtoken.Sign(authtoken.NewClaims("admin-id", "session-id", now, time.Hour), newKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := strings.Split(token, ".")
+	otherParts := strings.Split(other, ".")
+
+	// claims for another user, with the original signature
+	tampered := parts[0] + "." + otherParts[1] + "." + parts[2]
+	if _, err = authtoken.Verify(tampered, []authtoken.Key{oldKey, newKey}, now); err != authtoken.ErrInvalidSignature {
+		t.Errorf("expected ErrInvalidSignature for tampered claims, got %v", err)
+	}
+
+	for _, malformed := range []string{"", "abc", "a.b", parts[0] + "." + parts[1] + ".!!!", "e30." + parts[1] + "." + parts[2]} {
+		if _, err = authtoken.Verify(malformed, []authtoken.Key{oldKey}, now); err != authtoken.ErrMalformed {
+			t.Errorf("expected ErrMalformed for '%s', got %v", malformed, err)
+		}
+	}
+
+	if authtoken.IsSigned("bGVnYWN5K3Rva2VuLw==") {
+		t.Error("expected a Base64 legacy token not to be recognised as signed")
+	}
+
+	if _, err = authtoken.Sign(authtoken.Claims{}, authtoken.Key{ID: "short", Secret: "too short"}); err != authtoken.ErrWeakKey {
+		t.Errorf("expected ErrWeakKey, got %v", err)
+	}
+
+	// a key with an empty secret configured for verifying
+	if _, err = authtoken.Verify(token, []authtoken.Key{{ID: oldKey.ID, Secret: ""}}, now); err != authtoken.ErrWeakKey {
+		t.Errorf("expected ErrWeakKey verifying with a short secret, got %v", err)
+	}
+}
@@ -0,0 +1,226 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sironfoot/go-twitter-bot/lib/webhook"
+)
+
+// Feed is a parsed RSS or Atom feed
+type Feed struct {
+	Title string
+	Items []Item
+}
+
+// Item is a single entry in a Feed, in the order it appeared in the feed
+type Item struct {
+	GUID      string
+	Title     string
+	Link      string
+	Summary   string
+	Published time.Time
+}
+
+// ErrUnknownFormat is returned when a document is neither an RSS nor an Atom feed
+var ErrUnknownFormat = errors.New("feed: document is not an RSS or Atom feed")
+
+// ErrPrivateAddress is returned when a feed URL is a loopback, private or link-local address
+// and AllowPrivateAddresses is off
+var ErrPrivateAddress = errors.New("feed: URL is a loopback, private or link-local address")
+
+// AllowPrivateAddresses lets feeds be fetched from loopback, private and link-local addresses. It's off so that
+// whoever can subscribe to a feed can't make requests to the internal network or cloud metadata services.
+var AllowPrivateAddresses = false
+
+const maxFeedLength = 10485760
+
+// httpClient checks each address it connects to, including after redirects, with webhook.IsPrivateIP.
+// There's no proxy, as the address connected to would be the proxy's.
+var httpClient = &http.Client{
+	Timeout: 30 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 30 * time.Second,
+			Control: webhook.DialControl(func() bool { return AllowPrivateAddresses }, ErrPrivateAddress),
+		}).DialContext,
+		TLSHandshakeTimeout: 10 * time.Second,
+	},
+}
+
+// CheckHost returns ErrPrivateAddress if host is localhost or a private IP address and AllowPrivateAddresses
+// is off. Host names are checked again by Fetch once they've been looked up.
+func CheckHost(host string) error {
+	if !AllowPrivateAddresses && webhook.IsPrivateHost(host) {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
+// Fetch downloads and parses the RSS or Atom feed at url, refusing private addresses unless AllowPrivateAddresses is on
+var Fetch = func(url string) (Feed, error) {
+	var feed Feed
+
+	res, err := httpClient.Get(url)
+	if err != nil {
+		return feed, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return feed, fmt.Errorf("feed: %s returned status %d", url, res.StatusCode)
+	}
+
+	return Parse(io.LimitReader(res.Body, maxFeedLength))
+}
+
+// Parse parses an RSS 2.0 or Atom 1.0 document
+func Parse(reader io.Reader) (Feed, error) {
+	var feed Feed
+
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(reader); err != nil {
+		return feed, err
+	}
+
+	root, err := rootElementName(buf.Bytes())
+	if err != nil {
+		return feed, err
+	}
+
+	switch root {
+	case "rss":
+		var doc rssDocument
+		if err = xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+			return feed, err
+		}
+		return doc.toFeed(), nil
+	case "feed":
+		var doc atomDocument
+		if err = xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+			return feed, err
+		}
+		return doc.toFeed(), nil
+	}
+
+	return feed, ErrUnknownFormat
+}
+
+func rootElementName(data []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return "", ErrUnknownFormat
+		} else if err != nil {
+			return "", err
+		}
+
+		if element, ok := token.(xml.StartElement); ok {
+			return element.Name.Local, nil
+		}
+	}
+}
+
+type rssDocument struct {
+	Channel struct {
+		Title string `xml:"title"`
+		Items []struct {
+			GUID        string `xml:"guid"`
+			Title       string `xml:"title"`
+			Link        string `xml:"link"`
+			Description string `xml:"description"`
+			PubDate     string `xml:"pubDate"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+func (doc rssDocument) toFeed() Feed {
+	feed := Feed{Title: strings.TrimSpace(doc.Channel.Title)}
+
+	for _, rssItem := range doc.Channel.Items {
+		item := Item{
+			GUID:      strings.TrimSpace(rssItem.GUID),
+			Title:     strings.TrimSpace(rssItem.Title),
+			Link:      strings.TrimSpace(rssItem.Link),
+			Summary:   strings.TrimSpace(rssItem.Description),
+			Published: parseTime(rssItem.PubDate, time.RFC1123Z, time.RFC1123, time.RFC822Z, time.RFC822),
+		}
+
+		if item.GUID == "" {
+			item.GUID = item.Link
+		}
+
+		feed.Items = append(feed.Items, item)
+	}
+
+	return feed
+}
+
+type atomDocument struct {
+	Title   string `xml:"title"`
+	Entries []struct {
+		ID    string `xml:"id"`
+		Title string `xml:"title"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Summary   string `xml:"summary"`
+		Published string `xml:"published"`
+		Updated   string `xml:"updated"`
+	} `xml:"entry"`
+}
+
+func (doc atomDocument) toFeed() Feed {
+	feed := Feed{Title: strings.TrimSpace(doc.Title)}
+
+	for _, entry := range doc.Entries {
+		item := Item{
+			GUID:    strings.TrimSpace(entry.ID),
+			Title:   strings.TrimSpace(entry.Title),
+			Summary: strings.TrimSpace(entry.Summary),
+		}
+
+		for _, link := range entry.Links {
+			if link.Rel == "" || link.Rel == "alternate" {
+				item.Link = strings.TrimSpace(link.Href)
+				break
+			}
+		}
+
+		published := entry.Published
+		if published == "" {
+			published = entry.Updated
+		}
+		item.Published = parseTime(published, time.RFC3339)
+
+		if item.GUID == "" {
+			item.GUID = item.Link
+		}
+
+		feed.Items = append(feed.Items, item)
+	}
+
+	return feed
+}
+
+func parseTime(value string, layouts ...string) time.Time {
+	value = strings.TrimSpace(value)
+
+	for _, layout := range layouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed.UTC()
+		}
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,52 @@
+package feed
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+)
+
+// TemplateData is the data made available to templates rendered by RenderTemplate,
+// e.g. "New post: {{.Title}} {{.Link}}"
+type TemplateData struct {
+	FeedTitle string
+	Title     string
+	Link      string
+	Summary   string
+}
+
+// SampleItem is a representative Item that can be used to check a template
+// renders, and roughly how long the result will be
+var SampleItem = Item{
+	GUID:    "https://example.com/blog/2016/04/a-sample-blog-post",
+	Title:   "A sample blog post with a reasonably long title",
+	Link:    "https://example.com/blog/2016/04/a-sample-blog-post",
+	Summary: "A summary of the sample blog post.",
+}
+
+// ParseTemplate parses text as a template for RenderTemplate
+func ParseTemplate(text string) (*template.Template, error) {
+	return template.New("feed").Option("missingkey=error").Parse(text)
+}
+
+// RenderTemplate renders the template text for an Item from Feed f
+func RenderTemplate(text string, f Feed, item Item) (string, error) {
+	tmpl, err := ParseTemplate(text)
+	if err != nil {
+		return "", err
+	}
+
+	data := TemplateData{
+		FeedTitle: f.Title,
+		Title:     item.Title,
+		Link:      item.Link,
+		Summary:   item.Summary,
+	}
+
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(buf.String()), nil
+}
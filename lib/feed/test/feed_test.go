@@ -0,0 +1,172 @@
+package feed_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sironfoot/go-twitter-bot/lib/feed"
+)
+
+const rssFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+	<channel>
+		<title>My Blog</title>
+		<item>
+			<title>Second Post</title>
+			<link>https://example.com/second-post</link>
+			<guid isPermaLink="false">post-2</guid>
+			<pubDate>Tue, 05 Apr 2016 19:30:00 +0000</pubDate>
+		</item>
+		<item>
+			<title>First Post</title>
+			<link>https://example.com/first-post</link>
+			<pubDate>Mon, 04 Apr 2016 19:30:00 GMT</pubDate>
+		</item>
+	</channel>
+</rss>`
+
+const atomFeed = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+	<title>My Atom Blog</title>
+	<entry>
+		<title>Atom Post</title>
+		<link rel="self" href="https://example.com/atom-post.xml"/>
+		<link href="https://example.com/atom-post"/>
+		<id>urn:uuid:60a76c80-d399-11d9-b93C-0003939e0af6</id>
+		<updated>2016-04-05T19:30:00Z</updated>
+		<summary>All about Atom</summary>
+	</entry>
+</feed>`
+
+func init() {
+	// test servers listen on loopback
+	feed.AllowPrivateAddresses = true
+}
+
+func serveFeed(body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(res, body)
+	}))
+}
+
+func TestFetchRSS(t *testing.T) {
+	server := serveFeed(rssFeed)
+	defer server.Close()
+
+	f, err := feed.Fetch(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if f.Title != "My Blog" {
+		t.Errorf("expected feed title 'My Blog', got '%s'", f.Title)
+	}
+
+	if len(f.Items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(f.Items))
+	}
+
+	if f.Items[0].GUID != "post-2" || f.Items[0].Link != "https://example.com/second-post" {
+		t.Errorf("first item not parsed correctly: %+v", f.Items[0])
+	}
+
+	if f.Items[0].Published.IsZero() || f.Items[1].Published.IsZero() {
+		t.Error("item publish dates weren't parsed")
+	}
+
+	if f.Items[1].GUID != "https://example.com/first-post" {
+		t.Errorf("item without a guid should fall back to its link, got '%s'", f.Items[1].GUID)
+	}
+}
+
+func TestFetchAtom(t *testing.T) {
+	server := serveFeed(atomFeed)
+	defer server.Close()
+
+	f, err := feed.Fetch(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(f.Items) != 1 {
+		t.Fatalf("expected 1 item, got %d", len(f.Items))
+	}
+
+	item := f.Items[0]
+	if item.GUID != "urn:uuid:60a76c80-d399-11d9-b93C-0003939e0af6" {
+		t.Errorf("expected entry id as GUID, got '%s'", item.GUID)
+	}
+
+	if item.Link != "https://example.com/atom-post" {
+		t.Errorf("expected alternate link, got '%s'", item.Link)
+	}
+
+	if item.Published.IsZero() {
+		t.Error("entry updated date wasn't used as the publish date")
+	}
+}
+
+func TestFetchErrors(t *testing.T) {
+	server := serveFeed("<html><body>Not a feed</body></html>")
+	defer server.Close()
+
+	_, err := feed.Fetch(server.URL)
+	if err != feed.ErrUnknownFormat {
+		t.Errorf("expected ErrUnknownFormat for a HTML page, got: %v", err)
+	}
+
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+
+	_, err = feed.Fetch(notFound.URL)
+	if err == nil {
+		t.Error("expected an error for a 404 response")
+	}
+}
+
+func TestFetchPrivateAddress(t *testing.T) {
+	server := serveFeed(rssFeed)
+	defer server.Close()
+
+	feed.AllowPrivateAddresses = false
+	defer func() { feed.AllowPrivateAddresses = true }()
+
+	if _, err := feed.Fetch(server.URL); err == nil || !strings.Contains(err.Error(), feed.ErrPrivateAddress.Error()) {
+		t.Errorf("expected a loopback feed to be refused, got: %v", err)
+	}
+
+	for _, host := range []string{"localhost", "127.0.0.1", "169.254.169.254", "10.1.2.3"} {
+		if err := feed.CheckHost(host); err != feed.ErrPrivateAddress {
+			t.Errorf("host '%s': expected ErrPrivateAddress, got %v", host, err)
+		}
+	}
+
+	if err := feed.CheckHost("example.com"); err != nil {
+		t.Errorf("expected example.com to be allowed, got %v", err)
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	f, err := feed.Parse(strings.NewReader(rssFeed))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text, err := feed.RenderTemplate("New post: {{.Title}} {{.Link}}", f, f.Items[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "New post: Second Post https://example.com/second-post"
+	if text != expected {
+		t.Errorf("expected '%s', got '%s'", expected, text)
+	}
+
+	_, err = feed.RenderTemplate("{{.Nonsense}}", f, f.Items[0])
+	if err == nil {
+		t.Error("expected an error for an unknown template field")
+	}
+}
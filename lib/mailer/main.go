@@ -0,0 +1,167 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DriverSMTP sends email through an SMTP server
+	DriverSMTP = "smtp"
+	// DriverFile appends email to a file instead of sending it, for development and tests
+	DriverFile = "file"
+	// DriverLog writes email to the log instead of sending it, for development and tests
+	DriverLog = "log"
+)
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends email
+type Mailer interface {
+	Send(message Message) error
+}
+
+// Config chooses and configures a Mailer, see New
+type Config struct {
+	Driver string     `json:"driver"`
+	From   string     `json:"from"`
+	SMTP   SMTPConfig `json:"smtp"`
+	File   string     `json:"file"`
+}
+
+// SMTPConfig is the SMTP server to send email through, Username and Password are optional
+type SMTPConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// New returns the Mailer for config.Driver, the log Mailer if no driver is set
+func New(config Config) (Mailer, error) {
+	switch config.Driver {
+	case DriverSMTP:
+		if config.SMTP.Host == "" || config.From == "" {
+			return nil, fmt.Errorf("mailer: smtp needs a host and from address")
+		}
+		return &SMTPMailer{From: config.From, Config: config.SMTP}, nil
+	case DriverFile:
+		if config.File == "" {
+			return nil, fmt.Errorf("mailer: file needs a file path")
+		}
+		return &FileMailer{From: config.From, Path: config.File}, nil
+	case DriverLog, "":
+		return &LogMailer{From: config.From}, nil
+	}
+
+	return nil, fmt.Errorf("mailer: unknown driver '%s', expected one of: %s, %s, %s", config.Driver, DriverSMTP, DriverFile, DriverLog)
+}
+
+// Format returns the message as an RFC 5322 email
+func Format(from string, message Message, now time.Time) []byte {
+	var email bytes.Buffer
+
+	fmt.Fprintf(&email, "From: %s\r\n", from)
+	fmt.Fprintf(&email, "To: %s\r\n", message.To)
+	fmt.Fprintf(&email, "Subject: %s\r\n", message.Subject)
+	fmt.Fprintf(&email, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	email.WriteString("MIME-Version: 1.0\r\n")
+	email.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	email.WriteString("\r\n")
+	email.WriteString(strings.Replace(message.Body, "\n", "\r\n", -1))
+	email.WriteString("\r\n")
+
+	return email.Bytes()
+}
+
+// checkHeaders rejects messages that would inject extra headers
+func checkHeaders(message Message) error {
+	if strings.ContainsAny(message.To, "\r\n") || strings.ContainsAny(message.Subject, "\r\n") {
+		return fmt.Errorf("mailer: headers cannot contain line breaks")
+	}
+	return nil
+}
+
+// SMTPMailer sends email through an SMTP server, using PLAIN auth when a username is configured
+type SMTPMailer struct {
+	From   string
+	Config SMTPConfig
+}
+
+// Send sends the message
+func (mailer *SMTPMailer) Send(message Message) error {
+	if err := checkHeaders(message); err != nil {
+		return err
+	}
+
+	port := mailer.Config.Port
+	if port == 0 {
+		port = 587
+	}
+	addr := net.JoinHostPort(mailer.Config.Host, strconv.Itoa(port))
+
+	var auth smtp.Auth
+	if mailer.Config.Username != "" {
+		auth = smtp.PlainAuth("", mailer.Config.Username, mailer.Config.Password, mailer.Config.Host)
+	}
+
+	return smtp.SendMail(addr, auth, mailer.From, []string{message.To}, Format(mailer.From, message, time.Now()))
+}
+
+// FileMailer appends each message to a file instead of sending it
+type FileMailer struct {
+	From string
+	Path string
+
+	lock sync.Mutex
+}
+
+// Send appends the message to the file
+func (mailer *FileMailer) Send(message Message) error {
+	if err := checkHeaders(message); err != nil {
+		return err
+	}
+
+	mailer.lock.Lock()
+	defer mailer.lock.Unlock()
+
+	file, err := os.OpenFile(mailer.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err = file.Write(append(Format(mailer.From, message, time.Now()), "\r\n"...)); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// LogMailer writes each message to the log instead of sending it
+type LogMailer struct {
+	From string
+}
+
+// Send writes the message to the log
+func (mailer *LogMailer) Send(message Message) error {
+	if err := checkHeaders(message); err != nil {
+		return err
+	}
+
+	log.Printf("mailer: to %s: %s\n%s\n", message.To, message.Subject, message.Body)
+	return nil
+}
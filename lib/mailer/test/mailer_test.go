@@ -0,0 +1,76 @@
+package mailer_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/sironfoot/go-twitter-bot/lib/mailer"
+)
+
+func TestNew(t *testing.T) {
+	var testCases = []struct {
+		config   mailer.Config
+		expected string
+	}{
+		{mailer.Config{}, "*mailer.LogMailer"},
+		{mailer.Config{Driver: mailer.DriverFile, File: "mail.txt"}, "*mailer.FileMailer"},
+		{mailer.Config{Driver: mailer.DriverSMTP, From: "bot@example.com", SMTP: mailer.SMTPConfig{Host: "localhost"}}, "*mailer.SMTPMailer"},
+	}
+
+	for _, testCase := range testCases {
+		m, err := mailer.New(testCase.config)
+		if err != nil {
+			t.Errorf("%s: %s", testCase.expected, err)
+			continue
+		}
+
+		if actual := fmt.Sprintf("%T", m); actual != testCase.expected {
+			t.Errorf("expected %s, got %s", testCase.expected, actual)
+		}
+	}
+
+	for _, invalid := range []mailer.Config{{Driver: "pigeon"}, {Driver: mailer.DriverSMTP}, {Driver: mailer.DriverFile}} {
+		if _, err := mailer.New(invalid); err == nil {
+			t.Errorf("expected %+v to be rejected", invalid)
+		}
+	}
+}
+
+func TestFileMailer(t *testing.T) {
+	path := "mail_test.txt"
+	defer os.Remove(path)
+
+	m, err := mailer.New(mailer.Config{Driver: mailer.DriverFile, From: "bot@example.com", File: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = m.Send(mailer.Message{To: "you@example.com", Subject: "Hello", Body: "Line one\nLine two"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = m.Send(mailer.Message{To: "you@example.com", Subject: "Again", Body: "Another"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	email := string(data)
+	for _, expected := range []string{"From: bot@example.com\r\n", "To: you@example.com\r\n", "Subject: Hello\r\n", "\r\n\r\nLine one\r\nLine two\r\n", "Subject: Again\r\n"} {
+		if !strings.Contains(email, expected) {
+			t.Errorf("expected the file to contain %q, got:\n%s", expected, email)
+		}
+	}
+
+	if err = m.Send(mailer.Message{To: "you@example.com\r\nBcc: them@example.com", Subject: "Hello"}); err == nil {
+		t.Error("expected a header with a line break to be rejected")
+	}
+}
@@ -0,0 +1,85 @@
+package totp_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sironfoot/go-twitter-bot/lib/totp"
+)
+
+// the RFC 6238 SHA1 secret "12345678901234567890", Base32 encoded
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	// RFC 6238 appendix B test vectors, truncated to 6 digits
+	var vectors = []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, vector := range vectors {
+		code, err := totp.Code(rfcSecret, totp.Step(time.Unix(vector.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if code != vector.code {
+			t.Errorf("at %d expected code %s, got %s", vector.unix, vector.code, code)
+		}
+	}
+
+	if _, err := totp.Code("not base32!", 1); err != totp.ErrInvalidSecret {
+		t.Errorf("expected ErrInvalidSecret, got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+
+	step, valid := totp.Validate(rfcSecret, "050471", now)
+	if !valid || step != totp.Step(now) {
+		t.Errorf("expected the current code to be valid at step %d, got %d %v", totp.Step(now), step, valid)
+	}
+
+	if _, valid = totp.Validate(rfcSecret, "050471", now.Add(totp.Period)); !valid {
+		t.Error("expected the previous step's code to be allowed for clock drift")
+	}
+
+	if _, valid = totp.Validate(rfcSecret, "050471", now.Add(3*totp.Period)); valid {
+		t.Error("expected an old code not to be valid")
+	}
+
+	for _, invalid := range []string{"", "12345", "1234567", "000000"} {
+		if _, valid = totp.Validate(rfcSecret, invalid, now); valid {
+			t.Errorf("expected '%s' not to be valid", invalid)
+		}
+	}
+}
+
+func TestNewSecretAndURI(t *testing.T) {
+	secret, err := totp.NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(secret) != 32 {
+		t.Errorf("expected a 32 character secret, got '%s'", secret)
+	}
+
+	if _, err = totp.Code(secret, 1); err != nil {
+		t.Errorf("expected a new secret to be usable, got %v", err)
+	}
+
+	uri := totp.URI("GoBot", "jane@example.com", secret)
+	if !strings.HasPrefix(uri, "otpauth://totp/GoBot:jane@example.com?") || !strings.Contains(uri, "secret="+secret) || !strings.Contains(uri, "issuer=GoBot") {
+		t.Errorf("unexpected URI: %s", uri)
+	}
+}
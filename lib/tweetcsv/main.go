@@ -0,0 +1,169 @@
+package tweetcsv
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Columns are the CSV header names, in the order Write outputs them. Read matches
+// them case-insensitively in any order, ignoring any other columns.
+var Columns = []string{"text", "postOn", "account", "tags"}
+
+// PostOnLayouts are the formats accepted in the postOn column, times without
+// a zone are in the location passed to Read
+var PostOnLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04"}
+
+// ErrNoTextColumn is returned by Read when the header row doesn't have a text column
+var ErrNoTextColumn = errors.New("tweetcsv: header row must include a 'text' column")
+
+const byteOrderMark = "\uFEFF"
+
+// Row is a Tweet read from, or to be written to, a CSV file
+type Row struct {
+	// Line is the row's line number in the file, the header being line 1
+	Line    int
+	Text    string
+	PostOn  time.Time
+	Account string
+	Tags    []string
+	// Errors holds problems reading the row's fields, a field with an error is left blank
+	Errors []FieldError
+}
+
+// FieldError is a problem reading one of a Row's fields
+type FieldError struct {
+	Column  string
+	Message string
+}
+
+// Read reads Rows from UTF-8 CSV (as saved by spreadsheets, including a byte order mark), rows that are
+// entirely blank are skipped. A blank postOn is left as the zero time for the caller to decide on.
+func Read(r io.Reader, location *time.Location) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, ErrNoTextColumn
+	} else if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, byteOrderMark))
+
+		for _, column := range Columns {
+			if strings.EqualFold(name, column) {
+				columns[column] = i
+			}
+		}
+	}
+
+	if _, ok := columns["text"]; !ok {
+		return nil, ErrNoTextColumn
+	}
+
+	var rows []Row
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		if isBlank(record) {
+			continue
+		}
+
+		cell := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row := Row{
+			Line:    line,
+			Text:    cell("text"),
+			Account: cell("account"),
+			Tags:    SplitTags(cell("tags")),
+		}
+
+		if postOn := cell("postOn"); postOn != "" {
+			row.PostOn, err = parsePostOn(postOn, location)
+			if err != nil {
+				row.Errors = append(row.Errors, FieldError{
+					Column:  "postOn",
+					Message: err.Error(),
+				})
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// Write writes a header row then the Rows as CSV, with postOn in RFC 3339 format in UTC
+func Write(w io.Writer, rows []Row) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(Columns); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		record := []string{
+			row.Text,
+			row.PostOn.UTC().Format(time.RFC3339),
+			row.Account,
+			strings.Join(row.Tags, ", "),
+		}
+
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// SplitTags splits a comma separated list of tags, trimming whitespace and dropping blanks
+func SplitTags(tags string) []string {
+	var split []string
+	for _, tag := range strings.Split(tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			split = append(split, tag)
+		}
+	}
+
+	return split
+}
+
+func parsePostOn(value string, location *time.Location) (time.Time, error) {
+	for _, layout := range PostOnLayouts {
+		if postOn, err := time.ParseInLocation(layout, value, location); err == nil {
+			return postOn.UTC(), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("'postOn' must be formatted as one of: %s", strings.Join(PostOnLayouts, ", "))
+}
+
+func isBlank(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+
+	return true
+}
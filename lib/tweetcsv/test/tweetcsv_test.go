@@ -0,0 +1,77 @@
+package tweetcsv_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sironfoot/go-twitter-bot/lib/tweetcsv"
+)
+
+func TestRead(t *testing.T) {
+	location, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := "\uFEFFTags,Text,postOn,Notes\n" +
+		"\"launch, promo\",Our new product is out!,2017-07-01 09:00,ignored\n" +
+		",,,\n" +
+		"  ,\"Line one\nline two\",2017-07-01T12:00:00Z\n" +
+		",Bad date,tomorrow\n"
+
+	rows, err := tweetcsv.Read(strings.NewReader(input), location)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rows) != 3 {
+		t.Fatalf("expected 3 rows, got %d", len(rows))
+	}
+
+	if rows[0].Line != 2 || rows[0].Text != "Our new product is out!" || len(rows[0].Tags) != 2 || rows[0].Tags[1] != "promo" {
+		t.Errorf("first row not read correctly: %+v", rows[0])
+	}
+
+	if !rows[0].PostOn.Equal(time.Date(2017, 7, 1, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected postOn without a zone to be in the location, got %s", rows[0].PostOn)
+	}
+
+	if rows[1].Line != 4 || rows[1].Text != "Line one\nline two" || rows[1].Tags != nil {
+		t.Errorf("second row not read correctly: %+v", rows[1])
+	}
+
+	if len(rows[2].Errors) != 1 || rows[2].Errors[0].Column != "postOn" || !rows[2].PostOn.IsZero() {
+		t.Errorf("expected a postOn error for the third row: %+v", rows[2])
+	}
+
+	if _, err = tweetcsv.Read(strings.NewReader("postOn,tags\n"), time.UTC); err != tweetcsv.ErrNoTextColumn {
+		t.Errorf("expected ErrNoTextColumn, got %v", err)
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	rows := []tweetcsv.Row{
+		{Text: "Hello, \"world\"", PostOn: time.Date(2017, 7, 1, 9, 0, 0, 0, time.UTC), Account: "my_bot", Tags: []string{"a", "b"}},
+	}
+
+	var buf bytes.Buffer
+	if err := tweetcsv.Write(&buf, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(buf.String(), "text,postOn,account,tags\n") {
+		t.Errorf("unexpected header: %s", buf.String())
+	}
+
+	read, err := tweetcsv.Read(&buf, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(read) != 1 || read[0].Text != rows[0].Text || !read[0].PostOn.Equal(rows[0].PostOn) ||
+		read[0].Account != "my_bot" || strings.Join(read[0].Tags, "|") != "a|b" {
+		t.Errorf("expected to read back what was written, got %+v", read)
+	}
+}
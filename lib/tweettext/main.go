@@ -0,0 +1,43 @@
+package tweettext
+
+import (
+	"regexp"
+)
+
+// MaxWeightedLength is the maximum weighted length of a Tweet, as counted by WeightedLength
+const MaxWeightedLength = 280
+
+// URLLength is the weighted length of any URL, Twitter shortens all links to t.co URLs of this length
+const URLLength = 23
+
+var isURL = regexp.MustCompile(`(?i)\bhttps?://[^\s]+`)
+
+// WeightedLength returns the length of text as counted by Twitter, where URLs count as URLLength
+// characters, and characters outside of the Latin, punctuation and symbol ranges (e.g. CJK
+// characters and emoji) count as 2 characters
+func WeightedLength(text string) int {
+	length := 0
+
+	text = isURL.ReplaceAllStringFunc(text, func(url string) string {
+		length += URLLength
+		return ""
+	})
+
+	for _, r := range text {
+		length += runeWeight(r)
+	}
+
+	return length
+}
+
+func runeWeight(r rune) int {
+	switch {
+	case r <= 4351,
+		r >= 8192 && r <= 8205,
+		r >= 8208 && r <= 8223,
+		r >= 8242 && r <= 8247:
+		return 1
+	}
+
+	return 2
+}
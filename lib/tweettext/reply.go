@@ -0,0 +1,43 @@
+package tweettext
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+)
+
+// ReplyData is the data an auto-reply template is rendered with, e.g. "Thanks {{.Username}}!"
+type ReplyData struct {
+	Account  string
+	Username string
+	Text     string
+}
+
+// SampleReplyData is representative ReplyData for checking a reply template renders before it's used
+var SampleReplyData = ReplyData{
+	Account:  "account",
+	Username: "someone",
+	Text:     "@account a sample mention asking a question",
+}
+
+// RenderReply renders text as a reply template with the given data, the reply is
+// prefixed with the mentioning user's @username if the template doesn't include it
+func RenderReply(text string, data ReplyData) (string, error) {
+	tmpl, err := template.New("reply").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	reply := strings.TrimSpace(buf.String())
+	mentionPrefix := "@" + data.Username
+	if !strings.HasPrefix(strings.ToLower(reply), strings.ToLower(mentionPrefix)) {
+		reply = mentionPrefix + " " + reply
+	}
+
+	return reply, nil
+}
@@ -0,0 +1,64 @@
+package tweettext
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ShingleSize is the number of consecutive words in each shingle compared by Similarity
+const ShingleSize = 2
+
+// Normalise reduces text to a canonical form for comparing Tweets, lower case with
+// punctuation removed and whitespace collapsed, leaving # and @ as they change meaning
+func Normalise(text string) string {
+	text = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) || r == '#' || r == '@' {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, text)
+
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// Shingles returns the set of ShingleSize word sequences in the normalised text,
+// text with fewer words than ShingleSize is a single shingle
+func Shingles(text string) map[string]bool {
+	shingles := make(map[string]bool)
+
+	words := strings.Fields(Normalise(text))
+	if len(words) == 0 {
+		return shingles
+	}
+
+	if len(words) < ShingleSize {
+		shingles[strings.Join(words, " ")] = true
+		return shingles
+	}
+
+	for i := 0; i+ShingleSize <= len(words); i++ {
+		shingles[strings.Join(words[i:i+ShingleSize], " ")] = true
+	}
+
+	return shingles
+}
+
+// Similarity is the Jaccard index of the Shingles of 'a' and 'b', from 0 (nothing in common) to 1 (the same)
+func Similarity(a, b string) float64 {
+	shinglesA := Shingles(a)
+	shinglesB := Shingles(b)
+
+	if len(shinglesA) == 0 && len(shinglesB) == 0 {
+		return 1
+	}
+
+	intersection := 0
+	for shingle := range shinglesA {
+		if shinglesB[shingle] {
+			intersection++
+		}
+	}
+
+	union := len(shinglesA) + len(shinglesB) - intersection
+	return float64(intersection) / float64(union)
+}
@@ -0,0 +1,117 @@
+package tweettext
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Data is the data a Tweet's text template is rendered with. Templates can use:
+//
+//	{{.Account}}                  the Twitter username of the account posting the Tweet
+//	{{.Now.Format "Jan 2"}}       the current time in the account's time zone
+//	{{daysUntil "2016-05-01"}}    whole days from today until a date (in the account's time zone)
+//	{{var "name"}}                a user defined variable
+//	{{counter "name"}}            the current value of a counter
+//	{{next "name"}}               increments a counter and returns the new value
+type Data struct {
+	Account  string
+	Now      time.Time
+	Vars     map[string]string
+	Counters map[string]int
+
+	// Sample renders variables as placeholders rather than failing when they aren't
+	// defined, so templates can be checked before the real values are known
+	Sample bool
+}
+
+// Result is the outcome of rendering a Tweet's text template
+type Result struct {
+	Text string
+	// Counters holds the value of every counter after rendering, including any
+	// incremented by {{next}}, so they can be saved once the Tweet is posted
+	Counters map[string]int
+}
+
+// IsTemplate determines if text contains any template actions
+func IsTemplate(text string) bool {
+	return strings.Contains(text, "{{")
+}
+
+// Escape escapes text so that it renders as is, e.g. text from an external
+// source such as a feed that may contain "{{"
+func Escape(text string) string {
+	return strings.Replace(text, "{{", `{{"{{"}}`, -1)
+}
+
+// SampleData returns Data for checking a template renders, and its weighted length,
+// before it's posted. Counters start from 0 and variables render as their names.
+func SampleData(account string, now time.Time) Data {
+	return Data{
+		Account: account,
+		Now:     now,
+		Sample:  true,
+	}
+}
+
+// Render renders text as a template with the given data. Text without any template actions is returned as is.
+func Render(text string, data Data) (Result, error) {
+	result := Result{
+		Text:     text,
+		Counters: make(map[string]int),
+	}
+
+	for name, value := range data.Counters {
+		result.Counters[name] = value
+	}
+
+	if !IsTemplate(text) {
+		return result, nil
+	}
+
+	funcs := template.FuncMap{
+		"daysUntil": func(date string) (int, error) {
+			until, err := time.Parse("2006-01-02", date)
+			if err != nil {
+				return 0, err
+			}
+
+			// compare calendar dates in UTC so DST changes don't produce 23 or 25 hour days
+			today := time.Date(data.Now.Year(), data.Now.Month(), data.Now.Day(), 0, 0, 0, 0, time.UTC)
+			return int(until.Sub(today).Hours() / 24), nil
+		},
+		"var": func(name string) (string, error) {
+			if value, ok := data.Vars[name]; ok {
+				return value, nil
+			}
+
+			if data.Sample {
+				return name, nil
+			}
+
+			return "", fmt.Errorf("variable '%s' is not defined", name)
+		},
+		"counter": func(name string) int {
+			return result.Counters[name]
+		},
+		"next": func(name string) int {
+			result.Counters[name]++
+			return result.Counters[name]
+		},
+	}
+
+	tmpl, err := template.New("tweet").Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return result, err
+	}
+
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, data); err != nil {
+		return result, err
+	}
+
+	result.Text = strings.TrimSpace(buf.String())
+	return result, nil
+}
@@ -0,0 +1,169 @@
+package tweettext_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sironfoot/go-twitter-bot/lib/tweettext"
+)
+
+func TestWeightedLength(t *testing.T) {
+	testCases := []struct {
+		description string
+		text        string
+		expected    int
+	}{
+		{"latin text", "Hello world", 11},
+		{"CJK characters count double", "こんにちは", 10},
+		{"emoji count double", "🎉", 2},
+		{"urls count as 23", "Read https://example.com/a/really/long/path/to/a/blog/post now", 5 + 23 + 4},
+	}
+
+	for _, testCase := range testCases {
+		if actual := tweettext.WeightedLength(testCase.text); actual != testCase.expected {
+			t.Errorf("test case '%s': expected %d, got %d", testCase.description, testCase.expected, actual)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+
+	data := tweettext.Data{
+		Account:  "mybot",
+		Now:      time.Date(2016, 3, 29, 23, 30, 0, 0, time.UTC).In(london), // 00:30 on the 30th in London
+		Vars:     map[string]string{"product": "GoBot 2"},
+		Counters: map[string]int{"episode": 41},
+	}
+
+	testCases := []struct {
+		text     string
+		expected string
+	}{
+		{"No template here", "No template here"},
+		{`{{daysUntil "2016-04-01"}} days until {{var "product"}}!`, "2 days until GoBot 2!"},
+		{`Episode {{next "episode"}} from @{{.Account}}`, "Episode 42 from @mybot"},
+		{`{{.Now.Format "Jan 2"}}`, "Mar 30"},
+	}
+
+	for _, testCase := range testCases {
+		result, err := tweettext.Render(testCase.text, data)
+		if err != nil {
+			t.Errorf("'%s': %s", testCase.text, err)
+			continue
+		}
+
+		if result.Text != testCase.expected {
+			t.Errorf("expected '%s', got '%s'", testCase.expected, result.Text)
+		}
+	}
+
+	if data.Counters["episode"] != 41 {
+		t.Error("Render should not modify the counters passed in")
+	}
+
+	result, err := tweettext.Render(`{{next "episode"}} {{next "episode"}} {{counter "episode"}}`, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Text != "42 43 43" || result.Counters["episode"] != 43 {
+		t.Errorf("counters not incremented correctly, got '%s' and %d", result.Text, result.Counters["episode"])
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	data := tweettext.Data{Now: time.Now()}
+
+	for _, text := range []string{`{{var "undefined"}}`, `{{.Nonsense}}`, `{{daysUntil "next week"}}`, `{{if}}`} {
+		if _, err := tweettext.Render(text, data); err == nil {
+			t.Errorf("expected an error rendering '%s'", text)
+		}
+	}
+
+	// undefined variables render as their name with sample data
+	result, err := tweettext.Render(`Launching {{var "product"}}`, tweettext.SampleData("sample", time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Text != "Launching product" {
+		t.Errorf("expected undefined variable to render as its name, got '%s'", result.Text)
+	}
+}
+
+func TestEscape(t *testing.T) {
+	text := "Templates look like {{.Title}}"
+
+	result, err := tweettext.Render(tweettext.Escape(text), tweettext.Data{Now: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Text != text {
+		t.Errorf("expected escaped text to render as '%s', got '%s'", text, result.Text)
+	}
+
+	if !strings.Contains(tweettext.Escape(text), `{{"{{"}}`) {
+		t.Error("expected {{ to be escaped")
+	}
+}
+
+func TestChooseVariant(t *testing.T) {
+	if chosen := tweettext.ChooseVariant(tweettext.SelectRandom, nil); chosen != -1 {
+		t.Errorf("expected -1 with no variants, got %d", chosen)
+	}
+
+	variants := []tweettext.Variant{
+		{Weight: 1, TimesPosted: 2},
+		{Weight: 0, TimesPosted: 1},
+		{Weight: 3, TimesPosted: 1},
+	}
+
+	if chosen := tweettext.ChooseVariant(tweettext.SelectRoundRobin, variants); chosen != 1 {
+		t.Errorf("expected round robin to choose the earliest least posted variant, got %d", chosen)
+	}
+
+	for i := 0; i < 100; i++ {
+		chosen := tweettext.ChooseVariant(tweettext.SelectWeighted, variants)
+		if chosen == 1 {
+			t.Fatal("expected weighted selection never to choose a variant with 0 weight")
+		}
+
+		chosen = tweettext.ChooseVariant(tweettext.SelectRandom, variants)
+		if chosen < 0 || chosen >= len(variants) {
+			t.Fatalf("random selection out of range: %d", chosen)
+		}
+	}
+}
+
+func TestNormalise(t *testing.T) {
+	if actual := tweettext.Normalise("  Hello,   WORLD! #GoLang @Gopher "); actual != "hello world #golang @gopher" {
+		t.Errorf("unexpected normalised text '%s'", actual)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	testCases := []struct {
+		description string
+		a, b        string
+		minimum     float64
+		maximum     float64
+	}{
+		{"identical once normalised", "New blog post: Go tips!", "new blog post go tips", 1, 1},
+		{"one word changed", "Check out our new blog post about writing bots in Go", "Check out our new blog post about writing bots in Rust", 0.7, 0.99},
+		{"unrelated", "Check out our new blog post", "Happy Friday everyone, have a great weekend", 0, 0.1},
+	}
+
+	for _, testCase := range testCases {
+		similarity := tweettext.Similarity(testCase.a, testCase.b)
+		if similarity < testCase.minimum || similarity > testCase.maximum {
+			t.Errorf("test case '%s': expected similarity between %.2f and %.2f, got %.2f",
+				testCase.description, testCase.minimum, testCase.maximum, similarity)
+		}
+	}
+}
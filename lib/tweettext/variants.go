@@ -0,0 +1,71 @@
+package tweettext
+
+import "math/rand"
+
+const (
+	// SelectRandom picks any variant with equal probability
+	SelectRandom = "random"
+	// SelectRoundRobin picks the variant posted the fewest times, earliest variant first
+	SelectRoundRobin = "round_robin"
+	// SelectWeighted picks a variant at random in proportion to its Weight
+	SelectWeighted = "weighted"
+)
+
+// VariantSelections is a list of allowed variant selection methods for ChooseVariant
+var VariantSelections = []string{
+	SelectRandom,
+	SelectRoundRobin,
+	SelectWeighted,
+}
+
+// Variant describes one of a Tweet's alternative texts for ChooseVariant
+type Variant struct {
+	Weight      int
+	TimesPosted int
+}
+
+// ChooseVariant returns the index of the variant to post using the selection method,
+// an unknown selection method is treated as SelectRandom. Returns -1 if there are no variants.
+func ChooseVariant(selection string, variants []Variant) int {
+	if len(variants) == 0 {
+		return -1
+	}
+
+	switch selection {
+	case SelectRoundRobin:
+		chosen := 0
+		for i, variant := range variants {
+			if variant.TimesPosted < variants[chosen].TimesPosted {
+				chosen = i
+			}
+		}
+		return chosen
+
+	case SelectWeighted:
+		totalWeight := 0
+		for _, variant := range variants {
+			if variant.Weight > 0 {
+				totalWeight += variant.Weight
+			}
+		}
+
+		// all weights are 0, fall back to an equal chance
+		if totalWeight == 0 {
+			break
+		}
+
+		n := rand.Intn(totalWeight)
+		for i, variant := range variants {
+			if variant.Weight <= 0 {
+				continue
+			}
+
+			if n < variant.Weight {
+				return i
+			}
+			n -= variant.Weight
+		}
+	}
+
+	return rand.Intn(len(variants))
+}
@@ -0,0 +1,92 @@
+package twitter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/mrjones/oauth"
+)
+
+const maxResponseLength = 1048576
+
+type apiClient struct {
+	httpClient *http.Client
+}
+
+// NewClient returns a Client that makes OAuth signed requests to the Twitter REST API
+var NewClient = func(credentials Credentials) (Client, error) {
+	consumer := oauth.NewConsumer(credentials.ConsumerKey, credentials.ConsumerSecret, oauth.ServiceProvider{})
+
+	accessToken := oauth.AccessToken{
+		Token:  credentials.AccessToken,
+		Secret: credentials.AccessTokenSecret,
+	}
+
+	httpClient, err := consumer.MakeHttpClient(&accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &apiClient{httpClient: httpClient}, nil
+}
+
+func (client *apiClient) UpdateStatus(status, inReplyToStatusID string) (Tweet, error) {
+	var tweet Tweet
+
+	values := url.Values{"status": []string{status}}
+	if inReplyToStatusID != "" {
+		values.Set("in_reply_to_status_id", inReplyToStatusID)
+	}
+
+	res, err := client.httpClient.PostForm(APIBaseURL+"/statuses/update.json", values)
+	if err != nil {
+		return tweet, fmt.Errorf("error posting to twitter: %s", err)
+	}
+
+	err = decodeResponse(res, &tweet)
+	return tweet, err
+}
+
+func (client *apiClient) MentionsTimeline(sinceID string) ([]Tweet, error) {
+	var tweets []Tweet
+
+	values := url.Values{"count": []string{"200"}}
+	if sinceID != "" {
+		values.Set("since_id", sinceID)
+	}
+
+	res, err := client.httpClient.Get(APIBaseURL + "/statuses/mentions_timeline.json?" + values.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("error reading mentions from twitter: %s", err)
+	}
+
+	err = decodeResponse(res, &tweets)
+	return tweets, err
+}
+
+func decodeResponse(res *http.Response, v interface{}) error {
+	defer res.Body.Close()
+	body := io.LimitReader(res.Body, maxResponseLength)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		data, _ := ioutil.ReadAll(body)
+		twitterErr := &Error{
+			StatusCode: res.StatusCode,
+			Message:    string(data),
+		}
+
+		if reset, err := strconv.ParseInt(res.Header.Get("x-rate-limit-reset"), 10, 64); err == nil {
+			twitterErr.RateLimitReset = time.Unix(reset, 0).UTC()
+		}
+
+		return twitterErr
+	}
+
+	return json.NewDecoder(body).Decode(v)
+}
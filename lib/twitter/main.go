@@ -0,0 +1,98 @@
+package twitter
+
+import (
+	"fmt"
+	"time"
+)
+
+// APIBaseURL is the base URL of the Twitter REST API, all Client requests are made relative to it
+var APIBaseURL = "https://api.twitter.com/1.1"
+
+// Credentials holds the OAuth keys and tokens required to act on behalf of a Twitter account
+type Credentials struct {
+	ConsumerKey       string
+	ConsumerSecret    string
+	AccessToken       string
+	AccessTokenSecret string
+}
+
+// Tweet represents a status returned from the Twitter REST API
+type Tweet struct {
+	ID                string `json:"id_str"`
+	Text              string `json:"text"`
+	InReplyToStatusID string `json:"in_reply_to_status_id_str"`
+	User              User   `json:"user"`
+}
+
+// User represents the author of a Tweet
+type User struct {
+	ID         string `json:"id_str"`
+	ScreenName string `json:"screen_name"`
+}
+
+// Client defines the Twitter REST API operations used by the bot and the data server
+type Client interface {
+	// UpdateStatus posts a new status, inReplyToStatusID can be blank if the status isn't a reply
+	UpdateStatus(status, inReplyToStatusID string) (Tweet, error)
+	// MentionsTimeline returns mentions more recent than sinceID (newest first), sinceID can
+	// be blank to return the most recent mentions
+	MentionsTimeline(sinceID string) ([]Tweet, error)
+}
+
+// Error is returned when the Twitter REST API responds with a non 2xx status code
+type Error struct {
+	StatusCode int
+	Message    string
+	// RateLimitReset is when the rate limit window resets, from the x-rate-limit-reset header, zero if not sent
+	RateLimitReset time.Time
+}
+
+func (err *Error) Error() string {
+	return fmt.Sprintf("twitter: %d %s", err.StatusCode, err.Message)
+}
+
+// IsUnauthorized determines if err is a 401 response from Twitter, i.e. the credentials are invalid or have been revoked
+func IsUnauthorized(err error) bool {
+	twitterErr, ok := err.(*Error)
+	return ok && twitterErr.StatusCode == 401
+}
+
+// IsRateLimited determines if err is a 429 response from Twitter, i.e. the account has hit a rate limit
+func IsRateLimited(err error) bool {
+	twitterErr, ok := err.(*Error)
+	return ok && twitterErr.StatusCode == 429
+}
+
+// RetryBackoff is how long to wait before retrying a status update that has failed 'attempts' times,
+// doubling from a minute up to an hour
+func RetryBackoff(attempts int) time.Duration {
+	wait := time.Minute
+	for i := 1; i < attempts && wait < time.Hour; i++ {
+		wait *= 2
+	}
+
+	if wait > time.Hour {
+		wait = time.Hour
+	}
+	return wait
+}
+
+// CompareIDs compares two numeric Tweet IDs (as strings), returning -1 if id1 is
+// less than id2, 0 if they're equal, and 1 if id1 is greater than id2
+func CompareIDs(id1, id2 string) int {
+	if len(id1) != len(id2) {
+		if len(id1) < len(id2) {
+			return -1
+		}
+		return 1
+	}
+
+	switch {
+	case id1 < id2:
+		return -1
+	case id1 > id2:
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,130 @@
+package twitter_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sironfoot/go-twitter-bot/lib/twitter"
+)
+
+func newTestServer(t *testing.T, handler http.HandlerFunc) func() {
+	server := httptest.NewServer(handler)
+
+	apiBaseURL := twitter.APIBaseURL
+	twitter.APIBaseURL = server.URL
+
+	return func() {
+		twitter.APIBaseURL = apiBaseURL
+		server.Close()
+	}
+}
+
+func newTestClient(t *testing.T) twitter.Client {
+	client, err := twitter.NewClient(twitter.Credentials{
+		ConsumerKey:       "key",
+		ConsumerSecret:    "secret",
+		AccessToken:       "token",
+		AccessTokenSecret: "token_secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+func TestMentionsTimeline(t *testing.T) {
+	tearDown := newTestServer(t, func(res http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/statuses/mentions_timeline.json" {
+			t.Errorf("unexpected path: %s", req.URL.Path)
+		}
+
+		if sinceID := req.URL.Query().Get("since_id"); sinceID != "100" {
+			t.Errorf("expected since_id 100, got '%s'", sinceID)
+		}
+
+		if req.Header.Get("Authorization") == "" {
+			t.Error("request wasn't OAuth signed")
+		}
+
+		fmt.Fprint(res, `[{"id_str": "102", "text": "@bot hi", "user": {"id_str": "1", "screen_name": "someone"}},
+			{"id_str": "101", "text": "@bot hello", "user": {"id_str": "2", "screen_name": "another"}}]`)
+	})
+	defer tearDown()
+
+	mentions, err := newTestClient(t).MentionsTimeline("100")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(mentions) != 2 {
+		t.Fatalf("expected 2 mentions, got %d", len(mentions))
+	}
+
+	if mentions[0].ID != "102" || mentions[0].User.ScreenName != "someone" {
+		t.Errorf("mention not decoded correctly: %+v", mentions[0])
+	}
+}
+
+func TestUpdateStatusError(t *testing.T) {
+	tearDown := newTestServer(t, func(res http.ResponseWriter, req *http.Request) {
+		if inReplyTo := req.FormValue("in_reply_to_status_id"); inReplyTo != "101" {
+			t.Errorf("expected in_reply_to_status_id 101, got '%s'", inReplyTo)
+		}
+
+		res.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(res, `{"errors": [{"code": 187, "message": "Status is a duplicate."}]}`)
+	})
+	defer tearDown()
+
+	_, err := newTestClient(t).UpdateStatus("@someone hi", "101")
+
+	twitterErr, ok := err.(*twitter.Error)
+	if !ok {
+		t.Fatalf("expected a *twitter.Error, got: %v", err)
+	}
+
+	if twitterErr.StatusCode != http.StatusForbidden {
+		t.Errorf("expected status code 403, got %d", twitterErr.StatusCode)
+	}
+}
+
+func TestUpdateStatusRateLimited(t *testing.T) {
+	tearDown := newTestServer(t, func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("x-rate-limit-reset", "1500000000")
+		res.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(res, `{"errors": [{"code": 88, "message": "Rate limit exceeded"}]}`)
+	})
+	defer tearDown()
+
+	_, err := newTestClient(t).UpdateStatus("hi", "")
+
+	if !twitter.IsRateLimited(err) {
+		t.Fatalf("expected a rate limit error, got: %v", err)
+	}
+
+	if reset := err.(*twitter.Error).RateLimitReset; !reset.Equal(time.Unix(1500000000, 0)) {
+		t.Errorf("expected rate limit reset at 1500000000, got %s", reset)
+	}
+}
+
+func TestCompareIDs(t *testing.T) {
+	testCases := []struct {
+		id1, id2 string
+		expected int
+	}{
+		{"99", "100", -1},
+		{"100", "99", 1},
+		{"123", "123", 0},
+		{"124", "123", 1},
+	}
+
+	for _, testCase := range testCases {
+		if actual := twitter.CompareIDs(testCase.id1, testCase.id2); actual != testCase.expected {
+			t.Errorf("CompareIDs(%s, %s): expected %d, got %d", testCase.id1, testCase.id2, testCase.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// EventTweetPosted is sent when a Tweet is posted to Twitter
+	EventTweetPosted = "tweet.posted"
+	// EventTweetFailed is sent each time posting a Tweet to Twitter fails
+	EventTweetFailed = "tweet.failed"
+	// EventTweetRetried is sent when posting a Tweet is tried again after failing
+	EventTweetRetried = "tweet.retried"
+	// EventCredentialsInvalid is sent when Twitter rejects an account's credentials
+	EventCredentialsInvalid = "account.credentials_invalid"
+)
+
+// Events is a list of all events that can be subscribed to
+var Events = []string{
+	EventTweetPosted,
+	EventTweetFailed,
+	EventTweetRetried,
+	EventCredentialsInvalid,
+}
+
+const (
+	// SignatureHeader holds the HMAC-SHA256 signature of the timestamp and request body, see Sign
+	SignatureHeader = "X-GoBot-Signature"
+	// TimestampHeader holds the Unix time the request was signed at, see Sign
+	TimestampHeader = "X-GoBot-Timestamp"
+	// EventHeader holds the event type of the payload
+	EventHeader = "X-GoBot-Event"
+	// DeliveryHeader holds a unique ID for each delivery attempt
+	DeliveryHeader = "X-GoBot-Delivery"
+)
+
+// MaxAttempts is how many times a payload is delivered before giving up
+const MaxAttempts = 5
+
+// MaxTimestampAge is how far a request's TimestampHeader can be from the receiver's clock before
+// Verify rejects it, so a captured request can't be replayed later
+const MaxTimestampAge = 5 * time.Minute
+
+// ErrPrivateAddress is returned when a webhook URL is a loopback, private or link-local address
+// and AllowPrivateAddresses is off
+var ErrPrivateAddress = errors.New("webhook: URL is a loopback, private or link-local address")
+
+// AllowPrivateAddresses lets webhooks be delivered to loopback, private and link-local addresses. It's off so that
+// whoever can set a webhook URL can't make requests to the internal network or cloud metadata services, programs
+// where webhook URLs come from trusted configuration can turn it on.
+var AllowPrivateAddresses = false
+
+// Payload is the JSON body sent to webhook URLs
+type Payload struct {
+	ID      string    `json:"id"`
+	Event   string    `json:"event"`
+	Account string    `json:"account"`
+	Time    time.Time `json:"time"`
+	Tweet   *Tweet    `json:"tweet,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// Tweet describes the Tweet an event is about
+type Tweet struct {
+	ID       string    `json:"id,omitempty"`
+	Text     string    `json:"text"`
+	PostOn   time.Time `json:"postOn"`
+	StatusID string    `json:"statusId,omitempty"`
+	Attempts int       `json:"attempts"`
+}
+
+// NewPayload returns a Payload for an event with a new unique ID
+func NewPayload(event, account string) Payload {
+	return Payload{
+		ID:      NewID(),
+		Event:   event,
+		Account: account,
+		Time:    time.Now().UTC(),
+	}
+}
+
+// NewID returns a random hex ID for payloads and deliveries
+func NewID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// Sign returns the value of the SignatureHeader for 'body' sent at Unix time 'timestamp', "sha256=" followed by
+// the hex encoded HMAC-SHA256 using secret of the timestamp, a '.' and the body
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks that signature is a valid SignatureHeader for 'body' and the TimestampHeader 'timestamp' using
+// secret, and that the timestamp is within MaxTimestampAge of 'now', for webhook receivers
+func Verify(secret string, body []byte, timestamp, signature string, now time.Time) bool {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	if age := now.Sub(time.Unix(unix, 0)); age > MaxTimestampAge || age < -MaxTimestampAge {
+		return false
+	}
+
+	return hmac.Equal([]byte(Sign(secret, unix, body)), []byte(signature))
+}
+
+// IsPrivateIP determines if ip is a loopback, private, link-local or unspecified address
+func IsPrivateIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified()
+}
+
+// IsPrivateHost determines if host, from a URL, is localhost or a private IP address (see IsPrivateIP).
+// Host names other than localhost can only be checked once they've been looked up.
+func IsPrivateHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && IsPrivateIP(ip)
+}
+
+// DialControl returns a net.Dialer Control function that refuses to connect to private IP addresses (see
+// IsPrivateIP) with 'err', unless allow returns true. It checks every connection, including after redirects
+// and once host names have been looked up.
+func DialControl(allow func() bool, err error) func(network, address string, conn syscall.RawConn) error {
+	return func(network, address string, conn syscall.RawConn) error {
+		host, _, splitErr := net.SplitHostPort(address)
+		if splitErr != nil {
+			return splitErr
+		}
+
+		if ip := net.ParseIP(host); ip != nil && !allow() && IsPrivateIP(ip) {
+			return err
+		}
+		return nil
+	}
+}
+
+// CheckHost returns ErrPrivateAddress if host is localhost or a private IP address (see IsPrivateIP) and
+// AllowPrivateAddresses is off. Host names are checked again by Deliver once they've been looked up.
+func CheckHost(host string) error {
+	if !AllowPrivateAddresses && IsPrivateHost(host) {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
+// Backoff is how long to wait before delivery attempt number 'attempt' (starting at 1) is retried,
+// doubling from 30 seconds up to an hour
+func Backoff(attempt int) time.Duration {
+	wait := 30 * time.Second
+	for i := 1; i < attempt && wait < time.Hour; i++ {
+		wait *= 2
+	}
+
+	if wait > time.Hour {
+		wait = time.Hour
+	}
+	return wait
+}
+
+// httpClient checks each address it connects to, including after redirects, with IsPrivateIP. There's no proxy,
+// as the address connected to would be the proxy's.
+var httpClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 10 * time.Second,
+			Control: DialControl(func() bool { return AllowPrivateAddresses }, ErrPrivateAddress),
+		}).DialContext,
+		TLSHandshakeTimeout: 10 * time.Second,
+	},
+}
+
+// Deliver makes a single signed POST of the JSON 'body' to url, refusing private addresses unless
+// AllowPrivateAddresses is on, returning the response
+// status code, an error is returned for network failures and non 2xx responses
+var Deliver = func(url, secret, event string, body []byte) (int, error) {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(DeliveryHeader, NewID())
+
+	timestamp := time.Now().Unix()
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(secret, timestamp, body))
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("webhook: %s returned status %d", url, res.StatusCode)
+	}
+
+	return res.StatusCode, nil
+}
+
+// Marshal encodes a Payload as the JSON body to Deliver
+func Marshal(payload Payload) ([]byte, error) {
+	return json.Marshal(payload)
+}
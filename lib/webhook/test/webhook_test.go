@@ -0,0 +1,134 @@
+package webhook_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sironfoot/go-twitter-bot/lib/webhook"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"event":"tweet.posted"}`)
+	now := time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	signature := webhook.Sign("a_very_secret_key", now.Unix(), body)
+	if len(signature) != len("sha256=")+64 || signature[:7] != "sha256=" {
+		t.Errorf("unexpected signature format '%s'", signature)
+	}
+
+	if !webhook.Verify("a_very_secret_key", body, timestamp, signature, now.Add(time.Minute)) {
+		t.Error("expected signature to verify")
+	}
+
+	if webhook.Verify("the_wrong_secret_key", body, timestamp, signature, now) {
+		t.Error("expected signature not to verify with a different secret")
+	}
+
+	if webhook.Verify("a_very_secret_key", []byte(`{"event":"tweet.failed"}`), timestamp, signature, now) {
+		t.Error("expected signature not to verify with a different body")
+	}
+
+	later := strconv.FormatInt(now.Add(time.Minute).Unix(), 10)
+	if webhook.Verify("a_very_secret_key", body, later, signature, now) {
+		t.Error("expected signature not to verify with a different timestamp")
+	}
+
+	if webhook.Verify("a_very_secret_key", body, timestamp, signature, now.Add(webhook.MaxTimestampAge+time.Second)) {
+		t.Error("expected a replayed request to be rejected once the timestamp is too old")
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	var testCases = []struct {
+		host    string
+		private bool
+	}{
+		{"example.com", false},
+		{"93.184.216.34", false},
+		{"localhost", true},
+		{"api.localhost", true},
+		{"127.0.0.1", true},
+		{"10.1.2.3", true},
+		{"192.168.0.1", true},
+		{"169.254.169.254", true},
+		{"::1", true},
+		{"fd00::1", true},
+		{"0.0.0.0", true},
+	}
+
+	for _, testCase := range testCases {
+		if err := webhook.CheckHost(testCase.host); (err == webhook.ErrPrivateAddress) != testCase.private {
+			t.Errorf("host '%s': expected private to be %v, got %v", testCase.host, testCase.private, err)
+		}
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	payload := webhook.NewPayload(webhook.EventTweetPosted, "mybot")
+	body, err := webhook.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		received, _ := ioutil.ReadAll(req.Body)
+
+		if req.Header.Get(webhook.EventHeader) != webhook.EventTweetPosted {
+			t.Errorf("expected event header '%s', got '%s'", webhook.EventTweetPosted, req.Header.Get(webhook.EventHeader))
+		}
+
+		if !webhook.Verify("a_very_secret_key", received, req.Header.Get(webhook.TimestampHeader), req.Header.Get(webhook.SignatureHeader), time.Now()) {
+			t.Error("expected a valid signature header")
+		}
+
+		if req.URL.Path == "/broken" {
+			res.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	// the test server listens on a loopback address
+	statusCode, err := webhook.Deliver(server.URL, "a_very_secret_key", payload.Event, body)
+	if err == nil || !strings.Contains(err.Error(), webhook.ErrPrivateAddress.Error()) {
+		t.Errorf("expected delivery to a loopback address to be refused, got %d: %v", statusCode, err)
+	}
+
+	webhook.AllowPrivateAddresses = true
+	defer func() {
+		webhook.AllowPrivateAddresses = false
+	}()
+
+	statusCode, err = webhook.Deliver(server.URL, "a_very_secret_key", payload.Event, body)
+	if err != nil || statusCode != http.StatusOK {
+		t.Errorf("expected successful delivery, got %d: %v", statusCode, err)
+	}
+
+	statusCode, err = webhook.Deliver(server.URL+"/broken", "a_very_secret_key", payload.Event, body)
+	if err == nil || statusCode != http.StatusInternalServerError {
+		t.Errorf("expected failed delivery with status 500, got %d: %v", statusCode, err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	testCases := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{4, 4 * time.Minute},
+		{20, time.Hour},
+	}
+
+	for _, testCase := range testCases {
+		if actual := webhook.Backoff(testCase.attempt); actual != testCase.expected {
+			t.Errorf("attempt %d: expected %s, got %s", testCase.attempt, testCase.expected, actual)
+		}
+	}
+}